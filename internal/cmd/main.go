@@ -12,6 +12,8 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/kihyun1998/prisma-market/prisma-email-service/internal/config"
 	"github.com/kihyun1998/prisma-market/prisma-email-service/internal/handlers"
+	"github.com/kihyun1998/prisma-market/prisma-email-service/internal/models"
+	"github.com/kihyun1998/prisma-market/prisma-email-service/internal/repository/filesystem"
 	"github.com/kihyun1998/prisma-market/prisma-email-service/internal/repository/mongodb"
 	"github.com/kihyun1998/prisma-market/prisma-email-service/internal/services"
 	"github.com/kihyun1998/prisma-market/prisma-email-service/pkg/queue"
@@ -40,19 +42,97 @@ func main() {
 		cfg.SMTPFrom,
 	)
 
+	// 메시지 브로커 초기화
+	broker, err := queue.NewBroker(queue.BrokerConfig{
+		Backend:            cfg.QueueBackend,
+		ConsumerGroup:      cfg.QueueConsumerGroup,
+		AMQPURL:            cfg.RabbitMQURL,
+		AMQPConfirmTimeout: cfg.RabbitMQConfirmTimeout,
+		KafkaBrokers:       cfg.KafkaBrokers,
+		NATSURL:            cfg.NATSURL,
+		RedisAddr:          cfg.RedisAddr,
+		RedisPassword:      cfg.RedisPassword,
+		RedisDB:            cfg.RedisDB,
+	})
+	if err != nil {
+		log.Fatalf("Failed to create message broker: %v", err)
+	}
+
+	// 템플릿 캐시 초기화 (변경 감시는 종료 시까지 실행)
+	var templateCache *services.TemplateCache
+	if cfg.TemplateCacheEnabled {
+		templateCache = services.NewTemplateCache(cfg.TemplateCacheTTL)
+	}
+
 	// 이메일 서비스 초기화
-	emailService := services.NewEmailService(templateRepo, smtpClient)
+	emailService := services.NewEmailService(templateRepo, smtpClient, broker, cfg.QueueName, cfg.TestSendAllowlist, templateCache, services.UnsubscribeOptions{
+		BaseURL: cfg.UnsubscribeBaseURL,
+		Secret:  cfg.UnsubscribeSecret,
+		Mailto:  cfg.UnsubscribeMailto,
+	})
+
+	// 수신 거부 설정 없이 남아 있는 마케팅 템플릿은 전송할 수 없다 (새 마케팅 템플릿은 저장 시 거부)
+	if cfg.UnsubscribeBaseURL == "" || cfg.UnsubscribeSecret == "" {
+		marketing, err := templateRepo.ListTemplates(context.Background(), &models.TemplateListOptions{
+			Limit:    1,
+			Sort:     "name",
+			Category: models.TemplateCategoryMarketing,
+			Summary:  true,
+		})
+		if err == nil && len(marketing.Templates) > 0 {
+			log.Printf("Warning: marketing templates exist (e.g., %q) but UNSUBSCRIBE_BASE_URL/UNSUBSCRIBE_SECRET are not set; they will be rejected on send", marketing.Templates[0].Name)
+		}
+	}
+
+	watchCtx, stopWatching := context.WithCancel(context.Background())
+	defer stopWatching()
+
+	// 템플릿 원본 설정 (수신 거부 목록과 감사 로그는 어느 경우든 MongoDB 사용)
+	switch cfg.TemplateSource {
+	case services.TemplateSourceMongoDB:
+		go emailService.WatchTemplateChanges(watchCtx)
+
+	case services.TemplateSourceFilesystem:
+		dirRepo := filesystem.NewTemplateRepository()
+		if err := emailService.ReloadTemplateDir(cfg.TemplateDir, dirRepo); err != nil {
+			log.Fatalf("Failed to load templates from %s: %v", cfg.TemplateDir, err)
+		}
+		emailService.UseReadOnlySource(dirRepo)
+		watchTemplateDir(watchCtx, cfg, func() {
+			if err := emailService.ReloadTemplateDir(cfg.TemplateDir, dirRepo); err != nil {
+				log.Printf("Failed to reload templates, keeping the previous set: %v", err)
+			}
+		})
+
+	case services.TemplateSourceSync:
+		if _, err := emailService.SyncTemplateDir(context.Background(), cfg.TemplateDir); err != nil {
+			log.Fatalf("Failed to sync templates from %s: %v", cfg.TemplateDir, err)
+		}
+		go emailService.WatchTemplateChanges(watchCtx)
+		watchTemplateDir(watchCtx, cfg, func() {
+			if _, err := emailService.SyncTemplateDir(watchCtx, cfg.TemplateDir); err != nil {
+				log.Printf("Failed to sync templates: %v", err)
+			}
+		})
+
+	default:
+		log.Fatalf("Unsupported TEMPLATE_SOURCE %q (expected mongodb, filesystem or sync)", cfg.TemplateSource)
+	}
 
-	// RabbitMQ 소비자 초기화
-	consumer, err := queue.NewConsumer(
-		cfg.RabbitMQURL,
-		cfg.RabbitMQQueue,
-		cfg.RabbitMQRetries,
+	// 큐 소비자 초기화
+	consumer := queue.NewConsumer(
+		broker,
+		cfg.QueueName,
+		cfg.QueueRetries,
+		queue.ConsumerOptions{
+			Workers:          cfg.QueueWorkers,
+			Prefetch:         cfg.QueuePrefetch,
+			OrderByRecipient: cfg.QueueOrderByRecipient,
+			MessageTimeout:   cfg.QueueMessageTimeout,
+			RetryDelay:       cfg.QueueRetryDelay,
+		},
 		emailService,
 	)
-	if err != nil {
-		log.Fatalf("Failed to create queue consumer: %v", err)
-	}
 
 	// 메시지 큐 소비자 시작
 	if err := consumer.Start(context.Background()); err != nil {
@@ -67,17 +147,50 @@ func main() {
 
 	// API 엔드포인트 등록
 	api := r.PathPrefix("/api/v1").Subrouter()
+	api.Use(handlers.ActorMiddleware)
 
 	// 이메일 전송 엔드포인트
 	api.HandleFunc("/email/send", emailHandler.SendEmail).Methods(http.MethodPost)
+	api.HandleFunc("/email/enqueue", emailHandler.EnqueueEmail).Methods(http.MethodPost)
 
 	// 템플릿 관리 엔드포인트
 	api.HandleFunc("/templates", emailHandler.CreateTemplate).Methods(http.MethodPost)
 	api.HandleFunc("/templates", emailHandler.ListTemplates).Methods(http.MethodGet)
+	api.HandleFunc("/templates/cache/stats", emailHandler.TemplateCacheStats).Methods(http.MethodGet)
+	api.HandleFunc("/templates/export", emailHandler.ExportTemplates).Methods(http.MethodGet)
+	api.HandleFunc("/templates/import", emailHandler.ImportTemplates).Methods(http.MethodPost)
 	api.HandleFunc("/templates/{id}", emailHandler.GetTemplate).Methods(http.MethodGet)
 	api.HandleFunc("/templates/{id}", emailHandler.UpdateTemplate).Methods(http.MethodPut)
 	api.HandleFunc("/templates/{id}", emailHandler.DeleteTemplate).Methods(http.MethodDelete)
 
+	// 템플릿 게시 및 감사 로그 엔드포인트
+	api.HandleFunc("/templates/{id}/publish", emailHandler.PublishTemplate).Methods(http.MethodPost)
+	api.HandleFunc("/templates/{id}/audit", emailHandler.ListTemplateAudit).Methods(http.MethodGet)
+
+	// 템플릿 버전 관리 엔드포인트
+	api.HandleFunc("/templates/{id}/versions", emailHandler.ListTemplateVersions).Methods(http.MethodGet)
+	api.HandleFunc("/templates/{id}/versions/{version:[0-9]+}", emailHandler.GetTemplateVersion).Methods(http.MethodGet)
+	api.HandleFunc("/templates/{id}/versions/{version:[0-9]+}/rollback", emailHandler.RollbackTemplate).Methods(http.MethodPost)
+	api.HandleFunc("/templates/{id}/diff", emailHandler.DiffTemplateVersions).Methods(http.MethodGet)
+
+	// 템플릿 렌더링/미리보기 엔드포인트 (전송하지 않음)
+	api.HandleFunc("/templates/{id}/render", emailHandler.RenderTemplate).Methods(http.MethodPost)
+	api.HandleFunc("/templates/{id}/preview", emailHandler.PreviewTemplate).Methods(http.MethodGet)
+	api.HandleFunc("/templates/{id}/test-send", emailHandler.TestSendTemplate).Methods(http.MethodPost)
+
+	// 공유 레이아웃(/layouts)과 부분 템플릿(/partials) 관리 엔드포인트
+	api.HandleFunc("/{kind:layouts|partials}", emailHandler.CreateFragment).Methods(http.MethodPost)
+	api.HandleFunc("/{kind:layouts|partials}", emailHandler.ListFragments).Methods(http.MethodGet)
+	api.HandleFunc("/{kind:layouts|partials}/{id}", emailHandler.GetFragment).Methods(http.MethodGet)
+	api.HandleFunc("/{kind:layouts|partials}/{id}", emailHandler.UpdateFragment).Methods(http.MethodPut)
+	api.HandleFunc("/{kind:layouts|partials}/{id}", emailHandler.DeleteFragment).Methods(http.MethodDelete)
+
+	// 수신 거부 엔드포인트 (메일의 링크와 원클릭 수신 거부)
+	api.HandleFunc("/unsubscribe", emailHandler.Unsubscribe).Methods(http.MethodGet, http.MethodPost)
+	api.HandleFunc("/suppressions", emailHandler.AddSuppression).Methods(http.MethodPost)
+	api.HandleFunc("/suppressions", emailHandler.ListSuppressions).Methods(http.MethodGet)
+	api.HandleFunc("/suppressions/{email}", emailHandler.DeleteSuppression).Methods(http.MethodDelete)
+
 	// HTTP 서버 설정
 	srv := &http.Server{
 		Addr:         ":" + cfg.ServerPort,
@@ -110,8 +223,10 @@ func main() {
 		log.Fatalf("Server forced to shutdown: %v", err)
 	}
 
-	// 리소스 정리
-	if err := consumer.Close(); err != nil {
+	stopWatching()
+
+	// 리소스 정리 (처리 중인 메시지는 마무리한 뒤 종료)
+	if err := consumer.Shutdown(ctx); err != nil {
 		log.Printf("Error closing consumer: %v", err)
 	}
 
@@ -121,3 +236,11 @@ func main() {
 
 	log.Println("Server exited properly")
 }
+
+// watchTemplateDir 템플릿 디렉터리가 바뀌면 onChange 실행 (TEMPLATE_DIR_POLL_INTERVAL이 0이면 감시하지 않음)
+func watchTemplateDir(ctx context.Context, cfg *config.Config, onChange func()) {
+	if cfg.TemplateDirPollInterval <= 0 {
+		return
+	}
+	go filesystem.Watch(ctx, cfg.TemplateDir, cfg.TemplateDirPollInterval, onChange)
+}
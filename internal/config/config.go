@@ -1,6 +1,12 @@
 package config
 
-import "github.com/spf13/viper"
+import (
+	"log"
+	"os"
+	"time"
+
+	"github.com/spf13/viper"
+)
 
 type Config struct {
 	ServerPort string `mapstructure:"SERVER_PORT"`
@@ -13,10 +19,60 @@ type Config struct {
 	SMTPPassword string `mapstructure:"SMTP_PASSWORD"`
 	SMTPFrom     string `mapstructure:"SMTP_FROM"`
 
-	// RabbitMQ 설정
-	RabbitMQURL     string `mapstructure:"RABBITMQ_URL"`
-	RabbitMQQueue   string `mapstructure:"RABBITMQ_QUEUE"`
-	RabbitMQRetries int    `mapstructure:"RABBITMQ_RETRIES"`
+	// 메시지 브로커 설정 (amqp | kafka | nats | redis | memory)
+	QueueBackend       string `mapstructure:"QUEUE_BACKEND"`
+	QueueConsumerGroup string `mapstructure:"QUEUE_CONSUMER_GROUP"`
+
+	// 큐 이름과 재시도 횟수 (모든 브로커에 공통 적용)
+	QueueName    string `mapstructure:"QUEUE_NAME"`
+	QueueRetries int    `mapstructure:"QUEUE_RETRIES"`
+
+	// RabbitMQ 설정 (발행 확인(publisher confirm) 대기 시간 포함)
+	RabbitMQURL            string        `mapstructure:"RABBITMQ_URL"`
+	RabbitMQConfirmTimeout time.Duration `mapstructure:"RABBITMQ_CONFIRM_TIMEOUT"`
+
+	// Kafka / NATS / Redis 설정
+	KafkaBrokers  []string `mapstructure:"KAFKA_BROKERS"`
+	NATSURL       string   `mapstructure:"NATS_URL"`
+	RedisAddr     string   `mapstructure:"REDIS_ADDR"`
+	RedisPassword string   `mapstructure:"REDIS_PASSWORD"`
+	RedisDB       int      `mapstructure:"REDIS_DB"`
+
+	// 소비자 동시성 설정
+	QueueWorkers          int           `mapstructure:"QUEUE_WORKERS"`
+	QueuePrefetch         int           `mapstructure:"QUEUE_PREFETCH"`
+	QueueOrderByRecipient bool          `mapstructure:"QUEUE_ORDER_BY_RECIPIENT"`
+	QueueMessageTimeout   time.Duration `mapstructure:"QUEUE_MESSAGE_TIMEOUT"`
+	QueueRetryDelay       time.Duration `mapstructure:"QUEUE_RETRY_DELAY"`
+
+	// 테스트 전송 허용 주소 (쉼표 구분, "@example.com"은 도메인 전체)
+	TestSendAllowlist []string `mapstructure:"TEST_SEND_ALLOWLIST"`
+
+	// 전송 경로 템플릿 캐시 (change stream을 쓸 수 없으면 TTL 후 다시 조회)
+	TemplateCacheEnabled bool          `mapstructure:"TEMPLATE_CACHE_ENABLED"`
+	TemplateCacheTTL     time.Duration `mapstructure:"TEMPLATE_CACHE_TTL"`
+
+	// 마케팅 템플릿 수신 거부 링크 (UNSUBSCRIBE_BASE_URL은 /api/v1/unsubscribe의 외부 URL)
+	UnsubscribeBaseURL string `mapstructure:"UNSUBSCRIBE_BASE_URL"`
+	UnsubscribeSecret  string `mapstructure:"UNSUBSCRIBE_SECRET"`
+	UnsubscribeMailto  string `mapstructure:"UNSUBSCRIBE_MAILTO"`
+
+	// 템플릿 원본 (mongodb | filesystem | sync)
+	// filesystem은 TEMPLATE_DIR을 읽기 전용 원본으로 사용하고, sync는 시작 시와 변경 시 MongoDB에 반영한다.
+	TemplateSource          string        `mapstructure:"TEMPLATE_SOURCE"`
+	TemplateDir             string        `mapstructure:"TEMPLATE_DIR"`
+	TemplateDirPollInterval time.Duration `mapstructure:"TEMPLATE_DIR_POLL_INTERVAL"` // 0이면 변경을 감시하지 않는다
+}
+
+// legacyQueueKeys 브로커 공통 설정의 이전 키 (QUEUE_* 키가 없을 때만 사용)
+var legacyQueueKeys = map[string]string{
+	"QUEUE_NAME":               "RABBITMQ_QUEUE",
+	"QUEUE_RETRIES":            "RABBITMQ_RETRIES",
+	"QUEUE_WORKERS":            "RABBITMQ_WORKERS",
+	"QUEUE_PREFETCH":           "RABBITMQ_PREFETCH",
+	"QUEUE_ORDER_BY_RECIPIENT": "RABBITMQ_ORDER_BY_RECIPIENT",
+	"QUEUE_MESSAGE_TIMEOUT":    "RABBITMQ_MESSAGE_TIMEOUT",
+	"QUEUE_RETRY_DELAY":        "RABBITMQ_RETRY_DELAY",
 }
 
 func LoadConfig() (*Config, error) {
@@ -26,14 +82,32 @@ func LoadConfig() (*Config, error) {
 	// 기본값 설정
 	viper.SetDefault("SERVER_PORT", "8003")
 	viper.SetDefault("SMTP_PORT", 587)
-	viper.SetDefault("RABBITMQ_QUEUE", "email_queue")
-	viper.SetDefault("RABBITMQ_RETRIES", 3)
+	viper.SetDefault("QUEUE_BACKEND", "amqp")
+	viper.SetDefault("QUEUE_CONSUMER_GROUP", "email-service")
+	viper.SetDefault("QUEUE_NAME", "email_queue")
+	viper.SetDefault("QUEUE_RETRIES", 3)
+	viper.SetDefault("RABBITMQ_CONFIRM_TIMEOUT", 5*time.Second)
+	viper.SetDefault("QUEUE_WORKERS", 4)
+	viper.SetDefault("QUEUE_PREFETCH", 8)
+	viper.SetDefault("QUEUE_ORDER_BY_RECIPIENT", false)
+	viper.SetDefault("QUEUE_MESSAGE_TIMEOUT", 30*time.Second)
+	viper.SetDefault("QUEUE_RETRY_DELAY", 5*time.Second)
+	viper.SetDefault("TEST_SEND_ALLOWLIST", "")
+	viper.SetDefault("TEMPLATE_CACHE_ENABLED", true)
+	viper.SetDefault("TEMPLATE_CACHE_TTL", time.Minute)
+	viper.SetDefault("UNSUBSCRIBE_BASE_URL", "")
+	viper.SetDefault("UNSUBSCRIBE_SECRET", "")
+	viper.SetDefault("UNSUBSCRIBE_MAILTO", "")
+	viper.SetDefault("TEMPLATE_SOURCE", "mongodb")
+	viper.SetDefault("TEMPLATE_DIR", "templates")
+	viper.SetDefault("TEMPLATE_DIR_POLL_INTERVAL", 5*time.Second)
 
 	if err := viper.ReadInConfig(); err != nil {
 		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
 			return nil, err
 		}
 	}
+	applyLegacyKeys()
 
 	config := &Config{}
 	if err := viper.Unmarshal(config); err != nil {
@@ -42,3 +116,22 @@ func LoadConfig() (*Config, error) {
 
 	return config, nil
 }
+
+// applyLegacyKeys 새 키가 설정되지 않았으면 이전 키의 값을 사용한다
+func applyLegacyKeys() {
+	for key, legacy := range legacyQueueKeys {
+		if isExplicit(key) || !isExplicit(legacy) {
+			continue
+		}
+		log.Printf("%s is deprecated, use %s instead", legacy, key)
+		viper.Set(key, viper.Get(legacy))
+	}
+}
+
+// isExplicit 환경 변수나 설정 파일에 값이 있는지 (기본값 제외)
+func isExplicit(key string) bool {
+	if _, ok := os.LookupEnv(key); ok {
+		return true
+	}
+	return viper.InConfig(key)
+}
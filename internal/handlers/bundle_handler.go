@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/kihyun1998/prisma-market/prisma-email-service/internal/models"
+	"github.com/kihyun1998/prisma-market/prisma-email-service/internal/services"
+)
+
+// 가져오기 요청 본문 최대 크기
+const maxImportBodySize = 50 << 20
+
+// ExportTemplates 템플릿 번들 내보내기 (?name=a&name=b&format=json|tar, 이름이 없으면 전체)
+// 템플릿/레이아웃/부분 템플릿만 담으며 템플릿이 참조하는 이미지 등 에셋은 내보내지 않는다.
+func (h *EmailHandler) ExportTemplates(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	bundle, err := h.emailService.ExportBundle(r.Context(), query["name"])
+	if err != nil {
+		h.sendServiceError(w, err)
+		return
+	}
+
+	filename := "templates-" + bundle.ExportedAt.Format("20060102-150405")
+	switch query.Get("format") {
+	case "", "json":
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename+".json"))
+		json.NewEncoder(w).Encode(bundle)
+	case "tar":
+		w.Header().Set("Content-Type", "application/x-tar")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename+".tar"))
+		if err := services.WriteBundleTar(w, bundle); err != nil {
+			h.sendError(w, err.Error(), http.StatusInternalServerError)
+		}
+	default:
+		h.sendError(w, "Invalid format (expected json or tar)", http.StatusBadRequest)
+	}
+}
+
+// ImportTemplates 템플릿 번들 가져오기 (?dry_run=true&conflict=skip|overwrite|new_version)
+// 본문은 JSON 번들이며, Content-Type이 application/x-tar이면 tar 번들로 읽는다.
+// 에셋 승격은 하지 않는다: 템플릿이 참조하는 에셋 URL은 그대로 저장되며 대상 환경에서 접근 가능한지 확인하지 않는다.
+func (h *EmailHandler) ImportTemplates(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	dryRun, _ := strconv.ParseBool(query.Get("dry_run"))
+	body := http.MaxBytesReader(w, r.Body, maxImportBodySize)
+
+	var bundle *models.Bundle
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/x-tar") {
+		var err error
+		if bundle, err = services.ReadBundleTar(body); err != nil {
+			h.sendServiceError(w, err)
+			return
+		}
+	} else {
+		bundle = &models.Bundle{}
+		if err := json.NewDecoder(body).Decode(bundle); err != nil {
+			h.sendError(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	result, err := h.emailService.ImportBundle(r.Context(), bundle, &models.ImportOptions{
+		DryRun:   dryRun,
+		Conflict: query.Get("conflict"),
+	})
+	if err != nil && result != nil {
+		// 일부 항목만 반영된 경우 반영된 항목을 함께 알려준다
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(result)
+		return
+	}
+	if err != nil {
+		h.sendServiceError(w, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(result)
+}
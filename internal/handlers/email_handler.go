@@ -2,13 +2,16 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"strconv"
 
 	"github.com/gorilla/mux"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 
 	"github.com/kihyun1998/prisma-market/prisma-email-service/internal/models"
 	"github.com/kihyun1998/prisma-market/prisma-email-service/internal/services"
+	"github.com/kihyun1998/prisma-market/prisma-email-service/pkg/queue"
 )
 
 type EmailHandler struct {
@@ -16,7 +19,9 @@ type EmailHandler struct {
 }
 
 type ErrorResponse struct {
-	Error string `json:"error"`
+	Error   string                `json:"error"`
+	Code    string                `json:"code,omitempty"`
+	Details []services.FieldError `json:"details,omitempty"`
 }
 
 func NewEmailHandler(emailService *services.EmailService) *EmailHandler {
@@ -34,7 +39,7 @@ func (h *EmailHandler) SendEmail(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := h.emailService.ProcessEmailRequest(r.Context(), &req); err != nil {
-		h.sendError(w, err.Error(), http.StatusInternalServerError)
+		h.sendServiceError(w, err)
 		return
 	}
 
@@ -44,6 +49,39 @@ func (h *EmailHandler) SendEmail(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// EnqueueEmail 이메일 요청을 큐에 넣고 바로 응답
+func (h *EmailHandler) EnqueueEmail(w http.ResponseWriter, r *http.Request) {
+	var req models.EmailRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.sendError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// 요청 추적 정보를 큐 메시지로 전달
+	ctx := queue.ContextWithMetadata(r.Context(), queue.Metadata{
+		CorrelationID: r.Header.Get("X-Correlation-ID"),
+		TraceParent:   r.Header.Get("traceparent"),
+		TraceState:    r.Header.Get("tracestate"),
+	})
+
+	messageID, err := h.emailService.EnqueueEmailRequest(ctx, &req)
+	if err != nil {
+		var decodeErr *queue.DecodeError
+		if errors.As(err, &decodeErr) {
+			h.sendError(w, decodeErr.Error(), http.StatusBadRequest)
+			return
+		}
+		h.sendError(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]string{
+		"message":    "Email request queued",
+		"message_id": messageID,
+	})
+}
+
 // CreateTemplate 새 템플릿 생성
 func (h *EmailHandler) CreateTemplate(w http.ResponseWriter, r *http.Request) {
 	var template models.Template
@@ -53,7 +91,7 @@ func (h *EmailHandler) CreateTemplate(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := h.emailService.CreateTemplate(r.Context(), &template); err != nil {
-		h.sendError(w, err.Error(), http.StatusInternalServerError)
+		h.sendServiceError(w, err)
 		return
 	}
 
@@ -100,7 +138,7 @@ func (h *EmailHandler) UpdateTemplate(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := h.emailService.UpdateTemplate(r.Context(), id, &template); err != nil {
-		h.sendError(w, err.Error(), http.StatusInternalServerError)
+		h.sendServiceError(w, err)
 		return
 	}
 
@@ -118,7 +156,7 @@ func (h *EmailHandler) DeleteTemplate(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := h.emailService.DeleteTemplate(r.Context(), id); err != nil {
-		h.sendError(w, err.Error(), http.StatusInternalServerError)
+		h.sendServiceError(w, err)
 		return
 	}
 
@@ -128,15 +166,269 @@ func (h *EmailHandler) DeleteTemplate(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// ListTemplates 모든 템플릿 조회
+// ListTemplates 템플릿 목록 조회
+// (?limit=20&cursor=...&sort=-updated_at&tag=auth&category=marketing&team=orders&locale=ko-KR&status=published&q=reset&view=summary)
 func (h *EmailHandler) ListTemplates(w http.ResponseWriter, r *http.Request) {
-	templates, err := h.emailService.ListTemplates(r.Context())
+	query := r.URL.Query()
+	opts := &models.TemplateListOptions{
+		Cursor:   query.Get("cursor"),
+		Sort:     query.Get("sort"),
+		Tag:      query.Get("tag"),
+		Category: query.Get("category"),
+		Team:     query.Get("team"),
+		Locale:   query.Get("locale"),
+		Status:   query.Get("status"),
+		Search:   query.Get("q"),
+		Summary:  query.Get("view") == "summary",
+	}
+	if limit := query.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil {
+			h.sendError(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		opts.Limit = n
+	}
+
+	list, err := h.emailService.ListTemplates(r.Context(), opts)
+	if err != nil {
+		h.sendServiceError(w, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(list)
+}
+
+// TemplateCacheStats 템플릿 캐시 적중률과 무효화 방식 조회
+func (h *EmailHandler) TemplateCacheStats(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(h.emailService.TemplateCacheStats())
+}
+
+// PublishTemplate 템플릿 초안을 게시
+func (h *EmailHandler) PublishTemplate(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := primitive.ObjectIDFromHex(vars["id"])
+	if err != nil {
+		h.sendError(w, "Invalid template ID", http.StatusBadRequest)
+		return
+	}
+
+	// 게시 사유 (선택)
+	var req struct {
+		Comment string `json:"comment"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			h.sendError(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	template, err := h.emailService.PublishTemplate(r.Context(), id, req.Comment)
+	if err != nil {
+		h.sendServiceError(w, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(template)
+}
+
+// ListTemplateAudit 템플릿 감사 로그 조회
+func (h *EmailHandler) ListTemplateAudit(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := primitive.ObjectIDFromHex(vars["id"])
+	if err != nil {
+		h.sendError(w, "Invalid template ID", http.StatusBadRequest)
+		return
+	}
+
+	entries, err := h.emailService.ListTemplateAudit(r.Context(), id)
+	if err != nil {
+		h.sendError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(entries)
+}
+
+// ListTemplateVersions 템플릿 버전 이력 조회
+func (h *EmailHandler) ListTemplateVersions(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := primitive.ObjectIDFromHex(vars["id"])
+	if err != nil {
+		h.sendError(w, "Invalid template ID", http.StatusBadRequest)
+		return
+	}
+
+	versions, err := h.emailService.ListTemplateVersions(r.Context(), id)
+	if err != nil {
+		h.sendError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(versions)
+}
+
+// GetTemplateVersion 특정 버전 조회
+func (h *EmailHandler) GetTemplateVersion(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := primitive.ObjectIDFromHex(vars["id"])
+	if err != nil {
+		h.sendError(w, "Invalid template ID", http.StatusBadRequest)
+		return
+	}
+
+	version, err := strconv.Atoi(vars["version"])
+	if err != nil {
+		h.sendError(w, "Invalid template version", http.StatusBadRequest)
+		return
+	}
+
+	templateVersion, err := h.emailService.GetTemplateVersion(r.Context(), id, version)
 	if err != nil {
 		h.sendError(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	json.NewEncoder(w).Encode(templates)
+	if templateVersion == nil {
+		h.sendError(w, "Template version not found", http.StatusNotFound)
+		return
+	}
+
+	json.NewEncoder(w).Encode(templateVersion)
+}
+
+// DiffTemplateVersions 두 버전 비교 (?from=1&to=2)
+func (h *EmailHandler) DiffTemplateVersions(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := primitive.ObjectIDFromHex(vars["id"])
+	if err != nil {
+		h.sendError(w, "Invalid template ID", http.StatusBadRequest)
+		return
+	}
+
+	from, err := strconv.Atoi(r.URL.Query().Get("from"))
+	if err != nil {
+		h.sendError(w, "Invalid from version", http.StatusBadRequest)
+		return
+	}
+	to, err := strconv.Atoi(r.URL.Query().Get("to"))
+	if err != nil {
+		h.sendError(w, "Invalid to version", http.StatusBadRequest)
+		return
+	}
+
+	diff, err := h.emailService.DiffTemplateVersions(r.Context(), id, from, to)
+	if err != nil {
+		h.sendServiceError(w, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(diff)
+}
+
+// RollbackTemplate 이전 버전으로 되돌리기 (새 버전으로 기록)
+func (h *EmailHandler) RollbackTemplate(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := primitive.ObjectIDFromHex(vars["id"])
+	if err != nil {
+		h.sendError(w, "Invalid template ID", http.StatusBadRequest)
+		return
+	}
+
+	version, err := strconv.Atoi(vars["version"])
+	if err != nil {
+		h.sendError(w, "Invalid template version", http.StatusBadRequest)
+		return
+	}
+
+	template, err := h.emailService.RollbackTemplate(r.Context(), id, version)
+	if err != nil {
+		h.sendServiceError(w, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(template)
+}
+
+// RenderTemplate 전송 없이 렌더링 결과 반환
+func (h *EmailHandler) RenderTemplate(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := primitive.ObjectIDFromHex(vars["id"])
+	if err != nil {
+		h.sendError(w, "Invalid template ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.RenderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.sendError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	rendered, err := h.emailService.RenderTemplate(r.Context(), id, &req)
+	if err != nil {
+		h.sendServiceError(w, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(rendered)
+}
+
+// PreviewTemplate 저장된 예시 변수로 렌더링한 HTML 반환 (?sample=long+name&locale=ko-KR&published=true)
+func (h *EmailHandler) PreviewTemplate(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := primitive.ObjectIDFromHex(vars["id"])
+	if err != nil {
+		h.sendError(w, "Invalid template ID", http.StatusBadRequest)
+		return
+	}
+
+	query := r.URL.Query()
+	published, _ := strconv.ParseBool(query.Get("published"))
+
+	rendered, err := h.emailService.PreviewTemplate(r.Context(), id, query.Get("sample"), query.Get("locale"), published)
+	if err != nil {
+		h.sendServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write([]byte(rendered.HTML))
+}
+
+// TestSendTemplate 허용된 내부 주소로 테스트 메일 전송
+func (h *EmailHandler) TestSendTemplate(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := primitive.ObjectIDFromHex(vars["id"])
+	if err != nil {
+		h.sendError(w, "Invalid template ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.TestSendRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.sendError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	response, err := h.emailService.TestSendTemplate(r.Context(), id, &req)
+	if err != nil {
+		h.sendServiceError(w, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
+
+// ActorMiddleware X-Actor 헤더의 요청자를 감사 로그용으로 컨텍스트에 저장
+func ActorMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if actor := r.Header.Get("X-Actor"); actor != "" {
+			r = r.WithContext(services.ContextWithActor(r.Context(), actor))
+		}
+		next.ServeHTTP(w, r)
+	})
 }
 
 // sendError 에러 응답 전송 헬퍼 함수
@@ -145,3 +437,37 @@ func (h *EmailHandler) sendError(w http.ResponseWriter, message string, status i
 	w.WriteHeader(status)
 	json.NewEncoder(w).Encode(ErrorResponse{Error: message})
 }
+
+// sendServiceError 서비스 에러 코드에 맞는 상태 코드로 에러 응답 전송
+func (h *EmailHandler) sendServiceError(w http.ResponseWriter, err error) {
+	var serviceErr *services.Error
+	if !errors.As(err, &serviceErr) {
+		h.sendError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	status := http.StatusInternalServerError
+	switch serviceErr.Code {
+	case services.ErrCodeInvalidRequest, services.ErrCodeInvalidTemplateID, services.ErrCodeTemplateRefRequired,
+		services.ErrCodeInvalidSchema, services.ErrCodeInvalidTemplateSyntax, services.ErrCodeInvalidFragment,
+		services.ErrCodeIncludeCycle, services.ErrCodeInvalidUnsubscribeToken, services.ErrCodeInvalidBundle:
+		status = http.StatusBadRequest
+	case services.ErrCodeInvalidVariables, services.ErrCodeRenderFailed:
+		status = http.StatusUnprocessableEntity
+	case services.ErrCodeRecipientNotAllowed:
+		status = http.StatusForbidden
+	case services.ErrCodeTemplateIDNotFound, services.ErrCodeTemplateNameNotFound, services.ErrCodeTemplateVersionNotFound,
+		services.ErrCodeSampleNotFound, services.ErrCodeLayoutNotFound, services.ErrCodePartialNotFound:
+		status = http.StatusNotFound
+	case services.ErrCodeTemplateNotPublished, services.ErrCodeReadOnlySource, services.ErrCodeUnsubscribeNotConfigured:
+		status = http.StatusConflict
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(ErrorResponse{
+		Error:   serviceErr.Message,
+		Code:    serviceErr.Code,
+		Details: serviceErr.Details,
+	})
+}
@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/kihyun1998/prisma-market/prisma-email-service/internal/models"
+)
+
+// fragmentKind 경로(/layouts, /partials)에 해당하는 조각 종류
+func fragmentKind(r *http.Request) string {
+	if mux.Vars(r)["kind"] == "layouts" {
+		return models.FragmentKindLayout
+	}
+	return models.FragmentKindPartial
+}
+
+// CreateFragment 레이아웃 또는 부분 템플릿 생성
+func (h *EmailHandler) CreateFragment(w http.ResponseWriter, r *http.Request) {
+	var fragment models.Fragment
+	if err := json.NewDecoder(r.Body).Decode(&fragment); err != nil {
+		h.sendError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	fragment.Kind = fragmentKind(r)
+
+	if err := h.emailService.CreateFragment(r.Context(), &fragment); err != nil {
+		h.sendServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(fragment)
+}
+
+// GetFragment 레이아웃 또는 부분 템플릿 조회
+func (h *EmailHandler) GetFragment(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := primitive.ObjectIDFromHex(vars["id"])
+	if err != nil {
+		h.sendError(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	fragment, err := h.emailService.GetFragment(r.Context(), fragmentKind(r), id)
+	if err != nil {
+		h.sendError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if fragment == nil {
+		h.sendError(w, "Not found", http.StatusNotFound)
+		return
+	}
+
+	json.NewEncoder(w).Encode(fragment)
+}
+
+// UpdateFragment 레이아웃 또는 부분 템플릿 수정
+func (h *EmailHandler) UpdateFragment(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := primitive.ObjectIDFromHex(vars["id"])
+	if err != nil {
+		h.sendError(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	var fragment models.Fragment
+	if err := json.NewDecoder(r.Body).Decode(&fragment); err != nil {
+		h.sendError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	fragment.Kind = fragmentKind(r)
+
+	if err := h.emailService.UpdateFragment(r.Context(), id, &fragment); err != nil {
+		h.sendServiceError(w, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(fragment)
+}
+
+// DeleteFragment 레이아웃 또는 부분 템플릿 삭제
+func (h *EmailHandler) DeleteFragment(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := primitive.ObjectIDFromHex(vars["id"])
+	if err != nil {
+		h.sendError(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.emailService.DeleteFragment(r.Context(), fragmentKind(r), id); err != nil {
+		h.sendServiceError(w, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Deleted successfully",
+	})
+}
+
+// ListFragments 레이아웃 또는 부분 템플릿 목록 조회
+func (h *EmailHandler) ListFragments(w http.ResponseWriter, r *http.Request) {
+	fragments, err := h.emailService.ListFragments(r.Context(), fragmentKind(r))
+	if err != nil {
+		h.sendError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(fragments)
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/kihyun1998/prisma-market/prisma-email-service/internal/models"
+)
+
+// 수신 거부 확인/완료 페이지 (메일 스캐너나 링크 미리 읽기가 GET으로 수신 거부하지 않도록 확인 버튼을 눌러야 등록된다)
+var unsubscribePages = template.Must(template.New("unsubscribe").Parse(`
+{{define "confirm"}}<!DOCTYPE html>
+<html><body>
+<p>Unsubscribe {{.Email}} from these emails?</p>
+<form method="post" action="?token={{.Token}}"><button type="submit">Unsubscribe</button></form>
+</body></html>{{end}}
+{{define "done"}}<!DOCTYPE html><html><body><p>You have been unsubscribed.</p></body></html>{{end}}`))
+
+// Unsubscribe 수신 거부 링크(GET)는 확인 페이지만 보여 주고, POST(확인 버튼, RFC 8058 원클릭)에서 등록 (?token=...)
+func (h *EmailHandler) Unsubscribe(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+
+	if r.Method != http.MethodPost {
+		email, err := h.emailService.UnsubscribeAddress(token)
+		if err != nil {
+			h.sendServiceError(w, err)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		unsubscribePages.ExecuteTemplate(w, "confirm", map[string]string{"Email": email, "Token": token})
+		return
+	}
+
+	if err := h.emailService.Unsubscribe(r.Context(), token); err != nil {
+		h.sendServiceError(w, err)
+		return
+	}
+
+	// 메일 클라이언트의 원클릭 요청은 본문을 사용하지 않는다
+	if r.PostFormValue("List-Unsubscribe") == "One-Click" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	unsubscribePages.ExecuteTemplate(w, "done", nil)
+}
+
+// AddSuppression 수신 거부 주소 직접 등록
+func (h *EmailHandler) AddSuppression(w http.ResponseWriter, r *http.Request) {
+	var suppression models.Suppression
+	if err := json.NewDecoder(r.Body).Decode(&suppression); err != nil {
+		h.sendError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.emailService.AddSuppression(r.Context(), &suppression); err != nil {
+		h.sendServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(suppression)
+}
+
+// DeleteSuppression 수신 거부 해제
+func (h *EmailHandler) DeleteSuppression(w http.ResponseWriter, r *http.Request) {
+	if err := h.emailService.DeleteSuppression(r.Context(), mux.Vars(r)["email"]); err != nil {
+		h.sendError(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Suppression deleted successfully",
+	})
+}
+
+// ListSuppressions 수신 거부 주소 목록
+func (h *EmailHandler) ListSuppressions(w http.ResponseWriter, r *http.Request) {
+	suppressions, err := h.emailService.ListSuppressions(r.Context())
+	if err != nil {
+		h.sendError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(suppressions)
+}
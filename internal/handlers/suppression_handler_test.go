@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/kihyun1998/prisma-market/prisma-email-service/internal/services"
+)
+
+const testUnsubscribeSecret = "secret"
+
+func testUnsubscribeToken(email string) string {
+	mac := hmac.New(sha256.New, []byte(testUnsubscribeSecret))
+	mac.Write([]byte(email))
+	return base64.RawURLEncoding.EncodeToString([]byte(email)) + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestUnsubscribeGETOnlyRendersConfirmation(t *testing.T) {
+	// 리포지토리가 없으므로 GET에서 수신 거부를 등록하려 하면 패닉이 난다
+	service := services.NewEmailService(nil, nil, nil, "", nil, nil, services.UnsubscribeOptions{
+		BaseURL: "https://mail.example.com/api/v1/unsubscribe",
+		Secret:  testUnsubscribeSecret,
+	})
+	h := NewEmailHandler(service)
+
+	token := testUnsubscribeToken("user@example.com")
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/unsubscribe?token="+url.QueryEscape(token), nil)
+	rec := httptest.NewRecorder()
+	h.Unsubscribe(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", rec.Code, rec.Body)
+	}
+	body := rec.Body.String()
+	if !strings.Contains(body, `<form method="post"`) || !strings.Contains(body, "user@example.com") {
+		t.Errorf("confirmation page missing form or address:\n%s", body)
+	}
+}
+
+func TestUnsubscribeRejectsInvalidToken(t *testing.T) {
+	service := services.NewEmailService(nil, nil, nil, "", nil, nil, services.UnsubscribeOptions{
+		BaseURL: "https://mail.example.com/api/v1/unsubscribe",
+		Secret:  testUnsubscribeSecret,
+	})
+	h := NewEmailHandler(service)
+
+	for _, method := range []string{http.MethodGet, http.MethodPost} {
+		req := httptest.NewRequest(method, "/api/v1/unsubscribe?token=bogus", nil)
+		rec := httptest.NewRecorder()
+		h.Unsubscribe(rec, req)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s status = %d, want 400", method, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package models
+
+import "time"
+
+// BundleFormatVersion 내보내기 번들 형식 버전
+const BundleFormatVersion = 1
+
+// Bundle 환경 간 이동을 위한 템플릿/레이아웃/부분 템플릿 묶음
+// ObjectID는 환경마다 다르므로 포함하지 않고 이름으로 대응시킨다.
+// 이미지 등 에셋은 서비스가 저장하지 않으므로 (템플릿은 외부 URL로 참조) 번들에 포함하지 않는다.
+// 에셋을 다른 환경으로 옮기는 일(에셋 승격)은 내보내기/가져오기의 범위 밖이며, 참조하는 URL은 모든 환경에서 접근할 수 있어야 한다.
+type Bundle struct {
+	FormatVersion int              `json:"format_version"`
+	ExportedAt    time.Time        `json:"exported_at"`
+	Templates     []BundleTemplate `json:"templates"`
+	Layouts       []BundleFragment `json:"layouts,omitempty"`
+	Partials      []BundleFragment `json:"partials,omitempty"`
+}
+
+// BundleTemplate 번들에 담긴 템플릿
+type BundleTemplate struct {
+	Name    string          `json:"name"`
+	Status  string          `json:"status"`            // 내보낸 환경의 상태 (published면 가져온 뒤 게시)
+	Version int             `json:"version,omitempty"` // 내보낸 환경의 게시 버전 (참고용)
+	Content TemplateContent `json:"content"`           // 게시된 본문 (게시 전이면 초안)
+
+	SampleData map[string]interface{}            `json:"sample_data,omitempty"`
+	Samples    map[string]map[string]interface{} `json:"samples,omitempty"`
+
+	Tags        []string `json:"tags,omitempty"`
+	Category    string   `json:"category,omitempty"`
+	Team        string   `json:"team,omitempty"`
+	Description string   `json:"description,omitempty"`
+}
+
+// BundleFragment 번들에 담긴 레이아웃 또는 부분 템플릿
+type BundleFragment struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	HTMLContent string `json:"html_content"`
+	TextContent string `json:"text_content"`
+}
+
+// 가져오기 시 같은 이름이 이미 있을 때의 처리 방식
+const (
+	ImportConflictSkip       = "skip"        // 기존 항목 유지
+	ImportConflictOverwrite  = "overwrite"   // 템플릿은 초안과 분류 정보를 교체 (게시는 별도), 레이아웃/부분 템플릿은 교체
+	ImportConflictNewVersion = "new_version" // 템플릿은 초안을 교체한 뒤 새 버전으로 게시, 레이아웃/부분 템플릿은 교체
+)
+
+// ImportOptions 가져오기 옵션
+type ImportOptions struct {
+	DryRun   bool   // true면 변경 내용만 계산하고 저장하지 않는다
+	Conflict string // skip | overwrite | new_version (비어 있으면 skip)
+}
+
+// 가져오기 항목별 처리 결과
+const (
+	ImportActionCreate     = "create"
+	ImportActionOverwrite  = "overwrite"
+	ImportActionNewVersion = "new_version"
+	ImportActionSkip       = "skip"
+	ImportActionUnchanged  = "unchanged"
+)
+
+// ImportItem 가져오기 항목 하나의 처리 계획/결과
+type ImportItem struct {
+	Kind    string            `json:"kind"` // template | layout | partial
+	Name    string            `json:"name"`
+	Action  string            `json:"action"`
+	Version int               `json:"version,omitempty"` // 가져온 뒤 게시된 버전
+	Changes map[string]string `json:"changes,omitempty"` // 필드 이름 -> 현재 내용과의 unified diff
+
+	Warnings []string `json:"warnings,omitempty"`
+	Error    string   `json:"error,omitempty"` // 검증 실패 (하나라도 있으면 아무것도 저장하지 않는다)
+}
+
+// ImportResult 가져오기 결과
+type ImportResult struct {
+	DryRun bool         `json:"dry_run"`
+	Items  []ImportItem `json:"items"`
+
+	// 저장 도중 실패한 경우의 에러 (Items는 실패 전까지 반영된 항목, 저장은 트랜잭션이 아니다)
+	Error string `json:"error,omitempty"`
+}
@@ -0,0 +1,20 @@
+package models
+
+// 템플릿 캐시 무효화 방식
+const (
+	CacheModeDisabled     = "disabled"      // 캐시 사용 안 함
+	CacheModeChangeStream = "change_stream" // MongoDB change stream 이벤트로 무효화
+	CacheModeTTL          = "ttl"           // change stream을 쓸 수 없어 일정 시간 후 다시 조회
+)
+
+// TemplateCacheStats 전송 경로 템플릿 캐시 현황
+type TemplateCacheStats struct {
+	Mode          string  `json:"mode"`
+	TTL           string  `json:"ttl,omitempty"`
+	Documents     int     `json:"documents"` // 캐시된 템플릿 문서 수 (ID/이름/고정 버전별)
+	Compiled      int     `json:"compiled"`  // 파싱된 템플릿 수 (ID, 버전, 언어별)
+	Hits          uint64  `json:"hits"`
+	Misses        uint64  `json:"misses"`
+	HitRatio      float64 `json:"hit_ratio"`
+	Invalidations uint64  `json:"invalidations"`
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// 공유 템플릿 조각 종류
+const (
+	FragmentKindLayout  = "layout"  // 본문을 감싸는 레이아웃 ({{template "content" .}} 위치에 본문 삽입)
+	FragmentKindPartial = "partial" // 이름으로 포함하는 부분 템플릿 ({{template "footer" .}})
+)
+
+// Fragment 여러 템플릿이 공유하는 레이아웃 또는 부분 템플릿
+// 버전 관리 대상이 아니며, 수정하면 이를 사용하는 모든 템플릿에 즉시 반영된다.
+type Fragment struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	Kind        string             `bson:"kind" json:"kind"`
+	Name        string             `bson:"name" json:"name"` // 템플릿에서 참조하는 이름 (e.g., "default", "footer")
+	Description string             `bson:"description,omitempty" json:"description,omitempty"`
+	HTMLContent string             `bson:"html_content" json:"html_content"`
+	TextContent string             `bson:"text_content" json:"text_content"`
+	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt   time.Time          `bson:"updated_at" json:"updated_at"`
+
+	// 저장 시 검사 경고 (저장되지 않음)
+	Warnings []string `bson:"-" json:"warnings,omitempty"`
+}
@@ -0,0 +1,39 @@
+package models
+
+// 변수 타입
+const (
+	SchemaTypeObject  = "object"
+	SchemaTypeArray   = "array"
+	SchemaTypeString  = "string"
+	SchemaTypeNumber  = "number"
+	SchemaTypeInteger = "integer"
+	SchemaTypeBoolean = "boolean"
+)
+
+// 문자열 형식
+const (
+	SchemaFormatEmail    = "email"
+	SchemaFormatURL      = "url"
+	SchemaFormatDate     = "date"      // 2006-01-02
+	SchemaFormatDateTime = "date-time" // RFC 3339
+)
+
+// VariableSchema JSON Schema 형식의 템플릿 변수 정의
+// 템플릿의 루트 스키마는 object 타입이며 Properties에 변수별 정의를 둔다.
+type VariableSchema struct {
+	Type        string                     `bson:"type" json:"type"`
+	Description string                     `bson:"description,omitempty" json:"description,omitempty"`
+	Properties  map[string]*VariableSchema `bson:"properties,omitempty" json:"properties,omitempty"` // object 필드
+	Required    []string                   `bson:"required,omitempty" json:"required,omitempty"`     // object 필수 필드
+	Items       *VariableSchema            `bson:"items,omitempty" json:"items,omitempty"`           // array 원소
+	Default     interface{}                `bson:"default,omitempty" json:"default,omitempty"`       // 값이 없을 때 사용
+	Format      string                     `bson:"format,omitempty" json:"format,omitempty"`         // string 형식 (email, url, date, date-time)
+	Enum        []interface{}              `bson:"enum,omitempty" json:"enum,omitempty"`
+	Pattern     string                     `bson:"pattern,omitempty" json:"pattern,omitempty"` // string 정규식
+	MinLength   *int                       `bson:"min_length,omitempty" json:"min_length,omitempty"`
+	MaxLength   *int                       `bson:"max_length,omitempty" json:"max_length,omitempty"`
+	Minimum     *float64                   `bson:"minimum,omitempty" json:"minimum,omitempty"`
+	Maximum     *float64                   `bson:"maximum,omitempty" json:"maximum,omitempty"`
+	MinItems    *int                       `bson:"min_items,omitempty" json:"min_items,omitempty"`
+	MaxItems    *int                       `bson:"max_items,omitempty" json:"max_items,omitempty"`
+}
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// 수신 거부 사유
+const (
+	SuppressionReasonUnsubscribe = "unsubscribe" // 수신 거부 링크 또는 원클릭 수신 거부
+	SuppressionReasonManual      = "manual"      // 운영자가 직접 등록
+)
+
+// Suppression 마케팅 메일을 보내지 않을 주소
+type Suppression struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	Email     string             `bson:"email" json:"email"` // 소문자로 정규화된 주소
+	Reason    string             `bson:"reason" json:"reason"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+}
@@ -6,29 +6,238 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// TemplateContent 템플릿 본문 (버전 관리 대상)
+type TemplateContent struct {
+	Subject     string   `bson:"subject" json:"subject"`           // 이메일 제목
+	HTMLContent string   `bson:"html_content" json:"html_content"` // HTML 형식 내용
+	TextContent string   `bson:"text_content" json:"text_content"` // 텍스트 형식 내용
+	Variables   []string `bson:"variables" json:"variables"`       // 템플릿 변수 목록 (Schema가 없을 때 필수 변수로 사용)
+
+	// 받은편지함 미리보기 문구 (HTML 본문 앞에 숨겨서 삽입)
+	Preheader string `bson:"preheader,omitempty" json:"preheader,omitempty"`
+
+	// 본문 작성 형식 (html | markdown | mjml, 비어 있으면 html)
+	// markdown이면 MarkdownContent를 HTML로 변환해 레이아웃에 넣고, TextContent가 비어 있으면 마크다운 원문을 텍스트 본문으로 사용한다.
+	// mjml이면 저장 시 MJMLContent를 표 기반 HTML로 컴파일해 CompiledHTML에 저장하고 전송 시 이를 사용한다.
+	Format          string `bson:"format,omitempty" json:"format,omitempty"`
+	MarkdownContent string `bson:"markdown_content,omitempty" json:"markdown_content,omitempty"`
+	MJMLContent     string `bson:"mjml_content,omitempty" json:"mjml_content,omitempty"`
+	CompiledHTML    string `bson:"compiled_html,omitempty" json:"compiled_html,omitempty"`
+
+	// 본문을 감쌀 레이아웃 이름 (비어 있으면 레이아웃 없이 본문만 사용)
+	Layout string `bson:"layout,omitempty" json:"layout,omitempty"`
+
+	// 변수 타입/필수 여부/기본값 정의
+	Schema *VariableSchema `bson:"schema,omitempty" json:"schema,omitempty"`
+
+	// 저장 시 본문에서 자동 추출한 참조 변수 목록
+	ReferencedVariables []string `bson:"referenced_variables,omitempty" json:"referenced_variables,omitempty"`
+
+	// 언어별 본문 (e.g., "ko-KR", "ja", "en") - 일치하는 언어가 없으면 DefaultLocale, 그다음 위 기본 본문을 사용
+	DefaultLocale string                      `bson:"default_locale,omitempty" json:"default_locale,omitempty"`
+	Locales       map[string]LocalizedContent `bson:"locales,omitempty" json:"locales,omitempty"`
+}
+
+// LocalizedContent 언어별 템플릿 본문 (비어 있는 필드는 기본 본문을 사용)
+type LocalizedContent struct {
+	Subject     string `bson:"subject,omitempty" json:"subject,omitempty"`
+	HTMLContent string `bson:"html_content,omitempty" json:"html_content,omitempty"`
+	TextContent string `bson:"text_content,omitempty" json:"text_content,omitempty"`
+	Preheader   string `bson:"preheader,omitempty" json:"preheader,omitempty"`
+
+	MarkdownContent string `bson:"markdown_content,omitempty" json:"markdown_content,omitempty"`
+	MJMLContent     string `bson:"mjml_content,omitempty" json:"mjml_content,omitempty"`
+	CompiledHTML    string `bson:"compiled_html,omitempty" json:"compiled_html,omitempty"`
+}
+
+// 템플릿 본문 형식
+const (
+	TemplateFormatHTML     = "html"
+	TemplateFormatMarkdown = "markdown"
+	TemplateFormatMJML     = "mjml"
+)
+
+// 템플릿 분류
+const (
+	TemplateCategoryTransactional = "transactional" // 사용자 동작에 따른 안내 (e.g., 비밀번호 재설정, 주문 확인)
+	TemplateCategoryMarketing     = "marketing"     // 광고/뉴스레터 (수신 거부 처리 대상)
+)
+
+// 템플릿 상태
+const (
+	TemplateStatusDraft     = "draft"     // 아직 게시된 버전이 없음
+	TemplateStatusPublished = "published" // 게시된 버전으로 전송 가능
+)
+
 // Template 이메일 템플릿 모델
+// 최상위 본문 필드는 게시(published)된 내용이며 실제 전송에 사용된다. 편집은 Draft에만 반영된다.
 type Template struct {
-	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
-	Name        string             `bson:"name" json:"name"`                 // 템플릿 이름 (e.g., "password-reset")
-	Subject     string             `bson:"subject" json:"subject"`           // 이메일 제목
-	HTMLContent string             `bson:"html_content" json:"html_content"` // HTML 형식 내용
-	TextContent string             `bson:"text_content" json:"text_content"` // 텍스트 형식 내용
-	Variables   []string           `bson:"variables" json:"variables"`       // 템플릿 변수 목록
-	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
-	UpdatedAt   time.Time          `bson:"updated_at" json:"updated_at"`
+	ID              primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	Name            string             `bson:"name" json:"name"` // 템플릿 이름 (e.g., "password-reset")
+	TemplateContent `bson:",inline"`
+	Draft           *TemplateContent `bson:"draft,omitempty" json:"draft,omitempty"` // 작업 중인 초안
+	Status          string           `bson:"status" json:"status"`
+	Version         int              `bson:"version" json:"version"` // 게시된 버전 번호 (1부터 시작, 미게시 0)
+	PublishedAt     *time.Time       `bson:"published_at,omitempty" json:"published_at,omitempty"`
+
+	// 미리보기에 사용할 예시 변수 (버전 관리 대상 아님)
+	SampleData map[string]interface{} `bson:"sample_data,omitempty" json:"sample_data,omitempty"`
+
+	// 이름 붙은 예시 변수 세트 (e.g., "long name", "no coupon")
+	Samples map[string]map[string]interface{} `bson:"samples,omitempty" json:"samples,omitempty"`
+
+	// 분류와 소유 정보 (버전 관리 대상 아님)
+	// Category가 marketing이면 수신 거부 링크/헤더를 붙이고 수신 거부한 주소에는 보내지 않는다.
+	Tags        []string `bson:"tags,omitempty" json:"tags,omitempty"`
+	Category    string   `bson:"category,omitempty" json:"category,omitempty"`
+	Team        string   `bson:"team,omitempty" json:"team,omitempty"` // 소유 팀 (e.g., "auth", "orders")
+	Description string   `bson:"description,omitempty" json:"description,omitempty"`
+
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
+
+	// 저장 시 검사 경고 (저장되지 않음)
+	Warnings []string `bson:"-" json:"warnings,omitempty"`
+}
+
+// IsPublished 전송에 사용할 수 있는 게시 버전이 있는지 여부
+// 상태 필드가 없는 기존 템플릿은 게시된 것으로 본다.
+func (t *Template) IsPublished() bool {
+	return t.Status != TemplateStatusDraft
+}
+
+// DraftContent 초안 본문 (초안이 없으면 게시된 본문)
+func (t *Template) DraftContent() *TemplateContent {
+	if t.Draft != nil {
+		return t.Draft
+	}
+	return &t.TemplateContent
+}
+
+// TemplateListOptions 템플릿 목록 조회 조건
+type TemplateListOptions struct {
+	Limit  int    // 한 페이지 최대 개수 (0이면 기본값)
+	Cursor string // 이전 응답의 NextCursor
+	Sort   string // name | created_at | updated_at, "-" 접두사는 내림차순 (e.g., "-updated_at")
+
+	Tag      string
+	Category string
+	Team     string
+	Locale   string // 기본 언어이거나 언어별 본문이 있는 템플릿
+	Status   string // draft | published
+	Search   string // 이름/제목 부분 일치 (대소문자 무시)
+
+	Summary bool // true면 본문(HTML/텍스트/초안/언어별 본문/예시 변수)을 제외
+}
+
+// TemplateList 템플릿 목록 한 페이지
+type TemplateList struct {
+	Templates  []*Template `json:"templates"`
+	NextCursor string      `json:"next_cursor,omitempty"` // 다음 페이지가 없으면 비어 있다
+}
+
+// TemplateVersion 템플릿 버전 이력 (변경 불가)
+type TemplateVersion struct {
+	ID              primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	TemplateID      primitive.ObjectID `bson:"template_id" json:"template_id"`
+	Version         int                `bson:"version" json:"version"`
+	TemplateContent `bson:",inline"`
+	CreatedAt       time.Time `bson:"created_at" json:"created_at"`
+}
+
+// 감사 로그 동작
+const (
+	AuditActionCreate      = "create"
+	AuditActionUpdateDraft = "update_draft"
+	AuditActionPublish     = "publish"
+	AuditActionRollback    = "rollback"
+	AuditActionDelete      = "delete"
+	AuditActionTestSend    = "test_send"
+)
+
+// TemplateAuditEntry 템플릿 변경 감사 로그
+type TemplateAuditEntry struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	TemplateID primitive.ObjectID `bson:"template_id" json:"template_id"`
+	Action     string             `bson:"action" json:"action"`
+	Version    int                `bson:"version,omitempty" json:"version,omitempty"` // 게시/롤백으로 만들어진 버전
+	Actor      string             `bson:"actor,omitempty" json:"actor,omitempty"`
+	Comment    string             `bson:"comment,omitempty" json:"comment,omitempty"`
+	CreatedAt  time.Time          `bson:"created_at" json:"created_at"`
+}
+
+// TemplateDiff 두 버전 사이의 필드별 unified diff
+type TemplateDiff struct {
+	TemplateID  primitive.ObjectID `json:"template_id"`
+	FromVersion int                `json:"from_version"`
+	ToVersion   int                `json:"to_version"`
+	Changes     map[string]string  `json:"changes"` // 필드 이름 -> unified diff
 }
 
 // EmailRequest 이메일 전송 요청 구조체
+// 템플릿은 TemplateID 또는 환경과 무관한 TemplateName으로 지정한다.
 type EmailRequest struct {
-	TemplateID  string                 `json:"template_id"`
-	To          []string               `json:"to"`
-	Variables   map[string]interface{} `json:"variables"`
-	CallbackURL string                 `json:"callback_url,omitempty"` // 선택적 콜백 URL
+	TemplateID      string                 `json:"template_id,omitempty"`
+	TemplateName    string                 `json:"template_name,omitempty"`
+	TemplateVersion int                    `json:"template_version,omitempty"` // 고정할 게시 버전 (0이면 최신)
+	To              []string               `json:"to"`
+	Variables       map[string]interface{} `json:"variables"`
+	Locale          string                 `json:"locale,omitempty"`       // 수신자 언어 (e.g., "ko-KR")
+	CallbackURL     string                 `json:"callback_url,omitempty"` // 선택적 콜백 URL
 }
 
 // EmailResponse 이메일 전송 응답 구조체
 type EmailResponse struct {
-	MessageID string    `json:"message_id"`
-	Status    string    `json:"status"`
-	SentAt    time.Time `json:"sent_at"`
+	MessageID       string    `json:"message_id"`
+	Status          string    `json:"status"`
+	TemplateID      string    `json:"template_id,omitempty"`
+	TemplateVersion int       `json:"template_version,omitempty"` // 전송에 사용된 템플릿 버전
+	Locale          string    `json:"locale,omitempty"`           // 전송에 사용된 언어 본문
+	Suppressed      []string  `json:"suppressed,omitempty"`       // 수신 거부로 제외된 수신자 (마케팅 템플릿)
+	SentAt          time.Time `json:"sent_at"`
+}
+
+// RenderRequest 템플릿 렌더링(미리보기) 요청 - 전송하지 않는다
+type RenderRequest struct {
+	Variables  map[string]interface{} `json:"variables"`
+	Sample     string                 `json:"sample,omitempty"` // 기본 변수로 사용할 예시 세트 이름 (Variables가 덮어쓴다)
+	Locale     string                 `json:"locale,omitempty"`
+	Published  bool                   `json:"published,omitempty"`   // true면 게시된 본문, 기본은 초안
+	IncludeRaw bool                   `json:"include_raw,omitempty"` // 전체 MIME 메시지 포함 여부
+	To         []string               `json:"to,omitempty"`          // Raw 메시지에 사용할 수신자 (선택)
+}
+
+// RenderResponse 렌더링 결과
+type RenderResponse struct {
+	TemplateID string `json:"template_id"`
+	Version    int    `json:"version"` // 게시 버전 (초안 렌더링이면 현재 게시 버전)
+	Draft      bool   `json:"draft"`
+	Locale     string `json:"locale,omitempty"`
+	Subject    string `json:"subject"`
+	HTML       string `json:"html"`
+	Text       string `json:"text"`
+	Raw        string `json:"raw,omitempty"`
+
+	Warnings []string `json:"warnings,omitempty"` // e.g., Gmail 잘림 크기 초과
+}
+
+// TestSendRequest 테스트 전송 요청 (허용된 내부 주소로만 전송)
+type TestSendRequest struct {
+	To        []string               `json:"to"`
+	Sample    string                 `json:"sample,omitempty"`
+	Variables map[string]interface{} `json:"variables,omitempty"`
+	Locale    string                 `json:"locale,omitempty"`
+	Published bool                   `json:"published,omitempty"`
+}
+
+// TestSendResponse 테스트 전송 결과
+type TestSendResponse struct {
+	MessageID       string    `json:"message_id"`
+	Status          string    `json:"status"`
+	To              []string  `json:"to"`
+	Subject         string    `json:"subject"`
+	TemplateVersion int       `json:"template_version"`
+	Draft           bool      `json:"draft"`
+	Locale          string    `json:"locale,omitempty"`
+	SentAt          time.Time `json:"sent_at"`
 }
@@ -0,0 +1,307 @@
+package filesystem
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/kihyun1998/prisma-market/prisma-email-service/internal/models"
+)
+
+// 디렉터리 구성
+//
+//	<dir>/<template-name>/subject.txt      제목 (필수)
+//	<dir>/<template-name>/body.html        HTML 본문 (body.md 또는 body.mjml 중 하나로 대신할 수 있다)
+//	<dir>/<template-name>/body.txt         텍스트 본문
+//	<dir>/<template-name>/preheader.txt    미리보기 문구
+//	<dir>/<template-name>/schema.json      변수 스키마
+//	<dir>/<template-name>/meta.json        레이아웃, 언어, 분류, 예시 변수 등 (templateMeta)
+//	<dir>/<template-name>/locales/<locale>/subject.txt, body.html, ...
+//	<dir>/_layouts/<name>.html, <name>.txt
+//	<dir>/_partials/<name>.html, <name>.txt
+const (
+	layoutsDir  = "_layouts"
+	partialsDir = "_partials"
+	localesDir  = "locales"
+)
+
+// 본문 파일과 형식
+var bodyFiles = []struct {
+	name   string
+	format string
+}{
+	{"body.html", models.TemplateFormatHTML},
+	{"body.md", models.TemplateFormatMarkdown},
+	{"body.mjml", models.TemplateFormatMJML},
+}
+
+// templateMeta meta.json 내용
+type templateMeta struct {
+	Layout        string   `json:"layout,omitempty"`
+	Variables     []string `json:"variables,omitempty"`
+	DefaultLocale string   `json:"default_locale,omitempty"`
+
+	Tags        []string `json:"tags,omitempty"`
+	Category    string   `json:"category,omitempty"`
+	Team        string   `json:"team,omitempty"`
+	Description string   `json:"description,omitempty"`
+
+	SampleData map[string]interface{}            `json:"sample_data,omitempty"`
+	Samples    map[string]map[string]interface{} `json:"samples,omitempty"`
+}
+
+// TemplateID 템플릿 이름에서 만든 고정 ID (다시 읽어도 같은 ID를 유지한다)
+func TemplateID(name string) primitive.ObjectID {
+	return nameID("template:" + name)
+}
+
+// FragmentID 레이아웃/부분 템플릿 이름에서 만든 고정 ID
+func FragmentID(kind, name string) primitive.ObjectID {
+	return nameID(kind + ":" + name)
+}
+
+func nameID(key string) primitive.ObjectID {
+	sum := sha256.Sum256([]byte(key))
+	var id primitive.ObjectID
+	copy(id[:], sum[:])
+	return id
+}
+
+// LoadBundle 디렉터리의 템플릿과 레이아웃/부분 템플릿을 번들로 읽기
+// 파일 형식 오류는 경로를 포함한 에러로 반환한다. 본문 문법 검사는 호출 측에서 한다.
+func LoadBundle(dir string) (*models.Bundle, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	bundle := &models.Bundle{
+		FormatVersion: models.BundleFormatVersion,
+		ExportedAt:    time.Now().UTC(),
+		Templates:     []models.BundleTemplate{},
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		switch entry.Name() {
+		case layoutsDir:
+			if bundle.Layouts, err = loadFragments(path); err != nil {
+				return nil, err
+			}
+		case partialsDir:
+			if bundle.Partials, err = loadFragments(path); err != nil {
+				return nil, err
+			}
+		default:
+			template, err := loadTemplate(path)
+			if err != nil {
+				return nil, err
+			}
+			bundle.Templates = append(bundle.Templates, *template)
+		}
+	}
+
+	return bundle, nil
+}
+
+func loadTemplate(dir string) (*models.BundleTemplate, error) {
+	template := &models.BundleTemplate{
+		Name:    filepath.Base(dir),
+		Status:  models.TemplateStatusPublished,
+		Version: 1,
+	}
+	content := &template.Content
+
+	subject, err := readFile(dir, "subject.txt")
+	if err != nil {
+		return nil, err
+	}
+	if subject == "" {
+		return nil, fmt.Errorf("%s: subject.txt is required", dir)
+	}
+	content.Subject = subject
+
+	format, body, err := readBody(dir)
+	if err != nil {
+		return nil, err
+	}
+	switch format {
+	case models.TemplateFormatMarkdown:
+		content.Format, content.MarkdownContent = format, body
+	case models.TemplateFormatMJML:
+		content.Format, content.MJMLContent = format, body
+	default:
+		content.HTMLContent = body
+	}
+
+	if content.TextContent, err = readFile(dir, "body.txt"); err != nil {
+		return nil, err
+	}
+	if content.Preheader, err = readFile(dir, "preheader.txt"); err != nil {
+		return nil, err
+	}
+
+	if schema, err := readFile(dir, "schema.json"); err != nil {
+		return nil, err
+	} else if schema != "" {
+		content.Schema = &models.VariableSchema{}
+		if err := json.Unmarshal([]byte(schema), content.Schema); err != nil {
+			return nil, fmt.Errorf("%s: invalid schema.json: %v", dir, err)
+		}
+	}
+
+	if raw, err := readFile(dir, "meta.json"); err != nil {
+		return nil, err
+	} else if raw != "" {
+		var meta templateMeta
+		if err := json.Unmarshal([]byte(raw), &meta); err != nil {
+			return nil, fmt.Errorf("%s: invalid meta.json: %v", dir, err)
+		}
+		content.Layout = meta.Layout
+		content.Variables = meta.Variables
+		content.DefaultLocale = meta.DefaultLocale
+		template.Tags = meta.Tags
+		template.Category = meta.Category
+		template.Team = meta.Team
+		template.Description = meta.Description
+		template.SampleData = meta.SampleData
+		template.Samples = meta.Samples
+	}
+
+	if content.Locales, err = loadLocales(filepath.Join(dir, localesDir), format); err != nil {
+		return nil, err
+	}
+	return template, nil
+}
+
+// loadLocales 언어별 본문 (본문 파일은 기본 본문과 같은 형식이어야 한다)
+func loadLocales(dir, format string) (map[string]models.LocalizedContent, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	locales := make(map[string]models.LocalizedContent)
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+
+		var variant models.LocalizedContent
+		if variant.Subject, err = readFile(path, "subject.txt"); err != nil {
+			return nil, err
+		}
+		if variant.TextContent, err = readFile(path, "body.txt"); err != nil {
+			return nil, err
+		}
+		if variant.Preheader, err = readFile(path, "preheader.txt"); err != nil {
+			return nil, err
+		}
+
+		localeFormat, body, err := readBody(path)
+		if err != nil {
+			return nil, err
+		}
+		if body != "" && localeFormat != format {
+			return nil, fmt.Errorf("%s: body format %q does not match the template format %q", path, localeFormat, format)
+		}
+		switch format {
+		case models.TemplateFormatMarkdown:
+			variant.MarkdownContent = body
+		case models.TemplateFormatMJML:
+			variant.MJMLContent = body
+		default:
+			variant.HTMLContent = body
+		}
+
+		locales[entry.Name()] = variant
+	}
+	return locales, nil
+}
+
+// readBody body.html / body.md / body.mjml 중 있는 파일 (둘 이상이면 에러)
+func readBody(dir string) (string, string, error) {
+	format, body := models.TemplateFormatHTML, ""
+	found := ""
+	for _, f := range bodyFiles {
+		content, err := readFile(dir, f.name)
+		if err != nil {
+			return "", "", err
+		}
+		if content == "" {
+			continue
+		}
+		if found != "" {
+			return "", "", fmt.Errorf("%s: only one of %s and %s may be present", dir, found, f.name)
+		}
+		found, format, body = f.name, f.format, content
+	}
+	return format, body, nil
+}
+
+// loadFragments <name>.html / <name>.txt 쌍을 레이아웃 또는 부분 템플릿으로 읽기
+func loadFragments(dir string) ([]models.BundleFragment, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byName := make(map[string]*models.BundleFragment)
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if entry.IsDir() || (ext != ".html" && ext != ".txt") {
+			continue
+		}
+		name := strings.TrimSuffix(entry.Name(), ext)
+
+		content, err := readFile(dir, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		fragment, ok := byName[name]
+		if !ok {
+			fragment = &models.BundleFragment{Name: name}
+			byName[name] = fragment
+		}
+		if ext == ".html" {
+			fragment.HTMLContent = content
+		} else {
+			fragment.TextContent = content
+		}
+	}
+
+	fragments := make([]models.BundleFragment, 0, len(byName))
+	for _, fragment := range byName {
+		fragments = append(fragments, *fragment)
+	}
+	sort.Slice(fragments, func(i, j int) bool { return fragments[i].Name < fragments[j].Name })
+	return fragments, nil
+}
+
+// readFile 파일 내용 (없으면 빈 문자열, 끝의 줄바꿈 제거)
+func readFile(dir, name string) (string, error) {
+	b, err := os.ReadFile(filepath.Join(dir, name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(b), "\r\n"), nil
+}
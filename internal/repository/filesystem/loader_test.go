@@ -0,0 +1,158 @@
+package filesystem
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kihyun1998/prisma-market/prisma-email-service/internal/models"
+)
+
+// writeTree 경로별 내용으로 디렉터리 구성
+func writeTree(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestLoadBundle(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"welcome/subject.txt":            "Welcome {{.name}}\n",
+		"welcome/body.md":                "# Hi {{.name}}\n",
+		"welcome/body.txt":               "Hi {{.name}}\n",
+		"welcome/preheader.txt":          "Glad you're here",
+		"welcome/schema.json":            `{"type":"object","properties":{"name":{"type":"string"}},"required":["name"]}`,
+		"welcome/meta.json":              `{"layout":"base","default_locale":"en","tags":["onboarding"],"category":"transactional","team":"auth","sample_data":{"name":"Tom"}}`,
+		"welcome/locales/ko/subject.txt": "환영합니다 {{.name}}",
+		"welcome/locales/ko/body.md":     "# 안녕하세요 {{.name}}",
+		"welcome/locales/ja/subject.txt": "ようこそ",
+		"reset/subject.txt":              "Reset your password",
+		"reset/body.html":                "<p>Reset</p>\r\n",
+		"_layouts/base.html":             "<html>{{.content}}</html>",
+		"_layouts/base.txt":              "{{.content}}",
+		"_layouts/plain.txt":             "{{.content}}",
+		"_layouts/notes.md":              "ignored",
+		"_partials/footer.html":          "<footer></footer>",
+		".git/HEAD":                      "ref: refs/heads/main",
+	})
+
+	bundle, err := LoadBundle(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(bundle.Templates) != 2 || bundle.Templates[0].Name != "reset" || bundle.Templates[1].Name != "welcome" {
+		t.Fatalf("templates = %+v, want reset and welcome", bundle.Templates)
+	}
+
+	reset := bundle.Templates[0]
+	if reset.Content.Format != "" || reset.Content.HTMLContent != "<p>Reset</p>" || reset.Status != models.TemplateStatusPublished || reset.Version != 1 {
+		t.Errorf("reset = %+v", reset)
+	}
+
+	welcome := bundle.Templates[1]
+	content := welcome.Content
+	if content.Subject != "Welcome {{.name}}" || content.Format != models.TemplateFormatMarkdown || content.MarkdownContent != "# Hi {{.name}}" || content.HTMLContent != "" {
+		t.Errorf("welcome body = %+v", content)
+	}
+	if content.TextContent != "Hi {{.name}}" || content.Preheader != "Glad you're here" {
+		t.Errorf("welcome text = %q, preheader = %q", content.TextContent, content.Preheader)
+	}
+	if content.Schema == nil || content.Schema.Properties["name"] == nil || !reflect.DeepEqual(content.Schema.Required, []string{"name"}) {
+		t.Errorf("welcome schema = %+v", content.Schema)
+	}
+	if content.Layout != "base" || content.DefaultLocale != "en" || welcome.Team != "auth" || welcome.SampleData["name"] != "Tom" || !reflect.DeepEqual(welcome.Tags, []string{"onboarding"}) {
+		t.Errorf("welcome meta = %+v", welcome)
+	}
+
+	// 언어별 본문은 기본 본문의 형식을 따르고, 없는 파일은 기본 본문을 사용한다
+	ko, ja := content.Locales["ko"], content.Locales["ja"]
+	if ko.Subject != "환영합니다 {{.name}}" || ko.MarkdownContent != "# 안녕하세요 {{.name}}" || ko.HTMLContent != "" {
+		t.Errorf("ko = %+v", ko)
+	}
+	if ja.Subject != "ようこそ" || ja.MarkdownContent != "" {
+		t.Errorf("ja = %+v", ja)
+	}
+
+	// .html과 .txt는 같은 이름끼리 짝을 짓는다
+	wantLayouts := []models.BundleFragment{
+		{Name: "base", HTMLContent: "<html>{{.content}}</html>", TextContent: "{{.content}}"},
+		{Name: "plain", TextContent: "{{.content}}"},
+	}
+	if !reflect.DeepEqual(bundle.Layouts, wantLayouts) {
+		t.Errorf("layouts = %+v, want %+v", bundle.Layouts, wantLayouts)
+	}
+	if wantPartials := []models.BundleFragment{{Name: "footer", HTMLContent: "<footer></footer>"}}; !reflect.DeepEqual(bundle.Partials, wantPartials) {
+		t.Errorf("partials = %+v, want %+v", bundle.Partials, wantPartials)
+	}
+}
+
+func TestLoadBundleErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  string
+	}{
+		{
+			name:  "missing subject",
+			files: map[string]string{"welcome/body.html": "<p>Hi</p>"},
+			want:  "subject.txt is required",
+		},
+		{
+			name:  "empty subject",
+			files: map[string]string{"welcome/subject.txt": "\n", "welcome/body.html": "<p>Hi</p>"},
+			want:  "subject.txt is required",
+		},
+		{
+			name:  "multiple body files",
+			files: map[string]string{"welcome/subject.txt": "Hi", "welcome/body.html": "<p>Hi</p>", "welcome/body.md": "# Hi"},
+			want:  "only one of body.html and body.md may be present",
+		},
+		{
+			name:  "multiple locale body files",
+			files: map[string]string{"welcome/subject.txt": "Hi", "welcome/body.md": "# Hi", "welcome/locales/ko/body.md": "# 안녕", "welcome/locales/ko/body.mjml": "<mjml></mjml>"},
+			want:  "only one of body.md and body.mjml may be present",
+		},
+		{
+			name:  "locale body in another format",
+			files: map[string]string{"welcome/subject.txt": "Hi", "welcome/body.md": "# Hi", "welcome/locales/ko/body.html": "<p>안녕</p>"},
+			want:  `body format "html" does not match the template format "markdown"`,
+		},
+		{
+			name:  "invalid meta",
+			files: map[string]string{"welcome/subject.txt": "Hi", "welcome/meta.json": "{"},
+			want:  "invalid meta.json",
+		},
+		{
+			name:  "invalid schema",
+			files: map[string]string{"welcome/subject.txt": "Hi", "welcome/schema.json": "[]"},
+			want:  "invalid schema.json",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeTree(t, dir, tt.files)
+
+			_, err := LoadBundle(dir)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("error = %v, want %q", err, tt.want)
+			}
+			// 어느 파일이 문제인지 알 수 있도록 경로를 포함한다
+			if !strings.Contains(err.Error(), filepath.Join(dir, "welcome")) {
+				t.Errorf("error %q does not include the template path", err)
+			}
+		})
+	}
+}
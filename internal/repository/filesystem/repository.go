@@ -0,0 +1,310 @@
+package filesystem
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/kihyun1998/prisma-market/prisma-email-service/internal/models"
+)
+
+// ErrInvalidCursor 목록 커서가 손상되었거나 다른 정렬 조건으로 만들어짐
+var ErrInvalidCursor = errors.New("invalid list cursor")
+
+// TemplateRepository 디렉터리에서 읽은 템플릿을 메모리에 두는 읽기 전용 리포지토리
+// 템플릿마다 게시된 버전 1만 있으며, 다시 읽으면 전체를 교체한다.
+type TemplateRepository struct {
+	mu        sync.RWMutex
+	templates []*models.Template // 이름순
+	fragments map[string]map[string]*models.Fragment
+}
+
+func NewTemplateRepository() *TemplateRepository {
+	return &TemplateRepository{fragments: map[string]map[string]*models.Fragment{}}
+}
+
+// Replace 템플릿과 레이아웃/부분 템플릿 전체 교체
+func (r *TemplateRepository) Replace(templates []*models.Template, fragments []*models.Fragment) {
+	sorted := append([]*models.Template(nil), templates...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+	byKind := map[string]map[string]*models.Fragment{}
+	for _, fragment := range fragments {
+		if byKind[fragment.Kind] == nil {
+			byKind[fragment.Kind] = map[string]*models.Fragment{}
+		}
+		byKind[fragment.Kind][fragment.Name] = fragment
+	}
+
+	r.mu.Lock()
+	r.templates = sorted
+	r.fragments = byKind
+	r.mu.Unlock()
+}
+
+// GetTemplateByID ID로 템플릿 조회
+func (r *TemplateRepository) GetTemplateByID(ctx context.Context, id primitive.ObjectID) (*models.Template, error) {
+	return r.findTemplate(func(t *models.Template) bool { return t.ID == id }), nil
+}
+
+// GetTemplateByName 이름으로 템플릿 조회
+func (r *TemplateRepository) GetTemplateByName(ctx context.Context, name string) (*models.Template, error) {
+	return r.findTemplate(func(t *models.Template) bool { return t.Name == name }), nil
+}
+
+func (r *TemplateRepository) findTemplate(match func(*models.Template) bool) *models.Template {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, t := range r.templates {
+		if match(t) {
+			template := *t
+			return &template
+		}
+	}
+	return nil
+}
+
+// FindTemplatesByName 이름으로 여러 템플릿 조회 (names가 비어 있으면 전체, 이름순)
+func (r *TemplateRepository) FindTemplatesByName(ctx context.Context, names []string) ([]*models.Template, error) {
+	wanted := make(map[string]bool, len(names))
+	for _, name := range names {
+		wanted[name] = true
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	templates := []*models.Template{}
+	for _, t := range r.templates {
+		if len(names) == 0 || wanted[t.Name] {
+			template := *t
+			templates = append(templates, &template)
+		}
+	}
+	return templates, nil
+}
+
+// ListTemplateVersions 버전 이력 조회 (디렉터리의 내용이 유일한 버전)
+func (r *TemplateRepository) ListTemplateVersions(ctx context.Context, templateID primitive.ObjectID) ([]*models.TemplateVersion, error) {
+	v, err := r.GetTemplateVersion(ctx, templateID, 1)
+	if err != nil || v == nil {
+		return nil, err
+	}
+	return []*models.TemplateVersion{v}, nil
+}
+
+// GetTemplateVersion 특정 버전 조회
+func (r *TemplateRepository) GetTemplateVersion(ctx context.Context, templateID primitive.ObjectID, version int) (*models.TemplateVersion, error) {
+	template, _ := r.GetTemplateByID(ctx, templateID)
+	if template == nil || version != template.Version {
+		return nil, nil
+	}
+	return &models.TemplateVersion{
+		TemplateID:      template.ID,
+		Version:         template.Version,
+		TemplateContent: template.TemplateContent,
+		CreatedAt:       template.UpdatedAt,
+	}, nil
+}
+
+// ListTemplates 조건에 맞는 템플릿 한 페이지 조회
+// opts.Sort와 opts.Limit은 호출 측에서 검증된 값이어야 한다.
+func (r *TemplateRepository) ListTemplates(ctx context.Context, opts *models.TemplateListOptions) (*models.TemplateList, error) {
+	r.mu.RLock()
+	var matched []*models.Template
+	for _, t := range r.templates {
+		if matchesListOptions(t, opts) {
+			matched = append(matched, t)
+		}
+	}
+	r.mu.RUnlock()
+
+	field, descending := strings.TrimPrefix(opts.Sort, "-"), strings.HasPrefix(opts.Sort, "-")
+	sort.SliceStable(matched, func(i, j int) bool {
+		a, b := matched[i], matched[j]
+		less, equal := compareField(a, b, field)
+		if equal {
+			less = a.ID.Hex() < b.ID.Hex()
+		}
+		if descending {
+			return !less && a.ID != b.ID
+		}
+		return less
+	})
+
+	start := 0
+	if opts.Cursor != "" {
+		id, err := decodeCursor(opts.Cursor, opts.Sort)
+		if err != nil {
+			return nil, err
+		}
+		start = -1
+		for i, t := range matched {
+			if t.ID == id {
+				start = i + 1
+				break
+			}
+		}
+		if start < 0 {
+			// 다시 읽으면서 마지막 항목이 사라졌을 수 있다
+			return nil, ErrInvalidCursor
+		}
+	}
+
+	list := &models.TemplateList{Templates: []*models.Template{}}
+	for _, t := range matched[start:] {
+		if len(list.Templates) == opts.Limit {
+			list.NextCursor = encodeCursor(opts.Sort, list.Templates[opts.Limit-1].ID)
+			break
+		}
+		template := *t
+		if opts.Summary {
+			summarize(&template)
+		}
+		list.Templates = append(list.Templates, &template)
+	}
+	return list, nil
+}
+
+func compareField(a, b *models.Template, field string) (less, equal bool) {
+	switch field {
+	case "name":
+		return a.Name < b.Name, a.Name == b.Name
+	case "created_at":
+		return a.CreatedAt.Before(b.CreatedAt), a.CreatedAt.Equal(b.CreatedAt)
+	default:
+		return a.UpdatedAt.Before(b.UpdatedAt), a.UpdatedAt.Equal(b.UpdatedAt)
+	}
+}
+
+// matchesListOptions 태그/분류/팀/언어/상태/검색어 조건 (MongoDB 리포지토리와 같은 의미)
+func matchesListOptions(t *models.Template, opts *models.TemplateListOptions) bool {
+	if opts.Tag != "" && !contains(t.Tags, opts.Tag) {
+		return false
+	}
+	if opts.Category != "" && t.Category != opts.Category {
+		return false
+	}
+	if opts.Team != "" && t.Team != opts.Team {
+		return false
+	}
+	if opts.Locale != "" {
+		if _, ok := t.Locales[opts.Locale]; !ok && t.DefaultLocale != opts.Locale {
+			return false
+		}
+	}
+	switch opts.Status {
+	case models.TemplateStatusDraft:
+		if t.IsPublished() {
+			return false
+		}
+	case models.TemplateStatusPublished:
+		if !t.IsPublished() {
+			return false
+		}
+	}
+	if opts.Search != "" {
+		search := strings.ToLower(opts.Search)
+		if !strings.Contains(strings.ToLower(t.Name), search) && !strings.Contains(strings.ToLower(t.Subject), search) {
+			return false
+		}
+	}
+	return true
+}
+
+// summarize 목록 요약 보기에서 본문 제외
+func summarize(t *models.Template) {
+	t.HTMLContent = ""
+	t.TextContent = ""
+	t.MarkdownContent = ""
+	t.MJMLContent = ""
+	t.CompiledHTML = ""
+	t.Locales = nil
+	t.Draft = nil
+	t.SampleData = nil
+	t.Samples = nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// listCursor 마지막 항목의 ID (정렬 조건이 바뀌면 사용할 수 없다)
+type listCursor struct {
+	Sort string `json:"s"`
+	ID   string `json:"id"`
+}
+
+func encodeCursor(sort string, id primitive.ObjectID) string {
+	raw, _ := json.Marshal(listCursor{Sort: sort, ID: id.Hex()})
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeCursor(encoded, sort string) (primitive.ObjectID, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return primitive.NilObjectID, ErrInvalidCursor
+	}
+	var c listCursor
+	if err := json.Unmarshal(raw, &c); err != nil || c.Sort != sort {
+		return primitive.NilObjectID, ErrInvalidCursor
+	}
+	id, err := primitive.ObjectIDFromHex(c.ID)
+	if err != nil {
+		return primitive.NilObjectID, ErrInvalidCursor
+	}
+	return id, nil
+}
+
+// GetFragmentByID ID로 레이아웃 또는 부분 템플릿 조회
+func (r *TemplateRepository) GetFragmentByID(ctx context.Context, kind string, id primitive.ObjectID) (*models.Fragment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, f := range r.fragments[kind] {
+		if f.ID == id {
+			fragment := *f
+			return &fragment, nil
+		}
+	}
+	return nil, nil
+}
+
+// GetFragmentByName 이름으로 레이아웃 또는 부분 템플릿 조회
+func (r *TemplateRepository) GetFragmentByName(ctx context.Context, kind, name string) (*models.Fragment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	f, ok := r.fragments[kind][name]
+	if !ok {
+		return nil, nil
+	}
+	fragment := *f
+	return &fragment, nil
+}
+
+// ListFragments 레이아웃 또는 부분 템플릿 목록 (이름순)
+func (r *TemplateRepository) ListFragments(ctx context.Context, kind string) ([]*models.Fragment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	fragments := make([]*models.Fragment, 0, len(r.fragments[kind]))
+	for _, f := range r.fragments[kind] {
+		fragment := *f
+		fragments = append(fragments, &fragment)
+	}
+	sort.Slice(fragments, func(i, j int) bool { return fragments[i].Name < fragments[j].Name })
+	return fragments, nil
+}
@@ -0,0 +1,131 @@
+package filesystem
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/kihyun1998/prisma-market/prisma-email-service/internal/models"
+)
+
+func listRepository() *TemplateRepository {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	template := func(name string, updated time.Duration) *models.Template {
+		return &models.Template{
+			ID:              TemplateID(name),
+			Name:            name,
+			TemplateContent: models.TemplateContent{Subject: name + " subject", HTMLContent: "<p>" + name + "</p>"},
+			Status:          models.TemplateStatusPublished,
+			Version:         1,
+			UpdatedAt:       base.Add(updated),
+		}
+	}
+
+	r := NewTemplateRepository()
+	// 같은 수정 시각이 여러 개 있어도 ID로 이어서 조회해야 한다
+	r.Replace([]*models.Template{
+		template("welcome", time.Hour),
+		template("reset", 0),
+		template("invoice", time.Hour),
+		template("digest", 0),
+		template("alert", 2*time.Hour),
+	}, nil)
+	return r
+}
+
+// listAll 커서를 따라 모든 페이지의 이름을 모은다
+func listAll(t *testing.T, r *TemplateRepository, opts models.TemplateListOptions) ([]string, int) {
+	t.Helper()
+	var names []string
+	pages := 0
+	for {
+		list, err := r.ListTemplates(context.Background(), &opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pages++
+		for _, template := range list.Templates {
+			names = append(names, template.Name)
+		}
+		if list.NextCursor == "" {
+			return names, pages
+		}
+		opts.Cursor = list.NextCursor
+	}
+}
+
+func TestListTemplatesPaging(t *testing.T) {
+	r := listRepository()
+
+	tests := []struct {
+		sort  string
+		limit int
+		want  []string
+		pages int
+	}{
+		{sort: "name", limit: 2, want: []string{"alert", "digest", "invoice", "reset", "welcome"}, pages: 3},
+		{sort: "-name", limit: 5, want: []string{"welcome", "reset", "invoice", "digest", "alert"}, pages: 1},
+		{sort: "updated_at", limit: 2, want: append(append(byID("reset", "digest", false), byID("welcome", "invoice", false)...), "alert"), pages: 3},
+		{sort: "-updated_at", limit: 1, want: append(append([]string{"alert"}, byID("welcome", "invoice", true)...), byID("reset", "digest", true)...), pages: 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.sort, func(t *testing.T) {
+			names, pages := listAll(t, r, models.TemplateListOptions{Sort: tt.sort, Limit: tt.limit})
+			if !reflect.DeepEqual(names, tt.want) || pages != tt.pages {
+				t.Errorf("names = %v in %d pages, want %v in %d pages", names, pages, tt.want, tt.pages)
+			}
+		})
+	}
+}
+
+// byID 두 이름을 ID 순서로 (descending이면 역순)
+func byID(a, b string, descending bool) []string {
+	if (TemplateID(a).Hex() > TemplateID(b).Hex()) != descending {
+		a, b = b, a
+	}
+	return []string{a, b}
+}
+
+func TestListTemplatesFiltersAndSummary(t *testing.T) {
+	r := listRepository()
+
+	list, err := r.ListTemplates(context.Background(), &models.TemplateListOptions{Sort: "name", Limit: 10, Search: "RE", Summary: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Templates) != 1 || list.Templates[0].Name != "reset" {
+		t.Fatalf("templates = %v, want reset", list.Templates)
+	}
+	if got := list.Templates[0]; got.Subject != "reset subject" || got.HTMLContent != "" {
+		t.Errorf("summary = %+v, want subject without body", got)
+	}
+
+	// 요약은 원본을 바꾸지 않는다
+	if template, _ := r.GetTemplateByName(context.Background(), "reset"); template.HTMLContent == "" {
+		t.Error("summary cleared the stored template")
+	}
+}
+
+func TestListTemplatesRejectsCursorFromOtherSort(t *testing.T) {
+	r := listRepository()
+	list, err := r.ListTemplates(context.Background(), &models.TemplateListOptions{Sort: "name", Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, cursor := range []string{list.NextCursor, "!!!"} {
+		_, err := r.ListTemplates(context.Background(), &models.TemplateListOptions{Sort: "-name", Limit: 1, Cursor: cursor})
+		if !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("cursor %q: error = %v, want ErrInvalidCursor", cursor, err)
+		}
+	}
+
+	// 다시 읽으면서 사라진 항목의 커서도 거부한다
+	_, err = r.ListTemplates(context.Background(), &models.TemplateListOptions{Sort: "name", Limit: 1, Cursor: encodeCursor("name", TemplateID("missing"))})
+	if !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("missing item cursor: error = %v, want ErrInvalidCursor", err)
+	}
+}
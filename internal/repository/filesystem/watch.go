@@ -0,0 +1,60 @@
+package filesystem
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"log"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Fingerprint 디렉터리 아래 파일의 경로/크기/수정 시각 요약 (내용이 바뀌면 값이 달라진다)
+func Fingerprint(dir string) (string, error) {
+	var b strings.Builder
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(&b, "%s\x00%d\x00%d\n", path, info.Size(), info.ModTime().UnixNano())
+		return nil
+	})
+	return b.String(), err
+}
+
+// Watch interval마다 디렉터리를 확인해 바뀌었으면 onChange 호출 (ctx가 끝날 때까지 실행)
+// 저장소 체크아웃처럼 여러 파일이 한꺼번에 바뀌는 경우가 많으므로 알림 대신 주기적으로 비교한다.
+func Watch(ctx context.Context, dir string, interval time.Duration, onChange func()) {
+	last, err := Fingerprint(dir)
+	if err != nil {
+		log.Printf("Template directory: failed to scan %s: %v", dir, err)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		current, err := Fingerprint(dir)
+		if err != nil {
+			log.Printf("Template directory: failed to scan %s: %v", dir, err)
+			continue
+		}
+		if current != last {
+			last = current
+			onChange()
+		}
+	}
+}
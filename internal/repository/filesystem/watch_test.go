@@ -0,0 +1,72 @@
+package filesystem
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFingerprint(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"welcome/subject.txt": "Welcome",
+		"welcome/body.html":   "<p>Hi</p>",
+		"_layouts/base.html":  "{{.content}}",
+	})
+	subject := filepath.Join(dir, "welcome", "subject.txt")
+
+	fingerprint := func() string {
+		t.Helper()
+		f, err := Fingerprint(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return f
+	}
+
+	tests := []struct {
+		name   string
+		change func()
+	}{
+		{name: "content size", change: func() { writeTree(t, dir, map[string]string{"welcome/subject.txt": "Welcome!"}) }},
+		{name: "modification time", change: func() {
+			// 크기가 같은 수정도 수정 시각으로 감지한다
+			writeTree(t, dir, map[string]string{"welcome/subject.txt": "Welcome?"})
+			at := time.Now().Add(time.Hour)
+			if err := os.Chtimes(subject, at, at); err != nil {
+				t.Fatal(err)
+			}
+		}},
+		{name: "new file", change: func() { writeTree(t, dir, map[string]string{"_partials/footer.html": "<footer></footer>"}) }},
+		{name: "removed file", change: func() {
+			if err := os.Remove(filepath.Join(dir, "_layouts", "base.html")); err != nil {
+				t.Fatal(err)
+			}
+		}},
+		{name: "renamed template", change: func() {
+			if err := os.Rename(filepath.Join(dir, "welcome"), filepath.Join(dir, "hello")); err != nil {
+				t.Fatal(err)
+			}
+		}},
+	}
+
+	last := fingerprint()
+	if again := fingerprint(); again != last {
+		t.Fatal("fingerprint changed without any change")
+	}
+	for _, tt := range tests {
+		tt.change()
+		current := fingerprint()
+		if current == last {
+			t.Errorf("%s: fingerprint did not change", tt.name)
+		}
+		last = current
+	}
+}
+
+func TestFingerprintMissingDirectory(t *testing.T) {
+	if _, err := Fingerprint(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("error = nil, want an error for a missing directory")
+	}
+}
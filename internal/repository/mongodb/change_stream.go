@@ -0,0 +1,62 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// TemplateChange 템플릿 또는 레이아웃/부분 템플릿 변경 이벤트
+type TemplateChange struct {
+	Fragment bool               // 레이아웃/부분 템플릿 변경이면 true
+	ID       primitive.ObjectID // 변경된 문서 ID
+}
+
+// WatchTemplateChanges 템플릿과 레이아웃/부분 템플릿 컬렉션의 변경을 change stream으로 구독
+// 스트림을 열 수 없으면(e.g., 단일 노드 MongoDB) 바로 에러를 반환하고,
+// 열리면 onOpen을 호출한 뒤 ctx가 끝나거나 스트림이 끊길 때까지 onChange를 호출한다.
+func (r *TemplateRepository) WatchTemplateChanges(ctx context.Context, onOpen func(), onChange func(TemplateChange)) error {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"ns.coll": bson.M{"$in": bson.A{r.collection.Name(), r.layouts.Name(), r.partials.Name()}},
+		}}},
+	}
+
+	stream, err := r.db.Watch(ctx, pipeline)
+	if err != nil {
+		return err
+	}
+	defer stream.Close(context.Background())
+	onOpen()
+
+	for stream.Next(ctx) {
+		var event struct {
+			OperationType string `bson:"operationType"`
+			NS            struct {
+				Coll string `bson:"coll"`
+			} `bson:"ns"`
+			DocumentKey struct {
+				ID primitive.ObjectID `bson:"_id"`
+			} `bson:"documentKey"`
+		}
+		if err := stream.Decode(&event); err != nil {
+			return err
+		}
+
+		switch event.OperationType {
+		case "insert", "update", "replace", "delete":
+		default:
+			// drop/rename/invalidate 이후에는 스트림이 닫히므로 호출 측에서 캐시를 비우고 다시 연결한다
+			return fmt.Errorf("change stream ended by %q on %s", event.OperationType, event.NS.Coll)
+		}
+
+		onChange(TemplateChange{
+			Fragment: event.NS.Coll != r.collection.Name(),
+			ID:       event.DocumentKey.ID,
+		})
+	}
+	return stream.Err()
+}
@@ -0,0 +1,50 @@
+package mongodb
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// DeliveryRetention 수신자별 전송 기록 보관 기간 (재시도가 끝날 때까지만 필요하다)
+const DeliveryRetention = 7 * 24 * time.Hour
+
+// RecordDelivery 큐 메시지의 수신자에게 전송했음을 기록 (재시도 시 다시 보내지 않기 위해 사용)
+func (r *TemplateRepository) RecordDelivery(ctx context.Context, messageID, recipient string) error {
+	recipient = strings.ToLower(recipient)
+	_, err := r.deliveries.UpdateOne(ctx,
+		bson.M{"message_id": messageID, "recipient": recipient},
+		bson.M{"$setOnInsert": bson.M{
+			"message_id": messageID,
+			"recipient":  recipient,
+			"created_at": time.Now(),
+		}},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+// DeliveredRecipients 큐 메시지에서 이미 전송한 수신자 (소문자)
+func (r *TemplateRepository) DeliveredRecipients(ctx context.Context, messageID string) (map[string]bool, error) {
+	cursor, err := r.deliveries.Find(ctx, bson.M{"message_id": messageID})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var records []struct {
+		Recipient string `bson:"recipient"`
+	}
+	if err = cursor.All(ctx, &records); err != nil {
+		return nil, err
+	}
+
+	delivered := make(map[string]bool, len(records))
+	for _, record := range records {
+		delivered[record.Recipient] = true
+	}
+	return delivered, nil
+}
@@ -0,0 +1,147 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/kihyun1998/prisma-market/prisma-email-service/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// fragments 종류별 컬렉션 (email_layouts, email_partials)
+func (r *TemplateRepository) fragments(kind string) (*mongo.Collection, error) {
+	switch kind {
+	case models.FragmentKindLayout:
+		return r.layouts, nil
+	case models.FragmentKindPartial:
+		return r.partials, nil
+	default:
+		return nil, fmt.Errorf("unknown fragment kind: %s", kind)
+	}
+}
+
+// CreateFragment 레이아웃 또는 부분 템플릿 생성
+func (r *TemplateRepository) CreateFragment(ctx context.Context, fragment *models.Fragment) error {
+	collection, err := r.fragments(fragment.Kind)
+	if err != nil {
+		return err
+	}
+
+	fragment.CreatedAt = time.Now()
+	fragment.UpdatedAt = time.Now()
+
+	result, err := collection.InsertOne(ctx, fragment)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return fmt.Errorf("%s with this name already exists", fragment.Kind)
+		}
+		return err
+	}
+
+	if oid, ok := result.InsertedID.(primitive.ObjectID); ok {
+		fragment.ID = oid
+	}
+
+	return nil
+}
+
+// GetFragmentByID ID로 레이아웃 또는 부분 템플릿 조회
+func (r *TemplateRepository) GetFragmentByID(ctx context.Context, kind string, id primitive.ObjectID) (*models.Fragment, error) {
+	return r.findFragment(ctx, kind, bson.M{"_id": id})
+}
+
+// GetFragmentByName 이름으로 레이아웃 또는 부분 템플릿 조회
+func (r *TemplateRepository) GetFragmentByName(ctx context.Context, kind, name string) (*models.Fragment, error) {
+	return r.findFragment(ctx, kind, bson.M{"name": name})
+}
+
+func (r *TemplateRepository) findFragment(ctx context.Context, kind string, filter bson.M) (*models.Fragment, error) {
+	collection, err := r.fragments(kind)
+	if err != nil {
+		return nil, err
+	}
+
+	var fragment models.Fragment
+	err = collection.FindOne(ctx, filter).Decode(&fragment)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &fragment, nil
+}
+
+// UpdateFragment 레이아웃 또는 부분 템플릿 수정 (이름은 바꿀 수 없다)
+func (r *TemplateRepository) UpdateFragment(ctx context.Context, id primitive.ObjectID, fragment *models.Fragment) error {
+	collection, err := r.fragments(fragment.Kind)
+	if err != nil {
+		return err
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"description":  fragment.Description,
+			"html_content": fragment.HTMLContent,
+			"text_content": fragment.TextContent,
+			"updated_at":   time.Now(),
+		},
+	}
+
+	result, err := collection.UpdateByID(ctx, id, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("%s not found", fragment.Kind)
+	}
+
+	return nil
+}
+
+// DeleteFragment 레이아웃 또는 부분 템플릿 삭제
+func (r *TemplateRepository) DeleteFragment(ctx context.Context, kind string, id primitive.ObjectID) error {
+	collection, err := r.fragments(kind)
+	if err != nil {
+		return err
+	}
+
+	result, err := collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return fmt.Errorf("%s not found", kind)
+	}
+
+	return nil
+}
+
+// ListFragments 레이아웃 또는 부분 템플릿 목록 조회 (이름순)
+func (r *TemplateRepository) ListFragments(ctx context.Context, kind string) ([]*models.Fragment, error) {
+	collection, err := r.fragments(kind)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := collection.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "name", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var fragments []*models.Fragment
+	if err = cursor.All(ctx, &fragments); err != nil {
+		return nil, err
+	}
+
+	return fragments, nil
+}
@@ -0,0 +1,84 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/kihyun1998/prisma-market/prisma-email-service/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// AddSuppression 수신 거부 주소 등록 (이미 있으면 기존 기록 유지)
+func (r *TemplateRepository) AddSuppression(ctx context.Context, suppression *models.Suppression) error {
+	suppression.Email = strings.ToLower(suppression.Email)
+	suppression.CreatedAt = time.Now()
+
+	_, err := r.suppressions.UpdateOne(ctx,
+		bson.M{"email": suppression.Email},
+		bson.M{"$setOnInsert": bson.M{
+			"email":      suppression.Email,
+			"reason":     suppression.Reason,
+			"created_at": suppression.CreatedAt,
+		}},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+// SuppressedAddresses 주어진 주소 중 수신 거부한 주소 (소문자)
+func (r *TemplateRepository) SuppressedAddresses(ctx context.Context, emails []string) (map[string]bool, error) {
+	lowered := make(bson.A, len(emails))
+	for i, email := range emails {
+		lowered[i] = strings.ToLower(email)
+	}
+
+	cursor, err := r.suppressions.Find(ctx, bson.M{"email": bson.M{"$in": lowered}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var suppressions []*models.Suppression
+	if err = cursor.All(ctx, &suppressions); err != nil {
+		return nil, err
+	}
+
+	suppressed := make(map[string]bool, len(suppressions))
+	for _, s := range suppressions {
+		suppressed[s.Email] = true
+	}
+	return suppressed, nil
+}
+
+// DeleteSuppression 수신 거부 해제
+func (r *TemplateRepository) DeleteSuppression(ctx context.Context, email string) error {
+	result, err := r.suppressions.DeleteOne(ctx, bson.M{"email": strings.ToLower(email)})
+	if err != nil {
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return errors.New("suppression not found")
+	}
+
+	return nil
+}
+
+// ListSuppressions 수신 거부 주소 목록 (최근 등록순)
+func (r *TemplateRepository) ListSuppressions(ctx context.Context) ([]*models.Suppression, error) {
+	cursor, err := r.suppressions.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var suppressions []*models.Suppression
+	if err = cursor.All(ctx, &suppressions); err != nil {
+		return nil, err
+	}
+
+	return suppressions, nil
+}
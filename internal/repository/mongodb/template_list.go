@@ -0,0 +1,205 @@
+package mongodb
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/kihyun1998/prisma-market/prisma-email-service/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrInvalidCursor 목록 커서가 손상되었거나 다른 정렬 조건으로 만들어짐
+var ErrInvalidCursor = errors.New("invalid list cursor")
+
+// 목록 요약 보기에서 제외하는 본문 필드
+// 게시된 적 없는 템플릿은 초안 제목을 보여 주기 위해 초안은 제목 외의 본문만 제외한다.
+var summaryExcludedFields = []string{
+	"html_content", "text_content", "markdown_content", "mjml_content", "compiled_html",
+	"locales", "sample_data", "samples",
+	"draft.html_content", "draft.text_content", "draft.markdown_content", "draft.mjml_content", "draft.compiled_html",
+	"draft.locales", "draft.schema", "draft.referenced_variables",
+}
+
+// listCursor 마지막 항목의 정렬 값과 ID (같은 값이 여러 개여도 ID로 이어서 조회한다)
+type listCursor struct {
+	Sort  string          `json:"s"`
+	Value json.RawMessage `json:"v"`
+	ID    string          `json:"id"`
+}
+
+// ListTemplates 조건에 맞는 템플릿 한 페이지 조회 (정렬 값과 ID 기준 커서 페이지네이션)
+// opts.Sort와 opts.Limit은 호출 측에서 검증된 값이어야 한다.
+func (r *TemplateRepository) ListTemplates(ctx context.Context, opts *models.TemplateListOptions) (*models.TemplateList, error) {
+	field, direction := strings.TrimPrefix(opts.Sort, "-"), 1
+	if strings.HasPrefix(opts.Sort, "-") {
+		direction = -1
+	}
+
+	filter := templateListFilter(opts)
+	if opts.Cursor != "" {
+		after, err := cursorFilter(opts.Cursor, opts.Sort, field, direction)
+		if err != nil {
+			return nil, err
+		}
+		filter = bson.M{"$and": bson.A{filter, after}}
+	}
+
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: field, Value: direction}, {Key: "_id", Value: direction}}).
+		SetLimit(int64(opts.Limit) + 1)
+	if opts.Summary {
+		projection := bson.M{}
+		for _, name := range summaryExcludedFields {
+			projection[name] = 0
+		}
+		findOptions.SetProjection(projection)
+	}
+
+	cursor, err := r.collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	templates := []*models.Template{}
+	if err = cursor.All(ctx, &templates); err != nil {
+		return nil, err
+	}
+
+	if opts.Summary {
+		for _, t := range templates {
+			summarizeDraft(t)
+		}
+	}
+
+	list := &models.TemplateList{Templates: templates}
+	if len(templates) > opts.Limit {
+		list.Templates = templates[:opts.Limit]
+		next, err := encodeCursor(opts.Sort, field, list.Templates[opts.Limit-1])
+		if err != nil {
+			return nil, err
+		}
+		list.NextCursor = next
+	}
+	return list, nil
+}
+
+// summarizeDraft 게시된 제목이 없으면 초안 제목을 대신 보여 주고 초안은 응답에서 뺀다
+func summarizeDraft(t *models.Template) {
+	if t.Subject == "" && t.Draft != nil {
+		t.Subject = t.Draft.Subject
+	}
+	t.Draft = nil
+}
+
+// templateListFilter 태그/분류/팀/언어/상태/검색어 조건
+// 게시된 적 없는 템플릿은 본문이 초안에만 있으므로 언어와 제목은 초안도 함께 찾는다.
+func templateListFilter(opts *models.TemplateListOptions) bson.M {
+	var conditions bson.A
+	if opts.Tag != "" {
+		conditions = append(conditions, bson.M{"tags": opts.Tag})
+	}
+	if opts.Category != "" {
+		conditions = append(conditions, bson.M{"category": opts.Category})
+	}
+	if opts.Team != "" {
+		conditions = append(conditions, bson.M{"team": opts.Team})
+	}
+	if opts.Locale != "" {
+		conditions = append(conditions, bson.M{"$or": bson.A{
+			bson.M{"default_locale": opts.Locale},
+			bson.M{"locales." + opts.Locale: bson.M{"$exists": true}},
+			bson.M{"draft.default_locale": opts.Locale},
+			bson.M{"draft.locales." + opts.Locale: bson.M{"$exists": true}},
+		}})
+	}
+	switch opts.Status {
+	case models.TemplateStatusDraft:
+		conditions = append(conditions, bson.M{"status": models.TemplateStatusDraft})
+	case models.TemplateStatusPublished:
+		// 상태 필드가 없는 기존 템플릿은 게시된 것으로 본다
+		conditions = append(conditions, bson.M{"status": bson.M{"$ne": models.TemplateStatusDraft}})
+	}
+	if opts.Search != "" {
+		pattern := primitive.Regex{Pattern: regexp.QuoteMeta(opts.Search), Options: "i"}
+		conditions = append(conditions, bson.M{"$or": bson.A{
+			bson.M{"name": pattern},
+			bson.M{"subject": pattern},
+			bson.M{"draft.subject": pattern},
+		}})
+	}
+
+	if len(conditions) == 0 {
+		return bson.M{}
+	}
+	return bson.M{"$and": conditions}
+}
+
+// cursorFilter 커서 이후 항목 조건
+func cursorFilter(encoded, sort, field string, direction int) (bson.M, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c listCursor
+	if err := json.Unmarshal(raw, &c); err != nil || c.Sort != sort {
+		return nil, ErrInvalidCursor
+	}
+	id, err := primitive.ObjectIDFromHex(c.ID)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var value interface{}
+	if field == "name" {
+		var name string
+		if err := json.Unmarshal(c.Value, &name); err != nil {
+			return nil, ErrInvalidCursor
+		}
+		value = name
+	} else {
+		var t time.Time
+		if err := json.Unmarshal(c.Value, &t); err != nil {
+			return nil, ErrInvalidCursor
+		}
+		value = t
+	}
+
+	op := "$gt"
+	if direction < 0 {
+		op = "$lt"
+	}
+	return bson.M{"$or": bson.A{
+		bson.M{field: bson.M{op: value}},
+		bson.M{field: value, "_id": bson.M{op: id}},
+	}}, nil
+}
+
+func encodeCursor(sort, field string, last *models.Template) (string, error) {
+	var value interface{}
+	switch field {
+	case "name":
+		value = last.Name
+	case "created_at":
+		value = last.CreatedAt
+	default:
+		value = last.UpdatedAt
+	}
+
+	v, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	raw, err := json.Marshal(listCursor{Sort: sort, Value: v, ID: last.ID.Hex()})
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
@@ -0,0 +1,167 @@
+package mongodb
+
+import (
+	"encoding/base64"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/kihyun1998/prisma-market/prisma-email-service/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	last := &models.Template{
+		ID:        primitive.NewObjectID(),
+		Name:      "welcome",
+		CreatedAt: time.Date(2024, 1, 2, 3, 4, 5, 6000, time.UTC),
+		UpdatedAt: time.Date(2024, 2, 3, 4, 5, 6, 7000, time.UTC),
+	}
+
+	tests := []struct {
+		sort      string
+		field     string
+		direction int
+		op        string
+		value     interface{}
+	}{
+		{sort: "name", field: "name", direction: 1, op: "$gt", value: "welcome"},
+		{sort: "-name", field: "name", direction: -1, op: "$lt", value: "welcome"},
+		{sort: "created_at", field: "created_at", direction: 1, op: "$gt", value: last.CreatedAt},
+		{sort: "-updated_at", field: "updated_at", direction: -1, op: "$lt", value: last.UpdatedAt},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.sort, func(t *testing.T) {
+			encoded, err := encodeCursor(tt.sort, tt.field, last)
+			if err != nil {
+				t.Fatal(err)
+			}
+			filter, err := cursorFilter(encoded, tt.sort, tt.field, tt.direction)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// 정렬 값이 같은 항목은 ID로 이어서 조회한다
+			want := bson.M{"$or": bson.A{
+				bson.M{tt.field: bson.M{tt.op: tt.value}},
+				bson.M{tt.field: tt.value, "_id": bson.M{tt.op: last.ID}},
+			}}
+			if !reflect.DeepEqual(filter, want) {
+				t.Errorf("filter = %v, want %v", filter, want)
+			}
+		})
+	}
+}
+
+func TestCursorFilterRejectsInvalidCursors(t *testing.T) {
+	last := &models.Template{ID: primitive.NewObjectID(), Name: "welcome", UpdatedAt: time.Now()}
+	byName, err := encodeCursor("name", "name", last)
+	if err != nil {
+		t.Fatal(err)
+	}
+	byUpdated, err := encodeCursor("updated_at", "updated_at", last)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encode := func(raw string) string { return base64.RawURLEncoding.EncodeToString([]byte(raw)) }
+
+	tests := []struct {
+		name   string
+		cursor string
+		sort   string
+	}{
+		{name: "not base64", cursor: "!!!", sort: "name"},
+		{name: "not json", cursor: encode("nope"), sort: "name"},
+		{name: "other sort", cursor: byName, sort: "-name"},
+		{name: "other field", cursor: byUpdated, sort: "name"},
+		{name: "invalid id", cursor: encode(`{"s":"name","v":"welcome","id":"x"}`), sort: "name"},
+		{name: "wrong value type", cursor: encode(`{"s":"created_at","v":"welcome","id":"` + last.ID.Hex() + `"}`), sort: "created_at"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			field := tt.sort
+			if field[0] == '-' {
+				field = field[1:]
+			}
+			if _, err := cursorFilter(tt.cursor, tt.sort, field, 1); !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("error = %v, want ErrInvalidCursor", err)
+			}
+		})
+	}
+}
+
+func TestTemplateListFilter(t *testing.T) {
+	pattern := primitive.Regex{Pattern: `a\.b`, Options: "i"}
+
+	tests := []struct {
+		name string
+		opts models.TemplateListOptions
+		want bson.M
+	}{
+		{name: "no conditions", want: bson.M{}},
+		{
+			name: "tag, category and team",
+			opts: models.TemplateListOptions{Tag: "auth", Category: models.TemplateCategoryMarketing, Team: "growth"},
+			want: bson.M{"$and": bson.A{
+				bson.M{"tags": "auth"},
+				bson.M{"category": models.TemplateCategoryMarketing},
+				bson.M{"team": "growth"},
+			}},
+		},
+		{
+			name: "locale includes draft",
+			opts: models.TemplateListOptions{Locale: "ko"},
+			want: bson.M{"$and": bson.A{bson.M{"$or": bson.A{
+				bson.M{"default_locale": "ko"},
+				bson.M{"locales.ko": bson.M{"$exists": true}},
+				bson.M{"draft.default_locale": "ko"},
+				bson.M{"draft.locales.ko": bson.M{"$exists": true}},
+			}}}},
+		},
+		{
+			name: "draft status",
+			opts: models.TemplateListOptions{Status: models.TemplateStatusDraft},
+			want: bson.M{"$and": bson.A{bson.M{"status": models.TemplateStatusDraft}}},
+		},
+		{
+			name: "published status includes legacy templates",
+			opts: models.TemplateListOptions{Status: models.TemplateStatusPublished},
+			want: bson.M{"$and": bson.A{bson.M{"status": bson.M{"$ne": models.TemplateStatusDraft}}}},
+		},
+		{
+			name: "search is quoted and includes draft subject",
+			opts: models.TemplateListOptions{Search: "a.b"},
+			want: bson.M{"$and": bson.A{bson.M{"$or": bson.A{
+				bson.M{"name": pattern},
+				bson.M{"subject": pattern},
+				bson.M{"draft.subject": pattern},
+			}}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := templateListFilter(&tt.opts); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("filter = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSummarizeDraft(t *testing.T) {
+	draft := &models.Template{Draft: &models.TemplateContent{Subject: "Welcome draft"}}
+	summarizeDraft(draft)
+	if draft.Subject != "Welcome draft" || draft.Draft != nil {
+		t.Errorf("never published = %q, draft %v", draft.Subject, draft.Draft)
+	}
+
+	published := &models.Template{TemplateContent: models.TemplateContent{Subject: "Welcome"}, Draft: &models.TemplateContent{Subject: "Welcome v2"}}
+	summarizeDraft(published)
+	if published.Subject != "Welcome" {
+		t.Errorf("published subject = %q, want Welcome", published.Subject)
+	}
+}
@@ -3,6 +3,7 @@ package mongodb
 import (
 	"context"
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/kihyun1998/prisma-market/prisma-email-service/internal/models"
@@ -12,9 +13,23 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// 수정 대상이 없을 때의 에러 (서비스에서 코드가 있는 에러로 변환한다)
+var (
+	ErrTemplateNotFound        = errors.New("template not found")
+	ErrTemplateVersionNotFound = errors.New("template version not found")
+)
+
 type TemplateRepository struct {
 	db         *mongo.Database
 	collection *mongo.Collection
+	versions   *mongo.Collection
+	deleted    *mongo.Collection // 삭제된 템플릿 보관 (버전 이력은 versions에 남는다)
+	audit      *mongo.Collection
+	layouts    *mongo.Collection
+	partials   *mongo.Collection
+
+	suppressions *mongo.Collection
+	deliveries   *mongo.Collection
 }
 
 func NewTemplateRepository(mongoURI string) (*TemplateRepository, error) {
@@ -42,16 +57,93 @@ func NewTemplateRepository(mongoURI string) (*TemplateRepository, error) {
 		return nil, err
 	}
 
+	// 목록 필터용 인덱스
+	_, err = collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "tags", Value: 1}}},
+		{Keys: bson.D{{Key: "category", Value: 1}}},
+		{Keys: bson.D{{Key: "team", Value: 1}}},
+		{Keys: bson.D{{Key: "updated_at", Value: -1}, {Key: "_id", Value: -1}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 템플릿별 버전 번호에 대한 unique 인덱스 생성 (동시 수정 방지에도 사용)
+	versions := db.Collection("email_template_versions")
+	_, err = versions.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "template_id", Value: 1}, {Key: "version", Value: -1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 감사 로그 조회용 인덱스
+	audit := db.Collection("email_template_audit")
+	_, err = audit.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "template_id", Value: 1}, {Key: "created_at", Value: -1}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 레이아웃/부분 템플릿 이름에 대한 unique 인덱스 생성
+	layouts := db.Collection("email_layouts")
+	partials := db.Collection("email_partials")
+	for _, c := range []*mongo.Collection{layouts, partials} {
+		_, err = c.Indexes().CreateOne(ctx, mongo.IndexModel{
+			Keys:    bson.D{{Key: "name", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// 수신 거부 주소에 대한 unique 인덱스 생성
+	suppressions := db.Collection("email_suppressions")
+	_, err = suppressions.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "email", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 큐 메시지별 수신자 전송 기록 (재시도 중복 전송 방지, 보관 기간 후 자동 삭제)
+	deliveries := db.Collection("email_deliveries")
+	_, err = deliveries.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "message_id", Value: 1}, {Key: "recipient", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "created_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(int32(DeliveryRetention.Seconds())),
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
 	return &TemplateRepository{
-		db:         db,
-		collection: collection,
+		db:           db,
+		collection:   collection,
+		versions:     versions,
+		audit:        audit,
+		layouts:      layouts,
+		partials:     partials,
+		deleted:      db.Collection("email_templates_deleted"),
+		suppressions: suppressions,
+		deliveries:   deliveries,
 	}, nil
 }
 
-// CreateTemplate 새로운 이메일 템플릿 생성
+// CreateTemplate 새로운 이메일 템플릿 생성 (게시 전 초안 상태)
 func (r *TemplateRepository) CreateTemplate(ctx context.Context, template *models.Template) error {
 	template.CreatedAt = time.Now()
 	template.UpdatedAt = time.Now()
+	template.Version = 0
 
 	result, err := r.collection.InsertOne(ctx, template)
 	if err != nil {
@@ -94,49 +186,218 @@ func (r *TemplateRepository) GetTemplateByName(ctx context.Context, name string)
 	return &template, nil
 }
 
-// UpdateTemplate 템플릿 업데이트
-func (r *TemplateRepository) UpdateTemplate(ctx context.Context, id primitive.ObjectID, template *models.Template) error {
-	template.UpdatedAt = time.Now()
-
+// UpdateDraft 템플릿 초안과 예시 변수 업데이트 (게시된 내용은 바뀌지 않는다)
+func (r *TemplateRepository) UpdateDraft(ctx context.Context, id primitive.ObjectID, template *models.Template) (*models.Template, error) {
 	update := bson.M{
 		"$set": bson.M{
-			"subject":      template.Subject,
-			"html_content": template.HTMLContent,
-			"text_content": template.TextContent,
-			"variables":    template.Variables,
-			"updated_at":   template.UpdatedAt,
+			"draft":       &template.TemplateContent,
+			"sample_data": template.SampleData,
+			"samples":     template.Samples,
+			"tags":        template.Tags,
+			"category":    template.Category,
+			"team":        template.Team,
+			"description": template.Description,
+			"updated_at":  time.Now(),
 		},
 	}
 
 	result, err := r.collection.UpdateByID(ctx, id, update)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	if result.MatchedCount == 0 {
-		return errors.New("template not found")
+		return nil, ErrTemplateNotFound
 	}
 
-	return nil
+	return r.GetTemplateByID(ctx, id)
+}
+
+// PublishTemplate 초안을 다음 버전으로 게시
+func (r *TemplateRepository) PublishTemplate(ctx context.Context, id primitive.ObjectID) (*models.Template, error) {
+	current, err := r.GetTemplateByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if current == nil {
+		return nil, ErrTemplateNotFound
+	}
+
+	return r.saveNewVersion(ctx, id, current.DraftContent())
 }
 
-// DeleteTemplate 템플릿 삭제
+// saveNewVersion 본문을 다음 버전으로 기록하고 현재 템플릿에 반영
+func (r *TemplateRepository) saveNewVersion(ctx context.Context, id primitive.ObjectID, content *models.TemplateContent) (*models.Template, error) {
+	current, err := r.GetTemplateByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if current == nil {
+		return nil, ErrTemplateNotFound
+	}
+
+	// 버전 관리 이전에 만들어진 게시 템플릿은 기존 본문을 버전 1로 남긴다
+	storedVersion := current.Version
+	if storedVersion == 0 && current.IsPublished() {
+		current.Version = 1
+		if err := r.insertVersion(ctx, id, current.Version, &current.TemplateContent); err != nil && !mongo.IsDuplicateKeyError(err) {
+			return nil, err
+		}
+	}
+
+	// 버전 이력을 먼저 기록 - unique 인덱스 덕분에 동시 수정 중 하나만 성공한다
+	next := current.Version + 1
+	if err := r.insertVersion(ctx, id, next, content); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, errors.New("template was modified concurrently")
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	set := contentFields(content)
+	set["version"] = next
+	set["status"] = models.TemplateStatusPublished
+	set["published_at"] = now
+	set["updated_at"] = now
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id, "version": versionFilter(storedVersion)}, bson.M{"$set": set})
+	if err == nil && result.MatchedCount == 0 {
+		err = errors.New("template was modified concurrently")
+	}
+	if err != nil {
+		// 반영하지 못한 버전을 남기면 이후 게시가 모두 같은 버전 번호에서 충돌하므로 지운다
+		if _, cleanupErr := r.versions.DeleteOne(context.WithoutCancel(ctx), bson.M{"template_id": id, "version": next}); cleanupErr != nil {
+			return nil, fmt.Errorf("%v (failed to remove unpublished version %d: %v)", err, next, cleanupErr)
+		}
+		return nil, err
+	}
+
+	return r.GetTemplateByID(ctx, id)
+}
+
+// contentFields 템플릿 본문 필드 업데이트 문서
+func contentFields(content *models.TemplateContent) bson.M {
+	return bson.M{
+		"subject":              content.Subject,
+		"html_content":         content.HTMLContent,
+		"text_content":         content.TextContent,
+		"preheader":            content.Preheader,
+		"format":               content.Format,
+		"markdown_content":     content.MarkdownContent,
+		"mjml_content":         content.MJMLContent,
+		"compiled_html":        content.CompiledHTML,
+		"layout":               content.Layout,
+		"variables":            content.Variables,
+		"schema":               content.Schema,
+		"referenced_variables": content.ReferencedVariables,
+		"default_locale":       content.DefaultLocale,
+		"locales":              content.Locales,
+	}
+}
+
+// versionFilter 버전 필드 조건 (버전 관리 이전 문서는 필드가 없다)
+func versionFilter(version int) interface{} {
+	if version == 0 {
+		return bson.M{"$in": bson.A{0, nil}}
+	}
+	return version
+}
+
+// insertVersion 버전 이력 추가
+func (r *TemplateRepository) insertVersion(ctx context.Context, templateID primitive.ObjectID, version int, content *models.TemplateContent) error {
+	_, err := r.versions.InsertOne(ctx, &models.TemplateVersion{
+		TemplateID:      templateID,
+		Version:         version,
+		TemplateContent: *content,
+		CreatedAt:       time.Now(),
+	})
+	return err
+}
+
+// ListTemplateVersions 템플릿의 버전 이력 조회 (최신순)
+func (r *TemplateRepository) ListTemplateVersions(ctx context.Context, templateID primitive.ObjectID) ([]*models.TemplateVersion, error) {
+	cursor, err := r.versions.Find(ctx, bson.M{"template_id": templateID}, options.Find().SetSort(bson.D{{Key: "version", Value: -1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var versions []*models.TemplateVersion
+	if err = cursor.All(ctx, &versions); err != nil {
+		return nil, err
+	}
+
+	return versions, nil
+}
+
+// GetTemplateVersion 특정 버전 조회
+func (r *TemplateRepository) GetTemplateVersion(ctx context.Context, templateID primitive.ObjectID, version int) (*models.TemplateVersion, error) {
+	var v models.TemplateVersion
+	err := r.versions.FindOne(ctx, bson.M{"template_id": templateID, "version": version}).Decode(&v)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &v, nil
+}
+
+// RollbackTemplate 이전 버전의 본문을 새 버전으로 다시 게시 (초안은 유지)
+func (r *TemplateRepository) RollbackTemplate(ctx context.Context, templateID primitive.ObjectID, version int) (*models.Template, error) {
+	target, err := r.GetTemplateVersion(ctx, templateID, version)
+	if err != nil {
+		return nil, err
+	}
+	if target == nil {
+		return nil, ErrTemplateVersionNotFound
+	}
+
+	return r.saveNewVersion(ctx, templateID, &target.TemplateContent)
+}
+
+// deletedTemplate 삭제된 템플릿 보관 문서
+type deletedTemplate struct {
+	models.Template `bson:",inline"`
+	DeletedAt       time.Time `bson:"deleted_at"`
+}
+
+// DeleteTemplate 템플릿 삭제 (소프트 삭제)
+// 템플릿 문서는 삭제 보관 컬렉션으로 옮기고 버전 이력은 남겨, 과거에 보낸 메일이 사용한 본문을 계속 조회할 수 있게 한다.
+// 같은 이름으로 새 템플릿을 만들 수 있도록 현재 컬렉션에서는 제거한다.
 func (r *TemplateRepository) DeleteTemplate(ctx context.Context, id primitive.ObjectID) error {
-	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	current, err := r.GetTemplateByID(ctx, id)
 	if err != nil {
 		return err
 	}
+	if current == nil {
+		return ErrTemplateNotFound
+	}
 
-	if result.DeletedCount == 0 {
-		return errors.New("template not found")
+	_, err = r.deleted.ReplaceOne(ctx, bson.M{"_id": id}, deletedTemplate{Template: *current, DeletedAt: time.Now()}, options.Replace().SetUpsert(true))
+	if err != nil {
+		return err
 	}
 
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrTemplateNotFound
+	}
 	return nil
 }
 
-// ListTemplates 모든 템플릿 조회
-func (r *TemplateRepository) ListTemplates(ctx context.Context) ([]*models.Template, error) {
-	cursor, err := r.collection.Find(ctx, bson.M{})
+// FindTemplatesByName 이름으로 여러 템플릿 조회 (names가 비어 있으면 전체, 이름순)
+func (r *TemplateRepository) FindTemplatesByName(ctx context.Context, names []string) ([]*models.Template, error) {
+	filter := bson.M{}
+	if len(names) > 0 {
+		filter["name"] = bson.M{"$in": names}
+	}
+
+	cursor, err := r.collection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "name", Value: 1}}))
 	if err != nil {
 		return nil, err
 	}
@@ -150,6 +411,38 @@ func (r *TemplateRepository) ListTemplates(ctx context.Context) ([]*models.Templ
 	return templates, nil
 }
 
+// InsertAuditEntry 감사 로그 기록
+func (r *TemplateRepository) InsertAuditEntry(ctx context.Context, entry *models.TemplateAuditEntry) error {
+	entry.CreatedAt = time.Now()
+
+	result, err := r.audit.InsertOne(ctx, entry)
+	if err != nil {
+		return err
+	}
+
+	if oid, ok := result.InsertedID.(primitive.ObjectID); ok {
+		entry.ID = oid
+	}
+
+	return nil
+}
+
+// ListAuditEntries 템플릿 감사 로그 조회 (최신순)
+func (r *TemplateRepository) ListAuditEntries(ctx context.Context, templateID primitive.ObjectID) ([]*models.TemplateAuditEntry, error) {
+	cursor, err := r.audit.Find(ctx, bson.M{"template_id": templateID}, options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var entries []*models.TemplateAuditEntry
+	if err = cursor.All(ctx, &entries); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
 // Close MongoDB 연결 종료
 func (r *TemplateRepository) Close(ctx context.Context) error {
 	return r.db.Client().Disconnect(ctx)
@@ -0,0 +1,454 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/kihyun1998/prisma-market/prisma-email-service/internal/models"
+	"github.com/kihyun1998/prisma-market/prisma-email-service/pkg/render"
+	"github.com/kihyun1998/prisma-market/prisma-email-service/pkg/textdiff"
+)
+
+// importComment 가져오기로 게시한 버전의 감사 로그 메모
+const importComment = "imported from bundle"
+
+// ExportBundle 템플릿을 번들로 내보내기 (names가 비어 있으면 전체)
+// 일부만 내보내면 해당 템플릿이 사용하는 레이아웃과 (하위 포함까지) 부분 템플릿만 담는다.
+// 에셋은 저장하지 않으므로 내보내지 않는다 (models.Bundle 참고).
+func (s *EmailService) ExportBundle(ctx context.Context, names []string) (*models.Bundle, error) {
+	templates, err := s.templates.FindTemplatesByName(ctx, names)
+	if err != nil {
+		return nil, err
+	}
+
+	found := make(map[string]bool, len(templates))
+	for _, template := range templates {
+		found[template.Name] = true
+	}
+	for _, name := range names {
+		if !found[name] {
+			return nil, newError(ErrCodeTemplateNameNotFound, "template not found: name %q", name)
+		}
+	}
+
+	bundle := &models.Bundle{
+		FormatVersion: models.BundleFormatVersion,
+		ExportedAt:    time.Now().UTC(),
+		Templates:     make([]models.BundleTemplate, 0, len(templates)),
+	}
+
+	var layoutNames, pending []string
+	for _, template := range templates {
+		content := template.DraftContent()
+		if template.IsPublished() {
+			content = &template.TemplateContent
+		}
+
+		if content.Layout != "" {
+			layoutNames = append(layoutNames, content.Layout)
+		}
+		refs, _ := render.ReferencedTemplates(includeSources(content)...)
+		pending = append(pending, refs...)
+
+		bundle.Templates = append(bundle.Templates, models.BundleTemplate{
+			Name:        template.Name,
+			Status:      template.Status,
+			Version:     template.Version,
+			Content:     exportContent(content),
+			SampleData:  template.SampleData,
+			Samples:     template.Samples,
+			Tags:        template.Tags,
+			Category:    template.Category,
+			Team:        template.Team,
+			Description: template.Description,
+		})
+	}
+
+	if len(names) == 0 {
+		if bundle.Layouts, err = s.exportFragments(ctx, models.FragmentKindLayout); err != nil {
+			return nil, err
+		}
+		if bundle.Partials, err = s.exportFragments(ctx, models.FragmentKindPartial); err != nil {
+			return nil, err
+		}
+		return bundle, nil
+	}
+
+	// 사용하는 레이아웃과 부분 템플릿만 담는다 (없는 조각은 저장 시 경고했으므로 건너뛴다)
+	seen := make(map[string]bool)
+	for _, name := range layoutNames {
+		if seen["layout:"+name] {
+			continue
+		}
+		seen["layout:"+name] = true
+
+		layout, err := s.templates.GetFragmentByName(ctx, models.FragmentKindLayout, name)
+		if err != nil {
+			return nil, err
+		}
+		if layout == nil {
+			continue
+		}
+		bundle.Layouts = append(bundle.Layouts, bundleFragment(layout))
+		refs, _ := render.ReferencedTemplates(layout.HTMLContent, layout.TextContent)
+		pending = append(pending, refs...)
+	}
+	for len(pending) > 0 {
+		name := pending[0]
+		pending = pending[1:]
+		if seen["partial:"+name] || name == render.ContentTemplate {
+			continue
+		}
+		seen["partial:"+name] = true
+
+		partial, err := s.templates.GetFragmentByName(ctx, models.FragmentKindPartial, name)
+		if err != nil {
+			return nil, err
+		}
+		if partial == nil {
+			continue
+		}
+		bundle.Partials = append(bundle.Partials, bundleFragment(partial))
+		refs, _ := render.ReferencedTemplates(partial.HTMLContent, partial.TextContent)
+		pending = append(pending, refs...)
+	}
+
+	return bundle, nil
+}
+
+func (s *EmailService) exportFragments(ctx context.Context, kind string) ([]models.BundleFragment, error) {
+	fragments, err := s.templates.ListFragments(ctx, kind)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]models.BundleFragment, 0, len(fragments))
+	for _, fragment := range fragments {
+		result = append(result, bundleFragment(fragment))
+	}
+	return result, nil
+}
+
+func bundleFragment(fragment *models.Fragment) models.BundleFragment {
+	return models.BundleFragment{
+		Name:        fragment.Name,
+		Description: fragment.Description,
+		HTMLContent: fragment.HTMLContent,
+		TextContent: fragment.TextContent,
+	}
+}
+
+// exportContent 저장 시 다시 계산되는 필드(컴파일 결과, 참조 변수)를 뺀 본문
+func exportContent(content *models.TemplateContent) models.TemplateContent {
+	exported := *content
+	exported.CompiledHTML = ""
+	exported.ReferencedVariables = nil
+	if len(content.Locales) > 0 {
+		exported.Locales = make(map[string]models.LocalizedContent, len(content.Locales))
+		for locale, variant := range content.Locales {
+			variant.CompiledHTML = ""
+			exported.Locales[locale] = variant
+		}
+	}
+	return exported
+}
+
+// importStep 가져오기 항목 하나의 계획
+type importStep struct {
+	item      *models.ImportItem
+	template  *models.Template
+	fragment  *models.Fragment
+	currentID primitive.ObjectID
+	publish   bool // 템플릿을 저장한 뒤 게시
+}
+
+// ImportBundle 번들 가져오기 (이름으로 기존 항목과 대응)
+// 모든 항목을 먼저 검증하고, 하나라도 실패하면 아무것도 저장하지 않는다.
+// 레이아웃과 부분 템플릿을 먼저 저장해 템플릿 저장 시 참조를 확인할 수 있게 한다.
+// 저장은 트랜잭션이 아니므로 저장 도중 실패하면 이미 반영된 항목을 결과에 담아 에러와 함께 반환한다.
+// 에셋은 다루지 않는다 (models.Bundle 참고).
+func (s *EmailService) ImportBundle(ctx context.Context, bundle *models.Bundle, opts *models.ImportOptions) (*models.ImportResult, error) {
+	if err := s.checkWritable(); err != nil {
+		return nil, err
+	}
+	if bundle.FormatVersion != models.BundleFormatVersion {
+		return nil, newError(ErrCodeInvalidBundle, "unsupported bundle format_version %d (expected %d)", bundle.FormatVersion, models.BundleFormatVersion)
+	}
+
+	switch opts.Conflict {
+	case "":
+		opts.Conflict = models.ImportConflictSkip
+	case models.ImportConflictSkip, models.ImportConflictOverwrite, models.ImportConflictNewVersion:
+	default:
+		return nil, newError(ErrCodeInvalidRequest, "unsupported conflict policy %q (expected %q, %q or %q)",
+			opts.Conflict, models.ImportConflictSkip, models.ImportConflictOverwrite, models.ImportConflictNewVersion)
+	}
+
+	if err := checkBundleNames(bundle); err != nil {
+		return nil, err
+	}
+
+	var steps []*importStep
+	for _, group := range []struct {
+		kind      string
+		fragments []models.BundleFragment
+	}{
+		{models.FragmentKindLayout, bundle.Layouts},
+		{models.FragmentKindPartial, bundle.Partials},
+	} {
+		for _, fragment := range group.fragments {
+			step, err := s.planFragmentImport(ctx, group.kind, fragment, opts.Conflict)
+			if err != nil {
+				return nil, err
+			}
+			steps = append(steps, step)
+		}
+	}
+	for i := range bundle.Templates {
+		step, err := s.planTemplateImport(ctx, &bundle.Templates[i], opts.Conflict)
+		if err != nil {
+			return nil, err
+		}
+		steps = append(steps, step)
+	}
+
+	result := &models.ImportResult{DryRun: opts.DryRun, Items: make([]models.ImportItem, 0, len(steps))}
+	var details []FieldError
+	for _, step := range steps {
+		if step.item.Error != "" {
+			details = append(details, FieldError{Field: step.item.Kind + "." + step.item.Name, Message: step.item.Error})
+		}
+	}
+
+	if opts.DryRun {
+		for _, step := range steps {
+			result.Items = append(result.Items, *step.item)
+		}
+		return result, nil
+	}
+	if len(details) > 0 {
+		return nil, &Error{
+			Code:    ErrCodeInvalidBundle,
+			Message: fmt.Sprintf("bundle has %d invalid item(s); nothing was imported", len(details)),
+			Details: details,
+		}
+	}
+
+	for _, step := range steps {
+		if err := s.applyImportStep(ctx, step); err != nil {
+			err = fmt.Errorf("failed to import %s %q after %d item(s): %v", step.item.Kind, step.item.Name, len(result.Items), err)
+			result.Error = err.Error()
+			return result, err
+		}
+		result.Items = append(result.Items, *step.item)
+	}
+	return result, nil
+}
+
+// checkBundleNames 빈 이름과 같은 종류 안의 중복 이름 검사
+func checkBundleNames(bundle *models.Bundle) error {
+	seen := make(map[string]bool)
+	check := func(kind, name string) error {
+		if name == "" {
+			return newError(ErrCodeInvalidBundle, "%s without a name", kind)
+		}
+		if seen[kind+":"+name] {
+			return newError(ErrCodeInvalidBundle, "duplicate %s %q", kind, name)
+		}
+		seen[kind+":"+name] = true
+		return nil
+	}
+
+	for _, t := range bundle.Templates {
+		if err := check("template", t.Name); err != nil {
+			return err
+		}
+	}
+	for _, f := range bundle.Layouts {
+		if err := check(models.FragmentKindLayout, f.Name); err != nil {
+			return err
+		}
+	}
+	for _, f := range bundle.Partials {
+		if err := check(models.FragmentKindPartial, f.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *EmailService) planFragmentImport(ctx context.Context, kind string, bf models.BundleFragment, conflict string) (*importStep, error) {
+	fragment := &models.Fragment{
+		Kind:        kind,
+		Name:        bf.Name,
+		Description: bf.Description,
+		HTMLContent: bf.HTMLContent,
+		TextContent: bf.TextContent,
+	}
+	step := &importStep{
+		item:     &models.ImportItem{Kind: kind, Name: bf.Name, Action: models.ImportActionCreate},
+		fragment: fragment,
+	}
+
+	warnings, err := s.checkFragment(ctx, fragment)
+	if err := importItemError(step.item, err); err != nil {
+		return nil, err
+	}
+	step.item.Warnings = warnings
+
+	current, err := s.templates.GetFragmentByName(ctx, kind, bf.Name)
+	if err != nil {
+		return nil, err
+	}
+	if current == nil {
+		return step, nil
+	}
+	step.currentID = current.ID
+
+	changes := make(map[string]string)
+	for _, f := range []struct{ name, from, to string }{
+		{"description", current.Description, fragment.Description},
+		{"html_content", current.HTMLContent, fragment.HTMLContent},
+		{"text_content", current.TextContent, fragment.TextContent},
+	} {
+		if d := textdiff.Unified("current/"+f.name, "bundle/"+f.name, f.from, f.to); d != "" {
+			changes[f.name] = d
+		}
+	}
+	step.item.Action = conflictAction(conflict, len(changes) > 0)
+	if step.item.Action != models.ImportActionUnchanged {
+		step.item.Changes = changes
+	}
+	return step, nil
+}
+
+func (s *EmailService) planTemplateImport(ctx context.Context, bt *models.BundleTemplate, conflict string) (*importStep, error) {
+	template := &models.Template{
+		Name:            bt.Name,
+		TemplateContent: bt.Content,
+		SampleData:      bt.SampleData,
+		Samples:         bt.Samples,
+		Tags:            bt.Tags,
+		Category:        bt.Category,
+		Team:            bt.Team,
+		Description:     bt.Description,
+	}
+	step := &importStep{
+		item:     &models.ImportItem{Kind: "template", Name: bt.Name, Action: models.ImportActionCreate},
+		template: template,
+		publish:  bt.Status != models.TemplateStatusDraft,
+	}
+
+	// 저장 시와 같은 검사 (본문 컴파일 결과와 참조 변수도 여기서 채워진다)
+	err := s.checkTemplateMetadata(template)
+	if err == nil {
+		err = validateSchemaDefinition(template.Schema)
+	}
+	if err == nil {
+		step.item.Warnings, err = checkTemplateContent(&template.TemplateContent)
+	}
+	if err := importItemError(step.item, err); err != nil {
+		return nil, err
+	}
+	step.item.Warnings = append(step.item.Warnings, s.templateIncludeWarnings(ctx, &template.TemplateContent)...)
+
+	current, err := s.templates.GetTemplateByName(ctx, bt.Name)
+	if err != nil {
+		return nil, err
+	}
+	if current == nil {
+		return step, nil
+	}
+	step.currentID = current.ID
+
+	// 현재 게시된 본문(게시 전이면 초안)과 비교
+	base := current.DraftContent()
+	if current.IsPublished() {
+		base = &current.TemplateContent
+	}
+	changes := contentDiff("current", "bundle", base, &template.TemplateContent)
+	for _, f := range []struct{ name, from, to string }{
+		{"tags", strings.Join(current.Tags, "\n"), strings.Join(template.Tags, "\n")},
+		{"category", current.Category, template.Category},
+		{"team", current.Team, template.Team},
+		{"description", current.Description, template.Description},
+	} {
+		if d := textdiff.Unified("current/"+f.name, "bundle/"+f.name, f.from, f.to); d != "" {
+			changes[f.name] = d
+		}
+	}
+
+	// 내용이 같아도 번들은 게시 상태인데 대상이 아직 게시되지 않았으면 게시가 필요하다
+	changed := len(changes) > 0 || (step.publish && !current.IsPublished())
+	step.item.Action = conflictAction(conflict, changed)
+	step.publish = step.publish && step.item.Action == models.ImportActionNewVersion
+	if step.item.Action != models.ImportActionUnchanged {
+		step.item.Changes = changes
+	}
+	return step, nil
+}
+
+// conflictAction 같은 이름이 이미 있을 때의 처리
+func conflictAction(conflict string, changed bool) string {
+	switch {
+	case !changed:
+		return models.ImportActionUnchanged
+	case conflict == models.ImportConflictOverwrite:
+		return models.ImportActionOverwrite
+	case conflict == models.ImportConflictNewVersion:
+		return models.ImportActionNewVersion
+	default:
+		return models.ImportActionSkip
+	}
+}
+
+// importItemError 검증 실패는 항목 에러로 기록하고, 그 밖의 에러(DB 등)는 그대로 반환
+func importItemError(item *models.ImportItem, err error) error {
+	if err == nil {
+		return nil
+	}
+	var serviceErr *Error
+	if !errors.As(err, &serviceErr) {
+		return err
+	}
+	item.Error = serviceErr.Message
+	return nil
+}
+
+func (s *EmailService) applyImportStep(ctx context.Context, step *importStep) error {
+	switch step.item.Action {
+	case models.ImportActionSkip, models.ImportActionUnchanged:
+		return nil
+	}
+
+	if step.fragment != nil {
+		if step.item.Action == models.ImportActionCreate {
+			return s.CreateFragment(ctx, step.fragment)
+		}
+		return s.UpdateFragment(ctx, step.currentID, step.fragment)
+	}
+
+	id := step.currentID
+	if step.item.Action == models.ImportActionCreate {
+		if err := s.CreateTemplate(ctx, step.template); err != nil {
+			return err
+		}
+		id = step.template.ID
+	} else if err := s.UpdateTemplate(ctx, id, step.template); err != nil {
+		return err
+	}
+
+	if step.publish {
+		published, err := s.PublishTemplate(ctx, id, importComment)
+		if err != nil {
+			return err
+		}
+		step.item.Version = published.Version
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package services
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/url"
+	"path"
+	"time"
+
+	"github.com/kihyun1998/prisma-market/prisma-email-service/internal/models"
+)
+
+// tar 번들 구성
+// manifest.json              형식 버전과 내보낸 시각
+// templates/<name>.json      models.BundleTemplate
+// layouts/<name>.json        models.BundleFragment
+// partials/<name>.json       models.BundleFragment
+const bundleManifest = "manifest.json"
+
+// 번들 파일 하나의 최대 크기
+const maxBundleEntrySize = 10 << 20
+
+type bundleManifestFile struct {
+	FormatVersion int       `json:"format_version"`
+	ExportedAt    time.Time `json:"exported_at"`
+}
+
+// WriteBundleTar 번들을 항목별 JSON 파일로 나눈 tar로 기록
+func WriteBundleTar(w io.Writer, bundle *models.Bundle) error {
+	tw := tar.NewWriter(w)
+
+	write := func(name string, v interface{}) error {
+		body, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := tw.WriteHeader(&tar.Header{
+			Name:    name,
+			Mode:    0o644,
+			Size:    int64(len(body)),
+			ModTime: bundle.ExportedAt,
+		}); err != nil {
+			return err
+		}
+		_, err = tw.Write(body)
+		return err
+	}
+
+	if err := write(bundleManifest, bundleManifestFile{FormatVersion: bundle.FormatVersion, ExportedAt: bundle.ExportedAt}); err != nil {
+		return err
+	}
+	for _, t := range bundle.Templates {
+		if err := write(bundleEntryName("templates", t.Name), t); err != nil {
+			return err
+		}
+	}
+	for _, f := range bundle.Layouts {
+		if err := write(bundleEntryName("layouts", f.Name), f); err != nil {
+			return err
+		}
+	}
+	for _, f := range bundle.Partials {
+		if err := write(bundleEntryName("partials", f.Name), f); err != nil {
+			return err
+		}
+	}
+
+	return tw.Close()
+}
+
+// bundleEntryName 이름을 경로로 쓸 수 있게 이스케이프 (실제 이름은 JSON 안의 name을 사용한다)
+func bundleEntryName(dir, name string) string {
+	return dir + "/" + url.PathEscape(name) + ".json"
+}
+
+// ReadBundleTar WriteBundleTar로 만든 tar 번들 읽기
+func ReadBundleTar(r io.Reader) (*models.Bundle, error) {
+	tr := tar.NewReader(r)
+	bundle := &models.Bundle{}
+	hasManifest := false
+
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, newError(ErrCodeInvalidBundle, "invalid tar bundle: %v", err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		if header.Size > maxBundleEntrySize {
+			return nil, newError(ErrCodeInvalidBundle, "bundle entry %s is too large", header.Name)
+		}
+
+		decode := func(v interface{}) error {
+			if err := json.NewDecoder(io.LimitReader(tr, maxBundleEntrySize)).Decode(v); err != nil {
+				return newError(ErrCodeInvalidBundle, "invalid bundle entry %s: %v", header.Name, err)
+			}
+			return nil
+		}
+
+		name := path.Clean(header.Name)
+		switch dir := path.Dir(name); {
+		case name == bundleManifest:
+			var manifest bundleManifestFile
+			if err := decode(&manifest); err != nil {
+				return nil, err
+			}
+			bundle.FormatVersion = manifest.FormatVersion
+			bundle.ExportedAt = manifest.ExportedAt
+			hasManifest = true
+		case dir == "templates":
+			var t models.BundleTemplate
+			if err := decode(&t); err != nil {
+				return nil, err
+			}
+			bundle.Templates = append(bundle.Templates, t)
+		case dir == "layouts" || dir == "partials":
+			var f models.BundleFragment
+			if err := decode(&f); err != nil {
+				return nil, err
+			}
+			if dir == "layouts" {
+				bundle.Layouts = append(bundle.Layouts, f)
+			} else {
+				bundle.Partials = append(bundle.Partials, f)
+			}
+		default:
+			return nil, newError(ErrCodeInvalidBundle, "unexpected bundle entry %s", header.Name)
+		}
+	}
+
+	if !hasManifest {
+		return nil, newError(ErrCodeInvalidBundle, "bundle has no %s", bundleManifest)
+	}
+	return bundle, nil
+}
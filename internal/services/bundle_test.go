@@ -0,0 +1,242 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/kihyun1998/prisma-market/prisma-email-service/internal/models"
+)
+
+// memoryReader 이름으로 조회하는 메모리 템플릿 원본 (쓰기는 지원하지 않는다)
+type memoryReader struct {
+	templates map[string]*models.Template
+	fragments map[string]*models.Fragment // kind + ":" + name
+}
+
+func (m *memoryReader) GetTemplateByID(ctx context.Context, id primitive.ObjectID) (*models.Template, error) {
+	for _, t := range m.templates {
+		if t.ID == id {
+			return t, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *memoryReader) GetTemplateByName(ctx context.Context, name string) (*models.Template, error) {
+	return m.templates[name], nil
+}
+
+func (m *memoryReader) FindTemplatesByName(ctx context.Context, names []string) ([]*models.Template, error) {
+	var result []*models.Template
+	for _, name := range names {
+		if t, ok := m.templates[name]; ok {
+			result = append(result, t)
+		}
+	}
+	return result, nil
+}
+
+func (m *memoryReader) ListTemplates(ctx context.Context, opts *models.TemplateListOptions) (*models.TemplateList, error) {
+	return &models.TemplateList{}, nil
+}
+
+func (m *memoryReader) ListTemplateVersions(ctx context.Context, templateID primitive.ObjectID) ([]*models.TemplateVersion, error) {
+	return nil, nil
+}
+
+func (m *memoryReader) GetTemplateVersion(ctx context.Context, templateID primitive.ObjectID, version int) (*models.TemplateVersion, error) {
+	return nil, nil
+}
+
+func (m *memoryReader) GetFragmentByID(ctx context.Context, kind string, id primitive.ObjectID) (*models.Fragment, error) {
+	return nil, nil
+}
+
+func (m *memoryReader) GetFragmentByName(ctx context.Context, kind, name string) (*models.Fragment, error) {
+	return m.fragments[kind+":"+name], nil
+}
+
+func (m *memoryReader) ListFragments(ctx context.Context, kind string) ([]*models.Fragment, error) {
+	var result []*models.Fragment
+	for _, f := range m.fragments {
+		if f.Kind == kind {
+			result = append(result, f)
+		}
+	}
+	return result, nil
+}
+
+func bundleService() *EmailService {
+	return &EmailService{templates: &memoryReader{
+		templates: map[string]*models.Template{
+			"welcome": {
+				ID:     primitive.NewObjectID(),
+				Name:   "welcome",
+				Status: models.TemplateStatusPublished,
+				TemplateContent: models.TemplateContent{
+					Subject:     "Welcome",
+					HTMLContent: "<p>Hi {{.name}}</p>",
+				},
+			},
+		},
+		fragments: map[string]*models.Fragment{},
+	}}
+}
+
+func TestImportBundleDryRun(t *testing.T) {
+	// 저장소가 없으므로 저장을 시도하면 패닉이 난다
+	s := bundleService()
+	bundle := &models.Bundle{
+		FormatVersion: models.BundleFormatVersion,
+		Layouts:       []models.BundleFragment{{Name: "base", HTMLContent: `<div>{{template "content" .}}</div>`}},
+		Templates: []models.BundleTemplate{
+			{Name: "welcome", Status: models.TemplateStatusPublished, Content: models.TemplateContent{Subject: "Welcome", HTMLContent: "<p>Hello {{.name}}</p>"}},
+			{Name: "reset", Status: models.TemplateStatusDraft, Content: models.TemplateContent{Subject: "Reset", HTMLContent: "<p>{{.url}}</p>", Layout: "base"}},
+		},
+	}
+
+	result, err := s.ImportBundle(context.Background(), bundle, &models.ImportOptions{DryRun: true, Conflict: models.ImportConflictOverwrite})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	actions := make(map[string]string)
+	for _, item := range result.Items {
+		actions[item.Kind+":"+item.Name] = item.Action
+	}
+	want := map[string]string{
+		"layout:base":      models.ImportActionCreate,
+		"template:welcome": models.ImportActionOverwrite,
+		"template:reset":   models.ImportActionCreate,
+	}
+	if !reflect.DeepEqual(actions, want) {
+		t.Errorf("actions = %v, want %v", actions, want)
+	}
+	for _, item := range result.Items {
+		if item.Name == "welcome" && !strings.Contains(item.Changes["html_content"], "+<p>Hello {{.name}}</p>") {
+			t.Errorf("welcome changes = %v", item.Changes)
+		}
+	}
+}
+
+func TestImportBundleSkipsUnchangedAndConflicts(t *testing.T) {
+	s := bundleService()
+	bundle := &models.Bundle{
+		FormatVersion: models.BundleFormatVersion,
+		Templates: []models.BundleTemplate{
+			{Name: "welcome", Status: models.TemplateStatusPublished, Content: models.TemplateContent{Subject: "Welcome", HTMLContent: "<p>Hi {{.name}}</p>"}},
+		},
+	}
+
+	for conflict, want := range map[string]string{
+		models.ImportConflictSkip:       models.ImportActionUnchanged,
+		models.ImportConflictNewVersion: models.ImportActionUnchanged,
+	} {
+		result, err := s.ImportBundle(context.Background(), bundle, &models.ImportOptions{DryRun: true, Conflict: conflict})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := result.Items[0].Action; got != want {
+			t.Errorf("conflict %s: action = %s, want %s", conflict, got, want)
+		}
+	}
+
+	bundle.Templates[0].Content.HTMLContent = "<p>Changed</p>"
+	result, err := s.ImportBundle(context.Background(), bundle, &models.ImportOptions{DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := result.Items[0].Action; got != models.ImportActionSkip {
+		t.Errorf("default conflict: action = %s, want %s", got, models.ImportActionSkip)
+	}
+}
+
+func TestImportBundleRejectsInvalidItems(t *testing.T) {
+	s := bundleService()
+	bundle := &models.Bundle{
+		FormatVersion: models.BundleFormatVersion,
+		Templates: []models.BundleTemplate{
+			{Name: "ok", Content: models.TemplateContent{Subject: "Ok", HTMLContent: "<p>ok</p>"}},
+			{Name: "broken", Content: models.TemplateContent{Subject: "Broken", HTMLContent: "<p>{{if .x}}</p>"}},
+		},
+	}
+
+	// 검증 실패가 하나라도 있으면 아무것도 저장하지 않는다 (저장소가 없으므로 저장을 시도하면 패닉이 난다)
+	result, err := s.ImportBundle(context.Background(), bundle, &models.ImportOptions{})
+	var serviceErr *Error
+	if !errors.As(err, &serviceErr) || serviceErr.Code != ErrCodeInvalidBundle {
+		t.Fatalf("error = %v, want %s", err, ErrCodeInvalidBundle)
+	}
+	if result != nil {
+		t.Errorf("result = %+v, want nil", result)
+	}
+	if len(serviceErr.Details) != 1 || serviceErr.Details[0].Field != "template.broken" {
+		t.Errorf("details = %+v", serviceErr.Details)
+	}
+}
+
+func TestImportBundleRejectsDuplicateNames(t *testing.T) {
+	bundle := &models.Bundle{
+		FormatVersion: models.BundleFormatVersion,
+		Templates:     []models.BundleTemplate{{Name: "a"}, {Name: "a"}},
+	}
+	_, err := bundleService().ImportBundle(context.Background(), bundle, &models.ImportOptions{DryRun: true})
+	var serviceErr *Error
+	if !errors.As(err, &serviceErr) || serviceErr.Code != ErrCodeInvalidBundle {
+		t.Fatalf("error = %v, want %s", err, ErrCodeInvalidBundle)
+	}
+}
+
+func TestExportBundleIncludesUsedFragments(t *testing.T) {
+	s := bundleService()
+	reader := s.templates.(*memoryReader)
+	reader.templates["welcome"].TemplateContent.Layout = "base"
+	reader.fragments["layout:base"] = &models.Fragment{Kind: models.FragmentKindLayout, Name: "base", HTMLContent: `{{template "footer" .}}{{template "content" .}}`}
+	reader.fragments["partial:footer"] = &models.Fragment{Kind: models.FragmentKindPartial, Name: "footer", HTMLContent: `{{template "legal" .}}`}
+	reader.fragments["partial:legal"] = &models.Fragment{Kind: models.FragmentKindPartial, Name: "legal", HTMLContent: "(c)"}
+	reader.fragments["partial:unused"] = &models.Fragment{Kind: models.FragmentKindPartial, Name: "unused"}
+
+	bundle, err := s.ExportBundle(context.Background(), []string{"welcome"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bundle.Layouts) != 1 || bundle.Layouts[0].Name != "base" {
+		t.Errorf("layouts = %+v", bundle.Layouts)
+	}
+	var partials []string
+	for _, p := range bundle.Partials {
+		partials = append(partials, p.Name)
+	}
+	if want := []string{"footer", "legal"}; !reflect.DeepEqual(partials, want) {
+		t.Errorf("partials = %v, want %v", partials, want)
+	}
+
+	if _, err := s.ExportBundle(context.Background(), []string{"missing"}); err == nil {
+		t.Error("expected an error for a missing template")
+	}
+}
+
+func TestBundleTarRoundTrip(t *testing.T) {
+	bundle, err := bundleService().ExportBundle(context.Background(), []string{"welcome"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteBundleTar(&buf, bundle); err != nil {
+		t.Fatal(err)
+	}
+	read, err := ReadBundleTar(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(read.Templates) != 1 || read.Templates[0].Content.HTMLContent != "<p>Hi {{.name}}</p>" {
+		t.Errorf("templates = %+v", read.Templates)
+	}
+}
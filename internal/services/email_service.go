@@ -8,94 +8,304 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 
 	"github.com/kihyun1998/prisma-market/prisma-email-service/internal/models"
 	"github.com/kihyun1998/prisma-market/prisma-email-service/internal/repository/mongodb"
+	"github.com/kihyun1998/prisma-market/prisma-email-service/pkg/queue"
 	"github.com/kihyun1998/prisma-market/prisma-email-service/pkg/smtp"
 )
 
 type EmailService struct {
 	templateRepo *mongodb.TemplateRepository
 	smtpClient   *smtp.SMTPClient
+	publisher    queue.Publisher
+	queueTopic   string
 	httpClient   *http.Client
+
+	// 테스트 전송을 허용할 주소 또는 도메인(@example.com)
+	testAllowlist []string
+
+	// 전송 경로 템플릿 캐시 (nil이면 매번 조회/파싱)
+	cache *TemplateCache
+
+	// 마케팅 템플릿 수신 거부 링크/헤더 설정
+	unsubscribe UnsubscribeOptions
+
+	// 템플릿/레이아웃/부분 템플릿 조회 (기본은 templateRepo, 디렉터리 모드면 읽기 전용 리포지토리)
+	templates TemplateReader
+	readOnly  bool
 }
 
-func NewEmailService(templateRepo *mongodb.TemplateRepository, smtpClient *smtp.SMTPClient) *EmailService {
+func NewEmailService(templateRepo *mongodb.TemplateRepository, smtpClient *smtp.SMTPClient, publisher queue.Publisher, queueTopic string, testAllowlist []string, cache *TemplateCache, unsubscribe UnsubscribeOptions) *EmailService {
 	return &EmailService{
-		templateRepo: templateRepo,
-		smtpClient:   smtpClient,
-		httpClient:   &http.Client{Timeout: 10 * time.Second},
+		templateRepo:  templateRepo,
+		templates:     templateRepo,
+		smtpClient:    smtpClient,
+		publisher:     publisher,
+		queueTopic:    queueTopic,
+		httpClient:    &http.Client{Timeout: 10 * time.Second},
+		testAllowlist: testAllowlist,
+		cache:         cache,
+		unsubscribe:   unsubscribe,
 	}
 }
 
 // ProcessEmailRequest 이메일 요청 처리
 func (s *EmailService) ProcessEmailRequest(ctx context.Context, req *models.EmailRequest) error {
-	// 템플릿 ID를 ObjectID로 변환
-	templateID, err := primitive.ObjectIDFromHex(req.TemplateID)
+	// 템플릿 조회 (ID 또는 이름, 선택적으로 고정 버전)
+	template, err := s.resolveTemplate(ctx, req)
 	if err != nil {
-		return fmt.Errorf("invalid template ID: %v", err)
+		return err
 	}
 
-	// 템플릿 조회
-	template, err := s.templateRepo.GetTemplateByID(ctx, templateID)
+	// 변수 유효성 검사 및 기본값 적용
+	variables, err := prepareVariables(&template.TemplateContent, req.Variables)
 	if err != nil {
-		return fmt.Errorf("failed to get template: %v", err)
-	}
-	if template == nil {
-		return errors.New("template not found")
+		return err
 	}
 
-	// 변수 유효성 검사
-	if err := s.validateTemplateVariables(template, req.Variables); err != nil {
-		return err
+	// 수신자 언어에 맞는 본문 선택
+	content := localize(&template.TemplateContent, req.Locale)
+
+	// 마케팅 템플릿은 수신 거부한 주소를 제외하고, 수신 거부 링크가 수신자마다 다르므로 한 명씩 보낸다
+	// 큐 메시지는 수신자별 전송을 기록해 중간에 실패한 뒤 재시도해도 이미 받은 수신자에게 다시 보내지 않는다.
+	marketing := template.Category == models.TemplateCategoryMarketing
+	md, _ := queue.MetadataFromContext(ctx)
+	batches := [][]string{req.To}
+	var suppressed []string
+	if marketing {
+		var allowed []string
+		allowed, suppressed, err = s.marketingRecipients(ctx, req.To)
+		if err != nil {
+			return err
+		}
+
+		var delivered map[string]bool
+		if md.MessageID != "" && md.RetryCount > 0 {
+			if delivered, err = s.templateRepo.DeliveredRecipients(ctx, md.MessageID); err != nil {
+				return fmt.Errorf("failed to check delivered recipients: %v", err)
+			}
+		}
+
+		batches = batches[:0]
+		for _, to := range allowed {
+			if !delivered[strings.ToLower(recipientAddress(to))] {
+				batches = append(batches, []string{to})
+			}
+		}
 	}
 
-	// 이메일 전송
-	err = s.smtpClient.SendEmail(
-		req.To,
-		template.Subject,
-		template.HTMLContent,
-		template.TextContent,
-		req.Variables,
-	)
-	if err != nil {
-		return fmt.Errorf("failed to send email: %v", err)
+	for i, to := range batches {
+		// 사용한 템플릿 버전을 헤더에 기록
+		headers := messageHeaders(template, content.Locale)
+		messageVariables := variables
+		if marketing {
+			if messageVariables, err = s.withUnsubscribe(to[0], variables, headers); err != nil {
+				return err
+			}
+		}
+
+		// 레이아웃/부분 템플릿 결합 후 렌더링 (파싱된 템플릿은 버전별로 캐시)
+		rendered, err := s.renderCompiled(ctx, template, content, messageVariables)
+		if err != nil {
+			return err
+		}
+		if i == 0 {
+			for _, warning := range rendered.Warnings {
+				log.Printf("Template %s v%d: %s", template.Name, template.Version, warning)
+			}
+		}
+
+		// 이메일 전송
+		msg := s.smtpClient.NewMessage(to, rendered.Subject, rendered.HTML, rendered.Text, headers)
+		if err := s.smtpClient.SendEmail(ctx, msg); err != nil {
+			return fmt.Errorf("failed to send email: %v", err)
+		}
+		if marketing && md.MessageID != "" {
+			if err := s.templateRepo.RecordDelivery(ctx, md.MessageID, recipientAddress(to[0])); err != nil {
+				log.Printf("Failed to record delivery of message %s: %v", md.MessageID, err)
+			}
+		}
 	}
 
 	// 콜백 처리 (있는 경우)
 	if req.CallbackURL != "" {
-		go s.handleCallback(req.CallbackURL, &models.EmailResponse{
-			MessageID: primitive.NewObjectID().Hex(),
-			Status:    "delivered",
-			SentAt:    time.Now(),
+		status := "delivered"
+		if marketing && len(suppressed) == len(req.To) {
+			status = "suppressed"
+		}
+
+		go s.handleCallback(req.CallbackURL, md, &models.EmailResponse{
+			MessageID:       primitive.NewObjectID().Hex(),
+			Status:          status,
+			TemplateID:      template.ID.Hex(),
+			TemplateVersion: template.Version,
+			Locale:          content.Locale,
+			Suppressed:      suppressed,
+			SentAt:          time.Now(),
 		})
 	}
 
 	return nil
 }
 
-// 템플릿 변수 유효성 검사
-func (s *EmailService) validateTemplateVariables(template *models.Template, variables map[string]interface{}) error {
-	for _, required := range template.Variables {
-		if _, exists := variables[required]; !exists {
-			return fmt.Errorf("missing required variable: %s", required)
+// EnqueueEmailRequest 이메일 요청을 큐에 발행 (브로커 확인 후 반환)
+func (s *EmailService) EnqueueEmailRequest(ctx context.Context, req *models.EmailRequest) (string, error) {
+	envelope, err := queue.NewEmailRequestEnvelope(req, "email-service-http")
+	if err != nil {
+		return "", fmt.Errorf("failed to build message envelope: %v", err)
+	}
+
+	body, err := json.Marshal(envelope)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal message envelope: %v", err)
+	}
+
+	// 소비자와 같은 규칙으로 검증해 처리할 수 없는 메시지는 발행하지 않는다
+	if _, _, err := queue.DecodeEmailRequest(body); err != nil {
+		return "", err
+	}
+
+	msg := queue.Message{
+		ID:          envelope.ID,
+		ContentType: "application/json",
+		Headers:     map[string]string{},
+		Body:        body,
+	}
+	if md, ok := queue.MetadataFromContext(ctx); ok {
+		msg.CorrelationID = md.CorrelationID
+		if md.TraceParent != "" {
+			msg.Headers["traceparent"] = md.TraceParent
+		}
+		if md.TraceState != "" {
+			msg.Headers["tracestate"] = md.TraceState
 		}
 	}
-	return nil
+
+	if err := s.publisher.Publish(ctx, s.queueTopic, msg); err != nil {
+		return "", fmt.Errorf("failed to enqueue email request: %v", err)
+	}
+
+	return envelope.ID, nil
+}
+
+// resolveTemplate 요청이 참조하는 게시된 템플릿 조회
+// TemplateVersion이 지정되면 해당 버전의 이력 본문을 사용한다.
+func (s *EmailService) resolveTemplate(ctx context.Context, req *models.EmailRequest) (*models.Template, error) {
+	var template *models.Template
+	switch {
+	case req.TemplateID != "":
+		templateID, err := primitive.ObjectIDFromHex(req.TemplateID)
+		if err != nil {
+			return nil, newError(ErrCodeInvalidTemplateID, "invalid template ID: %v", err)
+		}
+
+		template, err = s.cachedTemplateByID(ctx, templateID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get template: %v", err)
+		}
+		if template == nil {
+			return nil, newError(ErrCodeTemplateIDNotFound, "template not found: id %s", req.TemplateID)
+		}
+		if req.TemplateName != "" && req.TemplateName != template.Name {
+			return nil, newError(ErrCodeInvalidRequest, "template_id %s does not match template_name %q", req.TemplateID, req.TemplateName)
+		}
+
+	case req.TemplateName != "":
+		var err error
+		template, err = s.cachedTemplateByName(ctx, req.TemplateName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get template: %v", err)
+		}
+		if template == nil {
+			return nil, newError(ErrCodeTemplateNameNotFound, "template not found: name %q", req.TemplateName)
+		}
+
+	default:
+		return nil, newError(ErrCodeTemplateRefRequired, "template_id or template_name is required")
+	}
+
+	if req.TemplateVersion > 0 {
+		return s.pinnedVersion(ctx, template, req.TemplateVersion)
+	}
+
+	if !template.IsPublished() {
+		return nil, newError(ErrCodeTemplateNotPublished, "template %q has not been published", template.Name)
+	}
+	return template, nil
+}
+
+// pinnedVersion 이력에 저장된 특정 버전의 본문으로 템플릿 구성
+func (s *EmailService) pinnedVersion(ctx context.Context, template *models.Template, version int) (*models.Template, error) {
+	if version == template.Version && template.IsPublished() {
+		return template, nil
+	}
+
+	pinned, err := s.cachedDocument(ctx, pinnedVersionKey(template.ID, version), func() (*models.Template, error) {
+		v, err := s.templates.GetTemplateVersion(ctx, template.ID, version)
+		if err != nil || v == nil {
+			return nil, err
+		}
+
+		pinned := *template
+		pinned.TemplateContent = v.TemplateContent
+		pinned.Version = v.Version
+		pinned.Draft = nil
+		return &pinned, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get template version: %v", err)
+	}
+	if pinned == nil {
+		return nil, newError(ErrCodeTemplateVersionNotFound, "template %q has no version %d", template.Name, version)
+	}
+	return pinned, nil
+}
+
+// messageHeaders 전송에 사용한 템플릿 정보를 담은 추가 헤더
+func messageHeaders(template *models.Template, locale string) map[string]string {
+	headers := map[string]string{
+		"X-Template-ID":      template.ID.Hex(),
+		"X-Template-Version": strconv.Itoa(template.Version),
+	}
+	if locale != "" {
+		headers["Content-Language"] = locale
+	}
+	return headers
 }
 
 // 콜백 처리
-func (s *EmailService) handleCallback(callbackURL string, response *models.EmailResponse) {
+func (s *EmailService) handleCallback(callbackURL string, md queue.Metadata, response *models.EmailResponse) {
 	body, err := json.Marshal(response)
 	if err != nil {
 		log.Printf("Failed to marshal callback response: %v", err)
 		return
 	}
 
-	resp, err := s.httpClient.Post(callbackURL, "application/json", bytes.NewBuffer(body))
+	req, err := http.NewRequest(http.MethodPost, callbackURL, bytes.NewBuffer(body))
+	if err != nil {
+		log.Printf("Failed to create callback request: %v", err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	// 요청 추적 정보 전달
+	if md.CorrelationID != "" {
+		req.Header.Set("X-Correlation-ID", md.CorrelationID)
+	}
+	if md.TraceParent != "" {
+		req.Header.Set("traceparent", md.TraceParent)
+	}
+	if md.TraceState != "" {
+		req.Header.Set("tracestate", md.TraceState)
+	}
+
+	resp, err := s.httpClient.Do(req)
 	if err != nil {
 		log.Printf("Failed to send callback: %v", err)
 		return
@@ -107,30 +317,20 @@ func (s *EmailService) handleCallback(callbackURL string, response *models.Email
 	}
 }
 
-// Template Management
-
-func (s *EmailService) CreateTemplate(ctx context.Context, template *models.Template) error {
-	return s.templateRepo.CreateTemplate(ctx, template)
-}
-
-func (s *EmailService) GetTemplate(ctx context.Context, id primitive.ObjectID) (*models.Template, error) {
-	return s.templateRepo.GetTemplateByID(ctx, id)
-}
-
-func (s *EmailService) UpdateTemplate(ctx context.Context, id primitive.ObjectID, template *models.Template) error {
-	return s.templateRepo.UpdateTemplate(ctx, id, template)
-}
-
-func (s *EmailService) DeleteTemplate(ctx context.Context, id primitive.ObjectID) error {
-	return s.templateRepo.DeleteTemplate(ctx, id)
-}
-
-func (s *EmailService) ListTemplates(ctx context.Context) ([]*models.Template, error) {
-	return s.templateRepo.ListTemplates(ctx)
-}
-
 // Queue Processing Implementation
-func (s *EmailService) ProcessMessage(msg *models.EmailRequest) error {
-	ctx := context.Background()
-	return s.ProcessEmailRequest(ctx, msg)
+func (s *EmailService) ProcessMessage(ctx context.Context, msg *models.EmailRequest) error {
+	err := s.ProcessEmailRequest(ctx, msg)
+	if err == nil {
+		return nil
+	}
+	if md, ok := queue.MetadataFromContext(ctx); ok && md.CorrelationID != "" {
+		err = fmt.Errorf("correlation_id=%s: %w", md.CorrelationID, err)
+	}
+
+	// 코드가 있는 서비스 에러(템플릿 없음, 변수 오류 등)는 재시도해도 같은 결과이므로 바로 데드레터로 보낸다
+	var serviceErr *Error
+	if errors.As(err, &serviceErr) {
+		return queue.Permanent(serviceErr.Code, err)
+	}
+	return err
 }
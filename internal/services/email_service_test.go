@@ -0,0 +1,32 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/kihyun1998/prisma-market/prisma-email-service/internal/models"
+	"github.com/kihyun1998/prisma-market/prisma-email-service/pkg/queue"
+)
+
+func TestProcessMessageMarksServiceErrorsPermanent(t *testing.T) {
+	s := &EmailService{templates: &memoryReader{}}
+	ctx := queue.ContextWithMetadata(context.Background(), queue.Metadata{CorrelationID: "c-1"})
+
+	err := s.ProcessMessage(ctx, &models.EmailRequest{TemplateName: "missing", To: []string{"a@example.com"}})
+	var permanent *queue.PermanentError
+	if !errors.As(err, &permanent) {
+		t.Fatalf("error = %v, want a permanent error", err)
+	}
+	if permanent.Code != ErrCodeTemplateNameNotFound {
+		t.Errorf("code = %s, want %s", permanent.Code, ErrCodeTemplateNameNotFound)
+	}
+	if !strings.Contains(err.Error(), "correlation_id=c-1") {
+		t.Errorf("error = %q, want the correlation ID", err)
+	}
+	var serviceErr *Error
+	if !errors.As(err, &serviceErr) {
+		t.Error("service error is not unwrappable")
+	}
+}
@@ -0,0 +1,43 @@
+package services
+
+import "fmt"
+
+// 서비스 에러 코드 (API 응답의 code 필드)
+const (
+	ErrCodeInvalidRequest           = "INVALID_REQUEST"
+	ErrCodeInvalidTemplateID        = "INVALID_TEMPLATE_ID"
+	ErrCodeTemplateRefRequired      = "TEMPLATE_REFERENCE_REQUIRED"
+	ErrCodeTemplateIDNotFound       = "TEMPLATE_ID_NOT_FOUND"
+	ErrCodeTemplateNameNotFound     = "TEMPLATE_NAME_NOT_FOUND"
+	ErrCodeTemplateVersionNotFound  = "TEMPLATE_VERSION_NOT_FOUND"
+	ErrCodeTemplateNotPublished     = "TEMPLATE_NOT_PUBLISHED"
+	ErrCodeInvalidVariables         = "INVALID_VARIABLES"
+	ErrCodeInvalidSchema            = "INVALID_SCHEMA"
+	ErrCodeInvalidTemplateSyntax    = "INVALID_TEMPLATE_SYNTAX"
+	ErrCodeRenderFailed             = "RENDER_FAILED"
+	ErrCodeSampleNotFound           = "SAMPLE_NOT_FOUND"
+	ErrCodeRecipientNotAllowed      = "RECIPIENT_NOT_ALLOWED"
+	ErrCodeLayoutNotFound           = "LAYOUT_NOT_FOUND"
+	ErrCodePartialNotFound          = "PARTIAL_NOT_FOUND"
+	ErrCodeInvalidFragment          = "INVALID_FRAGMENT"
+	ErrCodeIncludeCycle             = "TEMPLATE_INCLUDE_CYCLE"
+	ErrCodeInvalidUnsubscribeToken  = "INVALID_UNSUBSCRIBE_TOKEN"
+	ErrCodeUnsubscribeNotConfigured = "UNSUBSCRIBE_NOT_CONFIGURED"
+	ErrCodeInvalidBundle            = "INVALID_BUNDLE"
+	ErrCodeReadOnlySource           = "READ_ONLY_SOURCE"
+)
+
+// Error 코드가 있는 서비스 에러
+type Error struct {
+	Code    string
+	Message string
+	Details []FieldError // 필드별 검증 실패 (있는 경우)
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func newError(code, format string, args ...interface{}) *Error {
+	return &Error{Code: code, Message: fmt.Sprintf(format, args...)}
+}
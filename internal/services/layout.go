@@ -0,0 +1,320 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/kihyun1998/prisma-market/prisma-email-service/internal/models"
+	"github.com/kihyun1998/prisma-market/prisma-email-service/pkg/render"
+)
+
+// composeSource 본문에 레이아웃과 포함된 부분 템플릿(하위 포함까지)을 불러와 렌더링 소스 구성
+func (s *EmailService) composeSource(ctx context.Context, layoutName string, content *LocalizedTemplate) (*render.Source, error) {
+	src := &render.Source{
+		Subject:   content.Subject,
+		HTML:      content.HTMLContent,
+		Text:      content.TextContent,
+		Preheader: content.Preheader,
+		Partials:  make(map[string]render.Fragment),
+	}
+	switch content.Format {
+	case models.TemplateFormatMarkdown:
+		src.HTML = ""
+		src.Markdown = content.MarkdownContent
+	case models.TemplateFormatMJML:
+		src.HTML = content.CompiledHTML
+	}
+
+	pending, err := render.ReferencedTemplates(src.HTML, src.Text, src.Markdown)
+	if err != nil {
+		return nil, newError(ErrCodeRenderFailed, "%v", err)
+	}
+
+	if layoutName != "" {
+		layout, err := s.templates.GetFragmentByName(ctx, models.FragmentKindLayout, layoutName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get layout: %v", err)
+		}
+		if layout == nil {
+			return nil, newError(ErrCodeLayoutNotFound, "layout not found: %q", layoutName)
+		}
+		src.Layout = &render.Fragment{HTML: layout.HTMLContent, Text: layout.TextContent}
+
+		refs, err := render.ReferencedTemplates(layout.HTMLContent, layout.TextContent)
+		if err != nil {
+			return nil, newError(ErrCodeRenderFailed, "layout %q: %v", layoutName, err)
+		}
+		pending = append(pending, refs...)
+	}
+
+	// 이미 불러온 부분 템플릿은 다시 조회하지 않으므로 순환 참조가 있어도 끝난다 (순환은 렌더링 시 검출)
+	for len(pending) > 0 {
+		name := pending[0]
+		pending = pending[1:]
+		if _, ok := src.Partials[name]; ok || name == render.ContentTemplate {
+			continue
+		}
+
+		partial, err := s.templates.GetFragmentByName(ctx, models.FragmentKindPartial, name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get partial: %v", err)
+		}
+		if partial == nil {
+			return nil, newError(ErrCodePartialNotFound, "partial not found: %q", name)
+		}
+		src.Partials[name] = render.Fragment{HTML: partial.HTMLContent, Text: partial.TextContent}
+
+		refs, err := render.ReferencedTemplates(partial.HTMLContent, partial.TextContent)
+		if err != nil {
+			return nil, newError(ErrCodeRenderFailed, "partial %q: %v", name, err)
+		}
+		pending = append(pending, refs...)
+	}
+
+	return src, nil
+}
+
+// renderContent 레이아웃/부분 템플릿을 결합해 렌더링
+func (s *EmailService) renderContent(ctx context.Context, layoutName string, content *LocalizedTemplate, variables map[string]interface{}) (*render.Rendered, error) {
+	compiled, err := s.compileContent(ctx, layoutName, content)
+	if err != nil {
+		return nil, err
+	}
+	return executeCompiled(compiled, variables)
+}
+
+// compileContent 레이아웃/부분 템플릿을 결합해 파싱
+func (s *EmailService) compileContent(ctx context.Context, layoutName string, content *LocalizedTemplate) (*render.Template, error) {
+	src, err := s.composeSource(ctx, layoutName, content)
+	if err != nil {
+		return nil, err
+	}
+
+	compiled, err := render.Compile(src)
+	if err != nil {
+		var cycleErr *render.CycleError
+		if errors.As(err, &cycleErr) {
+			return nil, newError(ErrCodeIncludeCycle, "%v", err)
+		}
+		return nil, newError(ErrCodeRenderFailed, "%v", err)
+	}
+	return compiled, nil
+}
+
+func executeCompiled(compiled *render.Template, variables map[string]interface{}) (*render.Rendered, error) {
+	rendered, err := compiled.Execute(variables)
+	if err != nil {
+		return nil, newError(ErrCodeRenderFailed, "%v", err)
+	}
+	return rendered, nil
+}
+
+// includeWarnings 저장 시 존재하지 않는 레이아웃/부분 템플릿 참조 경고
+// 공유 조각은 템플릿보다 나중에 만들어질 수 있으므로 저장은 막지 않는다.
+func (s *EmailService) includeWarnings(ctx context.Context, layoutName string, sources ...string) []string {
+	var warnings []string
+	if layoutName != "" {
+		layout, err := s.templates.GetFragmentByName(ctx, models.FragmentKindLayout, layoutName)
+		if err == nil && layout == nil {
+			warnings = append(warnings, fmt.Sprintf("layout %q does not exist", layoutName))
+		}
+	}
+
+	names, err := render.ReferencedTemplates(sources...)
+	if err != nil {
+		return warnings
+	}
+	for _, name := range names {
+		if name == render.ContentTemplate {
+			continue
+		}
+		partial, err := s.templates.GetFragmentByName(ctx, models.FragmentKindPartial, name)
+		if err == nil && partial == nil {
+			warnings = append(warnings, fmt.Sprintf("partial %q does not exist", name))
+		}
+	}
+	return warnings
+}
+
+// Layout / Partial Management
+
+// CreateFragment 레이아웃 또는 부분 템플릿 생성
+func (s *EmailService) CreateFragment(ctx context.Context, fragment *models.Fragment) error {
+	if err := s.checkWritable(); err != nil {
+		return err
+	}
+	warnings, err := s.checkFragment(ctx, fragment)
+	if err != nil {
+		return err
+	}
+
+	if err := s.templateRepo.CreateFragment(ctx, fragment); err != nil {
+		return err
+	}
+
+	fragment.Warnings = warnings
+	return nil
+}
+
+func (s *EmailService) GetFragment(ctx context.Context, kind string, id primitive.ObjectID) (*models.Fragment, error) {
+	return s.templates.GetFragmentByID(ctx, kind, id)
+}
+
+// UpdateFragment 레이아웃 또는 부분 템플릿 수정 (사용하는 모든 템플릿에 즉시 반영)
+func (s *EmailService) UpdateFragment(ctx context.Context, id primitive.ObjectID, fragment *models.Fragment) error {
+	if err := s.checkWritable(); err != nil {
+		return err
+	}
+	current, err := s.templates.GetFragmentByID(ctx, fragment.Kind, id)
+	if err != nil {
+		return err
+	}
+	if current == nil {
+		return newError(fragmentNotFoundCode(fragment.Kind), "%s not found: id %s", fragment.Kind, id.Hex())
+	}
+	fragment.ID = id
+	fragment.Name = current.Name
+
+	warnings, err := s.checkFragment(ctx, fragment)
+	if err != nil {
+		return err
+	}
+
+	if err := s.templateRepo.UpdateFragment(ctx, id, fragment); err != nil {
+		return err
+	}
+	s.cache.InvalidateCompiled()
+
+	updated, err := s.templates.GetFragmentByID(ctx, fragment.Kind, id)
+	if err != nil {
+		return err
+	}
+	*fragment = *updated
+	fragment.Warnings = warnings
+	return nil
+}
+
+func (s *EmailService) DeleteFragment(ctx context.Context, kind string, id primitive.ObjectID) error {
+	if err := s.checkWritable(); err != nil {
+		return err
+	}
+	if err := s.templateRepo.DeleteFragment(ctx, kind, id); err != nil {
+		return err
+	}
+	s.cache.InvalidateCompiled()
+	return nil
+}
+
+func (s *EmailService) ListFragments(ctx context.Context, kind string) ([]*models.Fragment, error) {
+	return s.templates.ListFragments(ctx, kind)
+}
+
+// checkFragment 저장 전 문법 검사, 레이아웃의 본문 위치 확인, 부분 템플릿 순환 참조 검사
+func (s *EmailService) checkFragment(ctx context.Context, fragment *models.Fragment) ([]string, error) {
+	refs, err := checkFragmentContent(fragment)
+	if err != nil {
+		return nil, err
+	}
+
+	if fragment.Kind == models.FragmentKindPartial {
+		if err := s.checkPartialCycle(ctx, fragment.Name, refs); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.includeWarnings(ctx, "", fragment.HTMLContent, fragment.TextContent), nil
+}
+
+// checkFragmentContent 다른 조각과 무관한 검사 (문법, 레이아웃의 본문 위치, 예약된 이름) 후 참조하는 부분 템플릿 반환
+func checkFragmentContent(fragment *models.Fragment) ([]string, error) {
+	if fragment.Name == "" {
+		return nil, newError(ErrCodeInvalidFragment, "%s name is required", fragment.Kind)
+	}
+
+	if err := checkFragmentSyntax(fragment); err != nil {
+		return nil, err
+	}
+
+	refs, err := render.ReferencedTemplates(fragment.HTMLContent, fragment.TextContent)
+	if err != nil {
+		return nil, newError(ErrCodeInvalidTemplateSyntax, "invalid template syntax: %v", err)
+	}
+
+	switch fragment.Kind {
+	case models.FragmentKindLayout:
+		if !includesContent(fragment.HTMLContent) {
+			return nil, newError(ErrCodeInvalidFragment, "layout %q must include the message body with {{template %q .}}", fragment.Name, render.ContentTemplate)
+		}
+		if fragment.TextContent != "" && !includesContent(fragment.TextContent) {
+			return nil, newError(ErrCodeInvalidFragment, "layout %q text must include the message body with {{template %q .}}", fragment.Name, render.ContentTemplate)
+		}
+
+	case models.FragmentKindPartial:
+		if fragment.Name == render.ContentTemplate {
+			return nil, newError(ErrCodeInvalidFragment, "%q is reserved for the message body", render.ContentTemplate)
+		}
+	}
+
+	return refs, nil
+}
+
+// checkPartialCycle 저장할 부분 템플릿을 포함해 전체 부분 템플릿 사이에 순환 참조가 생기는지 검사
+func (s *EmailService) checkPartialCycle(ctx context.Context, name string, refs []string) error {
+	partials, err := s.templates.ListFragments(ctx, models.FragmentKindPartial)
+	if err != nil {
+		return err
+	}
+
+	graph := make(map[string][]string, len(partials)+1)
+	for _, p := range partials {
+		graph[p.Name], _ = render.ReferencedTemplates(p.HTMLContent, p.TextContent)
+	}
+	graph[name] = refs
+
+	if cycle := render.FindCycle(graph); cycle != nil {
+		return newError(ErrCodeIncludeCycle, "%v", &render.CycleError{Path: cycle})
+	}
+	return nil
+}
+
+func checkFragmentSyntax(fragment *models.Fragment) error {
+	for _, s := range []struct {
+		field string
+		err   error
+	}{
+		{"html_content", render.CheckHTML(fragment.HTMLContent)},
+		{"text_content", render.CheckText(fragment.TextContent)},
+	} {
+		var syntaxErr *render.SyntaxError
+		if errors.As(s.err, &syntaxErr) {
+			return &Error{
+				Code:    ErrCodeInvalidTemplateSyntax,
+				Message: fmt.Sprintf("invalid template syntax in %s at line %d, column %d: %s", s.field, syntaxErr.Line, syntaxErr.Column, syntaxErr.Message),
+				Details: []FieldError{{Field: s.field, Message: syntaxErr.Message, Line: syntaxErr.Line, Column: syntaxErr.Column}},
+			}
+		} else if s.err != nil {
+			return newError(ErrCodeInvalidTemplateSyntax, "invalid template syntax in %s: %v", s.field, s.err)
+		}
+	}
+	return nil
+}
+
+func includesContent(src string) bool {
+	refs, _ := render.ReferencedTemplates(src)
+	for _, name := range refs {
+		if name == render.ContentTemplate {
+			return true
+		}
+	}
+	return false
+}
+
+func fragmentNotFoundCode(kind string) string {
+	if kind == models.FragmentKindLayout {
+		return ErrCodeLayoutNotFound
+	}
+	return ErrCodePartialNotFound
+}
@@ -0,0 +1,133 @@
+package services
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/kihyun1998/prisma-market/prisma-email-service/internal/models"
+)
+
+// LocalizedTemplate 언어 선택이 끝난 템플릿 본문
+type LocalizedTemplate struct {
+	Locale      string // 선택된 언어 (기본 본문이면 빈 문자열)
+	Subject     string
+	HTMLContent string
+	TextContent string
+	Preheader   string
+
+	Format          string
+	MarkdownContent string
+	CompiledHTML    string
+}
+
+// localize 요청 언어에 가장 잘 맞는 본문 선택
+// 예) ko-KR → ko → 같은 언어의 다른 지역(ko-*) → DefaultLocale → 기본 본문. 선택된 언어에 없는 필드는 기본 본문으로 채운다.
+func localize(content *models.TemplateContent, locale string) *LocalizedTemplate {
+	result := &LocalizedTemplate{
+		Subject:     content.Subject,
+		HTMLContent: content.HTMLContent,
+		TextContent: content.TextContent,
+		Preheader:   content.Preheader,
+
+		Format:          content.Format,
+		MarkdownContent: content.MarkdownContent,
+		CompiledHTML:    content.CompiledHTML,
+	}
+
+	key, variant, ok := matchLocale(content, locale)
+	if !ok {
+		return result
+	}
+
+	result.Locale = key
+	if variant.Subject != "" {
+		result.Subject = variant.Subject
+	}
+	if variant.HTMLContent != "" {
+		result.HTMLContent = variant.HTMLContent
+	}
+	if variant.TextContent != "" {
+		result.TextContent = variant.TextContent
+	}
+	if variant.Preheader != "" {
+		result.Preheader = variant.Preheader
+	}
+	if variant.MarkdownContent != "" {
+		result.MarkdownContent = variant.MarkdownContent
+	}
+	if variant.CompiledHTML != "" {
+		result.CompiledHTML = variant.CompiledHTML
+	}
+	return result
+}
+
+// matchLocale 대체 순서를 따라 등록된 언어 본문 탐색
+func matchLocale(content *models.TemplateContent, locale string) (string, models.LocalizedContent, bool) {
+	if len(content.Locales) == 0 {
+		return "", models.LocalizedContent{}, false
+	}
+
+	// 대소문자와 구분자(_, -)를 무시하고 비교
+	byTag := make(map[string]string, len(content.Locales))
+	for key := range content.Locales {
+		byTag[normalizeLocale(key)] = key
+	}
+
+	for _, tag := range localeFallbacks(locale) {
+		if key, ok := byTag[tag]; ok {
+			return key, content.Locales[key], true
+		}
+	}
+
+	// 같은 언어의 지역 변형 (e.g., ja 요청에 ja-JP 본문)
+	if primary := primaryLanguage(locale); primary != "" {
+		tags := make([]string, 0, len(byTag))
+		for tag := range byTag {
+			tags = append(tags, tag)
+		}
+		sort.Strings(tags)
+		for _, tag := range tags {
+			if primaryLanguage(tag) == primary {
+				key := byTag[tag]
+				return key, content.Locales[key], true
+			}
+		}
+	}
+
+	for _, tag := range localeFallbacks(content.DefaultLocale) {
+		if key, ok := byTag[tag]; ok {
+			return key, content.Locales[key], true
+		}
+	}
+	return "", models.LocalizedContent{}, false
+}
+
+func primaryLanguage(locale string) string {
+	tag := normalizeLocale(locale)
+	if i := strings.Index(tag, "-"); i >= 0 {
+		return tag[:i]
+	}
+	return tag
+}
+
+// localeFallbacks 언어 태그를 점점 짧게 줄인 후보 목록 (e.g., zh-hant-tw → zh-hant → zh)
+func localeFallbacks(locale string) []string {
+	tag := normalizeLocale(locale)
+	if tag == "" {
+		return nil
+	}
+
+	var fallbacks []string
+	for {
+		fallbacks = append(fallbacks, tag)
+		i := strings.LastIndex(tag, "-")
+		if i < 0 {
+			return fallbacks
+		}
+		tag = tag[:i]
+	}
+}
+
+func normalizeLocale(locale string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"))
+}
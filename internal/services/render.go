@@ -0,0 +1,132 @@
+package services
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/kihyun1998/prisma-market/prisma-email-service/internal/models"
+)
+
+// previewRecipient Raw 메시지 요청에 수신자가 없을 때 사용하는 주소
+const previewRecipient = "preview@example.invalid"
+
+// RenderTemplate 템플릿을 전송하지 않고 렌더링
+// 기본은 초안 본문을 사용하며, Published가 true면 전송과 같은 게시 본문을 사용한다.
+func (s *EmailService) RenderTemplate(ctx context.Context, id primitive.ObjectID, req *models.RenderRequest) (*models.RenderResponse, error) {
+	template, err := s.getTemplate(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.render(ctx, template, req)
+}
+
+// PreviewTemplate 저장된 예시 변수로 템플릿 렌더링 (브라우저 미리보기용)
+// sample이 비어 있으면 기본 예시 변수(SampleData)를 사용한다.
+func (s *EmailService) PreviewTemplate(ctx context.Context, id primitive.ObjectID, sample, locale string, published bool) (*models.RenderResponse, error) {
+	template, err := s.getTemplate(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &models.RenderRequest{
+		Sample:    sample,
+		Locale:    locale,
+		Published: published,
+	}
+	if sample == "" {
+		req.Variables = template.SampleData
+	}
+	return s.render(ctx, template, req)
+}
+
+func (s *EmailService) getTemplate(ctx context.Context, id primitive.ObjectID) (*models.Template, error) {
+	template, err := s.templates.GetTemplateByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if template == nil {
+		return nil, newError(ErrCodeTemplateIDNotFound, "template not found: id %s", id.Hex())
+	}
+	return template, nil
+}
+
+func (s *EmailService) render(ctx context.Context, template *models.Template, req *models.RenderRequest) (*models.RenderResponse, error) {
+	source := template.DraftContent()
+	if req.Published {
+		if !template.IsPublished() {
+			return nil, newError(ErrCodeTemplateNotPublished, "template %q has not been published", template.Name)
+		}
+		source = &template.TemplateContent
+	}
+
+	variables, err := sampleVariables(template, req.Sample, req.Variables)
+	if err != nil {
+		return nil, err
+	}
+
+	variables, err = prepareVariables(source, variables)
+	if err != nil {
+		return nil, err
+	}
+
+	// 마케팅 템플릿은 첫 수신자(없으면 미리보기 주소)의 수신 거부 링크로 렌더링
+	to := req.To
+	if len(to) == 0 {
+		to = []string{previewRecipient}
+	}
+	headers := messageHeaders(template, "")
+	if template.Category == models.TemplateCategoryMarketing && s.unsubscribe.configured() {
+		if variables, err = s.withUnsubscribe(to[0], variables, headers); err != nil {
+			return nil, err
+		}
+	}
+
+	content := localize(source, req.Locale)
+	rendered, err := s.renderContent(ctx, source.Layout, content, variables)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &models.RenderResponse{
+		TemplateID: template.ID.Hex(),
+		Version:    template.Version,
+		Draft:      !req.Published,
+		Locale:     content.Locale,
+		Subject:    rendered.Subject,
+		HTML:       rendered.HTML,
+		Text:       rendered.Text,
+		Warnings:   rendered.Warnings,
+	}
+
+	if req.IncludeRaw {
+		if content.Locale != "" {
+			headers["Content-Language"] = content.Locale
+		}
+		msg := s.smtpClient.NewMessage(to, rendered.Subject, rendered.HTML, rendered.Text, headers)
+		response.Raw = string(msg.Bytes())
+	}
+
+	return response, nil
+}
+
+// sampleVariables 이름 붙은 예시 세트에 요청 변수를 덮어쓴 변수 맵
+func sampleVariables(template *models.Template, sample string, variables map[string]interface{}) (map[string]interface{}, error) {
+	if sample == "" {
+		return variables, nil
+	}
+
+	base, ok := template.Samples[sample]
+	if !ok {
+		return nil, newError(ErrCodeSampleNotFound, "template %q has no sample %q", template.Name, sample)
+	}
+
+	merged := make(map[string]interface{}, len(base)+len(variables))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range variables {
+		merged[k] = v
+	}
+	return merged, nil
+}
@@ -0,0 +1,306 @@
+package services
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/kihyun1998/prisma-market/prisma-email-service/internal/models"
+	"github.com/kihyun1998/prisma-market/prisma-email-service/internal/repository/mongodb"
+	"github.com/kihyun1998/prisma-market/prisma-email-service/pkg/render"
+)
+
+// TemplateCache 전송 경로에서 사용하는 템플릿 문서와 파싱된 템플릿 캐시
+// change stream이 연결되어 있으면 변경 이벤트로 무효화하고, 연결할 수 없으면 항목을 TTL 후 다시 조회한다.
+// nil이면 캐시를 사용하지 않는다.
+type TemplateCache struct {
+	ttl time.Duration
+
+	mu         sync.RWMutex
+	watching   bool
+	generation uint64 // 무효화할 때마다 증가 (조회 도중 무효화된 결과는 저장하지 않는다)
+	documents  map[string]cachedDocument
+	compiled   map[compiledKey]cachedCompiled
+
+	hits          atomic.Uint64
+	misses        atomic.Uint64
+	invalidations atomic.Uint64
+}
+
+type cachedDocument struct {
+	template *models.Template
+	loadedAt time.Time
+}
+
+// compiledKey 게시된 버전의 본문은 바뀌지 않으므로 ID, 버전, 언어로 구분한다
+type compiledKey struct {
+	id      primitive.ObjectID
+	version int
+	locale  string
+}
+
+type cachedCompiled struct {
+	template *render.Template
+	loadedAt time.Time
+}
+
+func NewTemplateCache(ttl time.Duration) *TemplateCache {
+	return &TemplateCache{
+		ttl:       ttl,
+		documents: make(map[string]cachedDocument),
+		compiled:  make(map[compiledKey]cachedCompiled),
+	}
+}
+
+// fresh TTL 방식일 때 만료되지 않은 항목인지 확인
+func (c *TemplateCache) fresh(loadedAt time.Time) bool {
+	return c.watching || c.ttl <= 0 || time.Since(loadedAt) < c.ttl
+}
+
+func (c *TemplateCache) document(key string) (*models.Template, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.RLock()
+	entry, ok := c.documents[key]
+	ok = ok && c.fresh(entry.loadedAt)
+	c.mu.RUnlock()
+
+	c.count(ok)
+	return entry.template, ok
+}
+
+func (c *TemplateCache) compiledTemplate(key compiledKey) (*render.Template, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.RLock()
+	entry, ok := c.compiled[key]
+	ok = ok && c.fresh(entry.loadedAt)
+	c.mu.RUnlock()
+
+	c.count(ok)
+	return entry.template, ok
+}
+
+func (c *TemplateCache) count(hit bool) {
+	if hit {
+		c.hits.Add(1)
+	} else {
+		c.misses.Add(1)
+	}
+}
+
+// currentGeneration 조회 시작 시점의 세대 (저장 시 비교)
+func (c *TemplateCache) currentGeneration() uint64 {
+	if c == nil {
+		return 0
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.generation
+}
+
+func (c *TemplateCache) storeDocument(key string, template *models.Template, generation uint64) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if generation == c.generation {
+		c.documents[key] = cachedDocument{template: template, loadedAt: time.Now()}
+	}
+}
+
+func (c *TemplateCache) storeCompiled(key compiledKey, template *render.Template, generation uint64) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if generation == c.generation {
+		c.compiled[key] = cachedCompiled{template: template, loadedAt: time.Now()}
+	}
+}
+
+// Invalidate 템플릿 하나의 문서(ID/이름/고정 버전)와 파싱된 템플릿 제거
+func (c *TemplateCache) Invalidate(id primitive.ObjectID) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation++
+	c.invalidations.Add(1)
+	for key, entry := range c.documents {
+		if entry.template.ID == id {
+			delete(c.documents, key)
+		}
+	}
+	for key := range c.compiled {
+		if key.id == id {
+			delete(c.compiled, key)
+		}
+	}
+}
+
+// InvalidateCompiled 파싱된 템플릿 전체 제거 (레이아웃/부분 템플릿은 여러 템플릿이 공유한다)
+func (c *TemplateCache) InvalidateCompiled() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation++
+	c.invalidations.Add(1)
+	c.compiled = make(map[compiledKey]cachedCompiled)
+}
+
+// Flush 모든 항목 제거
+func (c *TemplateCache) Flush() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation++
+	c.invalidations.Add(1)
+	c.documents = make(map[string]cachedDocument)
+	c.compiled = make(map[compiledKey]cachedCompiled)
+}
+
+func (c *TemplateCache) setWatching(watching bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.watching = watching
+}
+
+// Stats 캐시 적중률과 항목 수
+func (c *TemplateCache) Stats() *models.TemplateCacheStats {
+	if c == nil {
+		return &models.TemplateCacheStats{Mode: models.CacheModeDisabled}
+	}
+
+	c.mu.RLock()
+	stats := &models.TemplateCacheStats{
+		Mode:      models.CacheModeTTL,
+		TTL:       c.ttl.String(),
+		Documents: len(c.documents),
+		Compiled:  len(c.compiled),
+	}
+	if c.watching {
+		stats.Mode = models.CacheModeChangeStream
+		stats.TTL = ""
+	}
+	c.mu.RUnlock()
+
+	stats.Hits = c.hits.Load()
+	stats.Misses = c.misses.Load()
+	stats.Invalidations = c.invalidations.Load()
+	if total := stats.Hits + stats.Misses; total > 0 {
+		stats.HitRatio = float64(stats.Hits) / float64(total)
+	}
+	return stats
+}
+
+// WatchTemplateChanges change stream으로 캐시 무효화 (ctx가 끝날 때까지 실행)
+// change stream을 열 수 없거나 끊기면 캐시를 비우고 TTL 방식으로 전환한 뒤 TTL마다 다시 연결을 시도한다.
+func (s *EmailService) WatchTemplateChanges(ctx context.Context) {
+	c := s.cache
+	if c == nil {
+		return
+	}
+
+	retry := c.ttl
+	if retry <= 0 {
+		retry = time.Minute
+	}
+
+	for {
+		opened := false
+		err := s.templateRepo.WatchTemplateChanges(ctx, func() {
+			// 스트림이 열리기 전에 저장된 항목은 이벤트를 놓쳤을 수 있다
+			opened = true
+			c.Flush()
+			c.setWatching(true)
+			log.Println("Template cache: invalidating through change stream")
+		}, func(change mongodb.TemplateChange) {
+			if change.Fragment {
+				c.InvalidateCompiled()
+			} else {
+				c.Invalidate(change.ID)
+			}
+		})
+		if ctx.Err() != nil {
+			return
+		}
+
+		c.setWatching(false)
+		if opened {
+			c.Flush()
+		}
+		log.Printf("Template cache: change stream unavailable, entries expire after %s: %v", c.ttl, err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(retry):
+		}
+	}
+}
+
+// cachedTemplateByID 전송 경로용 ID 조회 (없는 템플릿은 캐시하지 않는다)
+func (s *EmailService) cachedTemplateByID(ctx context.Context, id primitive.ObjectID) (*models.Template, error) {
+	return s.cachedDocument(ctx, "id:"+id.Hex(), func() (*models.Template, error) {
+		return s.templates.GetTemplateByID(ctx, id)
+	})
+}
+
+// cachedTemplateByName 전송 경로용 이름 조회
+func (s *EmailService) cachedTemplateByName(ctx context.Context, name string) (*models.Template, error) {
+	return s.cachedDocument(ctx, "name:"+name, func() (*models.Template, error) {
+		return s.templates.GetTemplateByName(ctx, name)
+	})
+}
+
+func (s *EmailService) cachedDocument(ctx context.Context, key string, load func() (*models.Template, error)) (*models.Template, error) {
+	if template, ok := s.cache.document(key); ok {
+		return template, nil
+	}
+
+	generation := s.cache.currentGeneration()
+	template, err := load()
+	if err != nil || template == nil {
+		return template, err
+	}
+	s.cache.storeDocument(key, template, generation)
+	return template, nil
+}
+
+// renderCompiled 게시된 버전을 파싱된 템플릿 캐시로 렌더링
+func (s *EmailService) renderCompiled(ctx context.Context, template *models.Template, content *LocalizedTemplate, variables map[string]interface{}) (*render.Rendered, error) {
+	key := compiledKey{id: template.ID, version: template.Version, locale: content.Locale}
+	compiled, ok := s.cache.compiledTemplate(key)
+	if !ok {
+		generation := s.cache.currentGeneration()
+
+		var err error
+		compiled, err = s.compileContent(ctx, template.Layout, content)
+		if err != nil {
+			return nil, err
+		}
+		s.cache.storeCompiled(key, compiled, generation)
+	}
+
+	return executeCompiled(compiled, variables)
+}
+
+// pinnedVersionKey 고정 버전 문서 캐시 키
+func pinnedVersionKey(id primitive.ObjectID, version int) string {
+	return "version:" + id.Hex() + ":" + strconv.Itoa(version)
+}
@@ -0,0 +1,113 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kihyun1998/prisma-market/prisma-email-service/internal/models"
+)
+
+// countingReader 이름 조회 횟수를 세는 템플릿 원본
+type countingReader struct {
+	*memoryReader
+	loads int
+}
+
+func (r *countingReader) GetTemplateByName(ctx context.Context, name string) (*models.Template, error) {
+	r.loads++
+	return r.memoryReader.GetTemplateByName(ctx, name)
+}
+
+func cacheService(ttl time.Duration) (*EmailService, *countingReader) {
+	reader := &countingReader{memoryReader: bundleService().templates.(*memoryReader)}
+	s := &EmailService{templates: reader}
+	if ttl >= 0 {
+		s.cache = NewTemplateCache(ttl)
+	}
+	return s, reader
+}
+
+func TestTemplateCacheHitsAndInvalidation(t *testing.T) {
+	s, reader := cacheService(time.Hour)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if _, err := s.cachedTemplateByName(ctx, "welcome"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if reader.loads != 1 {
+		t.Errorf("loads = %d, want 1", reader.loads)
+	}
+
+	s.cache.Invalidate(reader.templates["welcome"].ID)
+	if _, err := s.cachedTemplateByName(ctx, "welcome"); err != nil {
+		t.Fatal(err)
+	}
+	if reader.loads != 2 {
+		t.Errorf("loads after invalidation = %d, want 2", reader.loads)
+	}
+
+	stats := s.cache.Stats()
+	if stats.Hits != 2 || stats.Misses != 2 || stats.Invalidations != 1 || stats.Mode != models.CacheModeTTL {
+		t.Errorf("stats = %+v", stats)
+	}
+}
+
+func TestTemplateCacheTTL(t *testing.T) {
+	s, reader := cacheService(20 * time.Millisecond)
+	ctx := context.Background()
+
+	s.cachedTemplateByName(ctx, "welcome")
+	time.Sleep(30 * time.Millisecond)
+	s.cachedTemplateByName(ctx, "welcome")
+	if reader.loads != 2 {
+		t.Errorf("loads = %d, want 2 after the entry expired", reader.loads)
+	}
+
+	// change stream으로 무효화하는 동안에는 만료되지 않는다
+	s.cache.Flush()
+	s.cache.setWatching(true)
+	s.cachedTemplateByName(ctx, "welcome")
+	time.Sleep(30 * time.Millisecond)
+	s.cachedTemplateByName(ctx, "welcome")
+	if reader.loads != 3 {
+		t.Errorf("loads = %d, want 3 while watching", reader.loads)
+	}
+}
+
+func TestTemplateCacheSkipsResultsLoadedDuringInvalidation(t *testing.T) {
+	s, _ := cacheService(time.Hour)
+	id := bundleService().templates.(*memoryReader).templates["welcome"].ID
+
+	// 조회 도중 무효화되면 조회 결과(이전 내용일 수 있다)를 저장하지 않는다
+	_, err := s.cachedDocument(context.Background(), "name:welcome", func() (*models.Template, error) {
+		s.cache.Flush()
+		return &models.Template{ID: id, Name: "welcome"}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := s.cache.document("name:welcome"); ok {
+		t.Error("stale result was cached")
+	}
+}
+
+func TestTemplateCacheDisabledAndMissing(t *testing.T) {
+	s, reader := cacheService(-1)
+	ctx := context.Background()
+	s.cachedTemplateByName(ctx, "welcome")
+	s.cachedTemplateByName(ctx, "welcome")
+	if reader.loads != 2 || s.cache.Stats().Mode != models.CacheModeDisabled {
+		t.Errorf("disabled cache: loads = %d, stats = %+v", reader.loads, s.cache.Stats())
+	}
+
+	// 없는 템플릿은 캐시하지 않아 생성 직후 바로 찾을 수 있다
+	s, reader = cacheService(time.Hour)
+	s.cachedTemplateByName(ctx, "missing")
+	s.cachedTemplateByName(ctx, "missing")
+	if reader.loads != 2 {
+		t.Errorf("missing template: loads = %d, want 2", reader.loads)
+	}
+}
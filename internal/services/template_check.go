@@ -0,0 +1,216 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/kihyun1998/prisma-market/prisma-email-service/internal/models"
+	"github.com/kihyun1998/prisma-market/prisma-email-service/pkg/mjml"
+	"github.com/kihyun1998/prisma-market/prisma-email-service/pkg/render"
+)
+
+// checkTemplateContent 저장 전 제목/HTML/텍스트 본문 문법 검사와 참조 변수 추출
+// 문법 오류는 에러로, 선언과 참조 변수의 불일치는 경고로 반환한다.
+func checkTemplateContent(content *models.TemplateContent) ([]string, error) {
+	switch content.Format {
+	case "", models.TemplateFormatHTML:
+	case models.TemplateFormatMarkdown:
+		if strings.TrimSpace(content.MarkdownContent) == "" {
+			return nil, newError(ErrCodeInvalidRequest, "markdown_content is required when format is %q", models.TemplateFormatMarkdown)
+		}
+	case models.TemplateFormatMJML:
+		if strings.TrimSpace(content.MJMLContent) == "" {
+			return nil, newError(ErrCodeInvalidRequest, "mjml_content is required when format is %q", models.TemplateFormatMJML)
+		}
+	default:
+		return nil, newError(ErrCodeInvalidRequest, "unsupported format %q (expected %q, %q or %q)", content.Format, models.TemplateFormatHTML, models.TemplateFormatMarkdown, models.TemplateFormatMJML)
+	}
+
+	// MJML 본문은 저장 시 컴파일해 결과를 CompiledHTML에 캐시한다
+	details := compileMJML(content)
+
+	type source struct {
+		field string
+		src   string
+		html  bool
+	}
+	sources := []source{
+		{"subject", content.Subject, false},
+		{"html_content", content.HTMLContent, true},
+		{"text_content", content.TextContent, false},
+		{"preheader", content.Preheader, false},
+		{"markdown_content", content.MarkdownContent, false},
+		{"compiled_html", content.CompiledHTML, true},
+	}
+	for _, locale := range sortedLocales(content) {
+		variant := content.Locales[locale]
+		prefix := "locales." + locale + "."
+		sources = append(sources,
+			source{prefix + "subject", variant.Subject, false},
+			source{prefix + "html_content", variant.HTMLContent, true},
+			source{prefix + "text_content", variant.TextContent, false},
+			source{prefix + "preheader", variant.Preheader, false},
+			source{prefix + "markdown_content", variant.MarkdownContent, false},
+			source{prefix + "compiled_html", variant.CompiledHTML, true},
+		)
+	}
+
+	var texts []string
+	for _, s := range sources {
+		if s.src == "" {
+			continue
+		}
+
+		var err error
+		if s.html {
+			err = render.CheckHTML(s.src)
+		} else {
+			err = render.CheckText(s.src)
+		}
+
+		var syntaxErr *render.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			details = append(details, FieldError{
+				Field:   s.field,
+				Message: syntaxErr.Message,
+				Line:    syntaxErr.Line,
+				Column:  syntaxErr.Column,
+			})
+			continue
+		} else if err != nil {
+			details = append(details, FieldError{Field: s.field, Message: err.Error()})
+			continue
+		}
+		texts = append(texts, s.src)
+	}
+
+	if len(details) > 0 {
+		first := details[0]
+		return nil, &Error{
+			Code:    ErrCodeInvalidTemplateSyntax,
+			Message: fmt.Sprintf("invalid template syntax in %s at line %d, column %d: %s", first.Field, first.Line, first.Column, first.Message),
+			Details: details,
+		}
+	}
+
+	referenced, err := render.ReferencedVariables(texts...)
+	if err != nil {
+		return nil, newError(ErrCodeInvalidTemplateSyntax, "invalid template syntax: %v", err)
+	}
+	content.ReferencedVariables = referenced
+
+	return variableWarnings(content, referenced), nil
+}
+
+// checkTemplateMetadata 분류 검사와 태그 정리 (앞뒤 공백 제거, 소문자, 중복 제거)
+// 마케팅 템플릿은 수신 거부 링크 없이 보낼 수 없으므로 수신 거부 설정이 없으면 저장하지 않는다.
+func (s *EmailService) checkTemplateMetadata(template *models.Template) error {
+	switch template.Category {
+	case "", models.TemplateCategoryTransactional:
+	case models.TemplateCategoryMarketing:
+		if !s.unsubscribe.configured() {
+			return newError(ErrCodeUnsubscribeNotConfigured, "marketing templates require unsubscribe handling (UNSUBSCRIBE_BASE_URL, UNSUBSCRIBE_SECRET)")
+		}
+	default:
+		return newError(ErrCodeInvalidRequest, "unsupported category %q (expected %q or %q)", template.Category, models.TemplateCategoryTransactional, models.TemplateCategoryMarketing)
+	}
+
+	seen := make(map[string]bool, len(template.Tags))
+	tags := make([]string, 0, len(template.Tags))
+	for _, tag := range template.Tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+	template.Tags = tags
+	template.Team = strings.TrimSpace(template.Team)
+	return nil
+}
+
+// compileMJML 기본 본문과 로케일별 MJML 본문을 컴파일해 CompiledHTML 갱신
+// mjml 형식이 아니면 이전 컴파일 결과를 지운다. 컴파일 오류는 mjml_content 필드 오류로 반환한다.
+func compileMJML(content *models.TemplateContent) []FieldError {
+	var details []FieldError
+	compile := func(field, src string) string {
+		if content.Format != models.TemplateFormatMJML || src == "" {
+			return ""
+		}
+		compiled, err := mjml.Compile(src)
+		if err != nil {
+			detail := FieldError{Field: field, Message: err.Error()}
+			var mjmlErr *mjml.Error
+			if errors.As(err, &mjmlErr) {
+				detail.Message = mjmlErr.Message
+				detail.Line = mjmlErr.Line
+				detail.Column = mjmlErr.Column
+			}
+			details = append(details, detail)
+			return ""
+		}
+		return compiled
+	}
+
+	content.CompiledHTML = compile("mjml_content", content.MJMLContent)
+	for _, locale := range sortedLocales(content) {
+		variant := content.Locales[locale]
+		variant.CompiledHTML = compile("locales."+locale+".mjml_content", variant.MJMLContent)
+		content.Locales[locale] = variant
+	}
+	return details
+}
+
+// variableWarnings 선언된 변수(스키마 또는 Variables)와 본문에서 참조하는 변수 비교
+func variableWarnings(content *models.TemplateContent, referenced []string) []string {
+	declared := make(map[string]bool)
+	if content.Schema != nil {
+		for name := range content.Schema.Properties {
+			declared[name] = true
+		}
+	} else {
+		for _, name := range content.Variables {
+			declared[name] = true
+		}
+	}
+
+	// 아무것도 선언하지 않은 템플릿은 비교하지 않는다
+	if len(declared) == 0 {
+		return nil
+	}
+
+	used := make(map[string]bool, len(referenced))
+	var warnings []string
+	for _, name := range referenced {
+		used[name] = true
+		// 수신 거부 링크는 전송 시 서비스가 채운다
+		if !declared[name] && name != UnsubscribeVariable {
+			warnings = append(warnings, fmt.Sprintf("variable %q is used in the template but not declared", name))
+		}
+	}
+
+	names := make([]string, 0, len(declared))
+	for name := range declared {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !used[name] {
+			warnings = append(warnings, fmt.Sprintf("declared variable %q is not used in the template", name))
+		}
+	}
+
+	return warnings
+}
+
+func sortedLocales(content *models.TemplateContent) []string {
+	locales := make([]string, 0, len(content.Locales))
+	for locale := range content.Locales {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
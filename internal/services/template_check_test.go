@@ -0,0 +1,51 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/kihyun1998/prisma-market/prisma-email-service/internal/models"
+)
+
+func TestCheckTemplateMetadata(t *testing.T) {
+	configured := &EmailService{unsubscribe: UnsubscribeOptions{BaseURL: "https://x.example/unsubscribe", Secret: "s"}}
+	unconfigured := &EmailService{}
+
+	tests := []struct {
+		name    string
+		service *EmailService
+		tmpl    models.Template
+		code    string
+	}{
+		{name: "transactional", service: unconfigured, tmpl: models.Template{Category: models.TemplateCategoryTransactional}},
+		{name: "marketing", service: configured, tmpl: models.Template{Category: models.TemplateCategoryMarketing}},
+		{name: "marketing without unsubscribe", service: unconfigured, tmpl: models.Template{Category: models.TemplateCategoryMarketing}, code: ErrCodeUnsubscribeNotConfigured},
+		{name: "unknown category", service: configured, tmpl: models.Template{Category: "promo"}, code: ErrCodeInvalidRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.service.checkTemplateMetadata(&tt.tmpl)
+			var serviceErr *Error
+			switch {
+			case tt.code == "" && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case tt.code != "" && (!errors.As(err, &serviceErr) || serviceErr.Code != tt.code):
+				t.Fatalf("error = %v, want code %s", err, tt.code)
+			}
+		})
+	}
+}
+
+func TestCheckTemplateMetadataNormalizesTags(t *testing.T) {
+	tmpl := &models.Template{Tags: []string{" Onboarding", "onboarding", "", "Billing"}, Team: " auth "}
+	if err := (&EmailService{}).checkTemplateMetadata(tmpl); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"onboarding", "billing"}; !reflect.DeepEqual(tmpl.Tags, want) {
+		t.Errorf("Tags = %v, want %v", tmpl.Tags, want)
+	}
+	if tmpl.Team != "auth" {
+		t.Errorf("Team = %q", tmpl.Team)
+	}
+}
@@ -0,0 +1,331 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/kihyun1998/prisma-market/prisma-email-service/internal/models"
+	"github.com/kihyun1998/prisma-market/prisma-email-service/internal/repository/filesystem"
+	"github.com/kihyun1998/prisma-market/prisma-email-service/internal/repository/mongodb"
+	"github.com/kihyun1998/prisma-market/prisma-email-service/pkg/textdiff"
+)
+
+type actorKey struct{}
+
+// ContextWithActor 요청한 사용자를 컨텍스트에 저장 (감사 로그에 기록)
+func ContextWithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+func actorFromContext(ctx context.Context) string {
+	actor, _ := ctx.Value(actorKey{}).(string)
+	return actor
+}
+
+// audit 감사 로그 기록 (실패해도 요청은 성공으로 처리)
+func (s *EmailService) audit(ctx context.Context, templateID primitive.ObjectID, action string, version int, comment string) {
+	err := s.templateRepo.InsertAuditEntry(ctx, &models.TemplateAuditEntry{
+		TemplateID: templateID,
+		Action:     action,
+		Version:    version,
+		Actor:      actorFromContext(ctx),
+		Comment:    comment,
+	})
+	if err != nil {
+		log.Printf("Failed to record audit entry (%s %s): %v", action, templateID.Hex(), err)
+	}
+}
+
+// Template Management
+
+// CreateTemplate 새 템플릿은 초안으로 생성되며 게시 전까지 전송에 사용되지 않는다
+func (s *EmailService) CreateTemplate(ctx context.Context, template *models.Template) error {
+	if err := s.checkWritable(); err != nil {
+		return err
+	}
+	if err := s.checkTemplateMetadata(template); err != nil {
+		return err
+	}
+	if err := validateSchemaDefinition(template.Schema); err != nil {
+		return err
+	}
+
+	warnings, err := checkTemplateContent(&template.TemplateContent)
+	if err != nil {
+		return err
+	}
+	warnings = append(warnings, s.templateIncludeWarnings(ctx, &template.TemplateContent)...)
+
+	draft := template.TemplateContent
+	template.Draft = &draft
+	template.TemplateContent = models.TemplateContent{}
+	template.Status = models.TemplateStatusDraft
+	template.PublishedAt = nil
+
+	if err := s.templateRepo.CreateTemplate(ctx, template); err != nil {
+		return err
+	}
+
+	s.audit(ctx, template.ID, models.AuditActionCreate, 0, "")
+	template.Warnings = warnings
+	return nil
+}
+
+// templateIncludeWarnings 본문(언어별 본문 포함)이 참조하는 레이아웃/부분 템플릿 존재 여부 경고
+func (s *EmailService) templateIncludeWarnings(ctx context.Context, content *models.TemplateContent) []string {
+	return s.includeWarnings(ctx, content.Layout, includeSources(content)...)
+}
+
+// includeSources 레이아웃/부분 템플릿을 포함할 수 있는 본문 (언어별 본문 포함)
+func includeSources(content *models.TemplateContent) []string {
+	sources := []string{content.HTMLContent, content.TextContent, content.MarkdownContent, content.CompiledHTML}
+	for _, variant := range content.Locales {
+		sources = append(sources, variant.HTMLContent, variant.TextContent, variant.MarkdownContent, variant.CompiledHTML)
+	}
+	return sources
+}
+
+func (s *EmailService) GetTemplate(ctx context.Context, id primitive.ObjectID) (*models.Template, error) {
+	return s.templates.GetTemplateByID(ctx, id)
+}
+
+// UpdateTemplate 템플릿 초안 수정 (게시된 내용은 PublishTemplate 전까지 유지)
+func (s *EmailService) UpdateTemplate(ctx context.Context, id primitive.ObjectID, template *models.Template) error {
+	if err := s.checkWritable(); err != nil {
+		return err
+	}
+	if err := s.checkTemplateMetadata(template); err != nil {
+		return err
+	}
+	if err := validateSchemaDefinition(template.Schema); err != nil {
+		return err
+	}
+
+	warnings, err := checkTemplateContent(&template.TemplateContent)
+	if err != nil {
+		return err
+	}
+	warnings = append(warnings, s.templateIncludeWarnings(ctx, &template.TemplateContent)...)
+
+	updated, err := s.templateRepo.UpdateDraft(ctx, id, template)
+	if err != nil {
+		return notFoundError(err, id, 0)
+	}
+	s.cache.Invalidate(id)
+
+	s.audit(ctx, id, models.AuditActionUpdateDraft, 0, "")
+	*template = *updated
+	template.Warnings = warnings
+	return nil
+}
+
+// PublishTemplate 초안을 새 버전으로 게시
+func (s *EmailService) PublishTemplate(ctx context.Context, id primitive.ObjectID, comment string) (*models.Template, error) {
+	if err := s.checkWritable(); err != nil {
+		return nil, err
+	}
+	template, err := s.templateRepo.PublishTemplate(ctx, id)
+	if err != nil {
+		return nil, notFoundError(err, id, 0)
+	}
+	s.cache.Invalidate(id)
+
+	s.audit(ctx, id, models.AuditActionPublish, template.Version, comment)
+	return template, nil
+}
+
+func (s *EmailService) DeleteTemplate(ctx context.Context, id primitive.ObjectID) error {
+	if err := s.checkWritable(); err != nil {
+		return err
+	}
+	if err := s.templateRepo.DeleteTemplate(ctx, id); err != nil {
+		return notFoundError(err, id, 0)
+	}
+	s.cache.Invalidate(id)
+
+	s.audit(ctx, id, models.AuditActionDelete, 0, "")
+	return nil
+}
+
+// 템플릿 목록 페이지 크기
+const (
+	DefaultTemplateListLimit = 50
+	MaxTemplateListLimit     = 200
+)
+
+// 템플릿 목록 정렬 기준
+var templateListSorts = map[string]bool{"name": true, "created_at": true, "updated_at": true}
+
+// ListTemplates 조건에 맞는 템플릿 목록 한 페이지 조회
+func (s *EmailService) ListTemplates(ctx context.Context, opts *models.TemplateListOptions) (*models.TemplateList, error) {
+	switch {
+	case opts.Limit == 0:
+		opts.Limit = DefaultTemplateListLimit
+	case opts.Limit < 0 || opts.Limit > MaxTemplateListLimit:
+		return nil, newError(ErrCodeInvalidRequest, "limit must be between 1 and %d", MaxTemplateListLimit)
+	}
+
+	if opts.Sort == "" {
+		opts.Sort = "name"
+	}
+	if !templateListSorts[strings.TrimPrefix(opts.Sort, "-")] {
+		return nil, newError(ErrCodeInvalidRequest, "unsupported sort %q (expected name, created_at or updated_at, optionally prefixed with \"-\")", opts.Sort)
+	}
+
+	switch opts.Status {
+	case "", models.TemplateStatusDraft, models.TemplateStatusPublished:
+	default:
+		return nil, newError(ErrCodeInvalidRequest, "unsupported status %q (expected %q or %q)", opts.Status, models.TemplateStatusDraft, models.TemplateStatusPublished)
+	}
+
+	// 태그는 저장 시 소문자로 정리된다
+	opts.Tag = strings.ToLower(strings.TrimSpace(opts.Tag))
+
+	// 언어 코드는 필드 경로(locales.<locale>)로 사용된다
+	if strings.ContainsAny(opts.Locale, ".$") {
+		return nil, newError(ErrCodeInvalidRequest, "invalid locale %q", opts.Locale)
+	}
+
+	list, err := s.templates.ListTemplates(ctx, opts)
+	if errors.Is(err, mongodb.ErrInvalidCursor) || errors.Is(err, filesystem.ErrInvalidCursor) {
+		return nil, newError(ErrCodeInvalidRequest, "%v", err)
+	}
+	return list, err
+}
+
+// TemplateCacheStats 전송 경로 템플릿 캐시 적중률과 항목 수
+func (s *EmailService) TemplateCacheStats() *models.TemplateCacheStats {
+	return s.cache.Stats()
+}
+
+func (s *EmailService) ListTemplateAudit(ctx context.Context, id primitive.ObjectID) ([]*models.TemplateAuditEntry, error) {
+	return s.templateRepo.ListAuditEntries(ctx, id)
+}
+
+// notFoundError 리포지토리의 "없음" 에러를 코드가 있는 서비스 에러로 변환 (그 밖의 에러는 그대로)
+func notFoundError(err error, id primitive.ObjectID, version int) error {
+	switch {
+	case errors.Is(err, mongodb.ErrTemplateNotFound):
+		return newError(ErrCodeTemplateIDNotFound, "template not found: id %s", id.Hex())
+	case errors.Is(err, mongodb.ErrTemplateVersionNotFound):
+		return newError(ErrCodeTemplateVersionNotFound, "template %s has no version %d", id.Hex(), version)
+	}
+	return err
+}
+
+// Template Versions
+
+func (s *EmailService) ListTemplateVersions(ctx context.Context, id primitive.ObjectID) ([]*models.TemplateVersion, error) {
+	return s.templates.ListTemplateVersions(ctx, id)
+}
+
+func (s *EmailService) GetTemplateVersion(ctx context.Context, id primitive.ObjectID, version int) (*models.TemplateVersion, error) {
+	return s.templates.GetTemplateVersion(ctx, id, version)
+}
+
+// RollbackTemplate 이전 버전을 새 버전으로 다시 게시
+func (s *EmailService) RollbackTemplate(ctx context.Context, id primitive.ObjectID, version int) (*models.Template, error) {
+	if err := s.checkWritable(); err != nil {
+		return nil, err
+	}
+	template, err := s.templateRepo.RollbackTemplate(ctx, id, version)
+	if err != nil {
+		return nil, notFoundError(err, id, version)
+	}
+	s.cache.Invalidate(id)
+
+	s.audit(ctx, id, models.AuditActionRollback, template.Version, fmt.Sprintf("rolled back to version %d", version))
+	return template, nil
+}
+
+// DiffTemplateVersions 두 버전의 필드별 차이 계산
+func (s *EmailService) DiffTemplateVersions(ctx context.Context, id primitive.ObjectID, from, to int) (*models.TemplateDiff, error) {
+	fromVersion, err := s.templates.GetTemplateVersion(ctx, id, from)
+	if err != nil {
+		return nil, err
+	}
+	toVersion, err := s.templates.GetTemplateVersion(ctx, id, to)
+	if err != nil {
+		return nil, err
+	}
+	if fromVersion == nil {
+		return nil, notFoundError(mongodb.ErrTemplateVersionNotFound, id, from)
+	}
+	if toVersion == nil {
+		return nil, notFoundError(mongodb.ErrTemplateVersionNotFound, id, to)
+	}
+
+	return &models.TemplateDiff{
+		TemplateID:  id,
+		FromVersion: from,
+		ToVersion:   to,
+		Changes:     contentDiff(fmt.Sprintf("v%d", from), fmt.Sprintf("v%d", to), &fromVersion.TemplateContent, &toVersion.TemplateContent),
+	}, nil
+}
+
+// contentDiff 두 본문의 필드별 unified diff (바뀐 필드만 포함)
+func contentDiff(fromName, toName string, from, to *models.TemplateContent) map[string]string {
+	type field struct {
+		name     string
+		from, to string
+	}
+	fields := []field{
+		{"subject", from.Subject, to.Subject},
+		{"html_content", from.HTMLContent, to.HTMLContent},
+		{"text_content", from.TextContent, to.TextContent},
+		{"preheader", from.Preheader, to.Preheader},
+		{"format", from.Format, to.Format},
+		{"markdown_content", from.MarkdownContent, to.MarkdownContent},
+		{"mjml_content", from.MJMLContent, to.MJMLContent},
+		{"layout", from.Layout, to.Layout},
+		{"variables", strings.Join(from.Variables, "\n"), strings.Join(to.Variables, "\n")},
+		{"default_locale", from.DefaultLocale, to.DefaultLocale},
+		{"schema", schemaText(from.Schema), schemaText(to.Schema)},
+	}
+
+	// 언어별 본문 비교 (한쪽에만 있는 언어도 포함)
+	locales := make(map[string]bool)
+	for locale := range from.Locales {
+		locales[locale] = true
+	}
+	for locale := range to.Locales {
+		locales[locale] = true
+	}
+	for locale := range locales {
+		a, b := from.Locales[locale], to.Locales[locale]
+		fields = append(fields,
+			field{"locales." + locale + ".subject", a.Subject, b.Subject},
+			field{"locales." + locale + ".html_content", a.HTMLContent, b.HTMLContent},
+			field{"locales." + locale + ".text_content", a.TextContent, b.TextContent},
+			field{"locales." + locale + ".preheader", a.Preheader, b.Preheader},
+			field{"locales." + locale + ".markdown_content", a.MarkdownContent, b.MarkdownContent},
+			field{"locales." + locale + ".mjml_content", a.MJMLContent, b.MJMLContent},
+		)
+	}
+
+	changes := make(map[string]string)
+	for _, f := range fields {
+		if d := textdiff.Unified(fromName+"/"+f.name, toName+"/"+f.name, f.from, f.to); d != "" {
+			changes[f.name] = d
+		}
+	}
+	return changes
+}
+
+// schemaText 비교용 스키마 JSON (들여쓰기 포함)
+func schemaText(schema *models.VariableSchema) string {
+	if schema == nil {
+		return ""
+	}
+	b, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
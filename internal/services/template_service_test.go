@@ -0,0 +1,39 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/kihyun1998/prisma-market/prisma-email-service/internal/repository/mongodb"
+)
+
+func TestNotFoundError(t *testing.T) {
+	id := primitive.NewObjectID()
+	dbErr := errors.New("connection reset")
+
+	tests := []struct {
+		err  error
+		code string
+	}{
+		{mongodb.ErrTemplateNotFound, ErrCodeTemplateIDNotFound},
+		{fmt.Errorf("publish: %w", mongodb.ErrTemplateVersionNotFound), ErrCodeTemplateVersionNotFound},
+		{dbErr, ""},
+	}
+	for _, tt := range tests {
+		err := notFoundError(tt.err, id, 3)
+		var serviceErr *Error
+		if tt.code == "" {
+			// DB 오류는 404로 바꾸지 않는다
+			if err != dbErr {
+				t.Errorf("notFoundError(%v) = %v, want the original error", tt.err, err)
+			}
+			continue
+		}
+		if !errors.As(err, &serviceErr) || serviceErr.Code != tt.code {
+			t.Errorf("notFoundError(%v) = %v, want code %s", tt.err, err, tt.code)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/kihyun1998/prisma-market/prisma-email-service/internal/models"
+	"github.com/kihyun1998/prisma-market/prisma-email-service/internal/repository/filesystem"
+	"github.com/kihyun1998/prisma-market/prisma-email-service/pkg/render"
+)
+
+// 템플릿 원본 위치
+const (
+	TemplateSourceMongoDB    = "mongodb"    // MongoDB에서 조회하고 API로 편집
+	TemplateSourceFilesystem = "filesystem" // 디렉터리를 읽기 전용 원본으로 사용 (API 편집 불가)
+	TemplateSourceSync       = "sync"       // 디렉터리 내용을 MongoDB에 반영한 뒤 MongoDB에서 조회
+)
+
+// templateSyncActor 디렉터리 동기화로 바뀐 항목의 감사 로그 사용자
+const templateSyncActor = "template-sync"
+
+// TemplateReader 템플릿과 레이아웃/부분 템플릿 조회
+// MongoDB 리포지토리와 디렉터리에서 읽은 읽기 전용 리포지토리가 구현한다.
+type TemplateReader interface {
+	GetTemplateByID(ctx context.Context, id primitive.ObjectID) (*models.Template, error)
+	GetTemplateByName(ctx context.Context, name string) (*models.Template, error)
+	FindTemplatesByName(ctx context.Context, names []string) ([]*models.Template, error)
+	ListTemplates(ctx context.Context, opts *models.TemplateListOptions) (*models.TemplateList, error)
+	ListTemplateVersions(ctx context.Context, templateID primitive.ObjectID) ([]*models.TemplateVersion, error)
+	GetTemplateVersion(ctx context.Context, templateID primitive.ObjectID, version int) (*models.TemplateVersion, error)
+	GetFragmentByID(ctx context.Context, kind string, id primitive.ObjectID) (*models.Fragment, error)
+	GetFragmentByName(ctx context.Context, kind, name string) (*models.Fragment, error)
+	ListFragments(ctx context.Context, kind string) ([]*models.Fragment, error)
+}
+
+// UseReadOnlySource 템플릿 조회를 reader로 바꾸고 템플릿/레이아웃/부분 템플릿 편집을 막는다
+// 수신 거부 목록과 감사 로그는 계속 MongoDB를 사용한다.
+func (s *EmailService) UseReadOnlySource(reader TemplateReader) {
+	s.templates = reader
+	s.readOnly = true
+}
+
+// checkWritable 읽기 전용 원본이면 편집 거부
+func (s *EmailService) checkWritable() error {
+	if s.readOnly {
+		return newError(ErrCodeReadOnlySource, "templates are loaded from a directory and cannot be modified through the API")
+	}
+	return nil
+}
+
+// ReloadTemplateDir 디렉터리의 템플릿을 검사해 읽기 전용 리포지토리 내용을 교체
+// 하나라도 검사에 실패하면 기존 내용을 유지한다. 디렉터리의 내용은 게시된 버전 1로 취급한다.
+func (s *EmailService) ReloadTemplateDir(dir string, repo *filesystem.TemplateRepository) error {
+	bundle, err := filesystem.LoadBundle(dir)
+	if err != nil {
+		return err
+	}
+	if err := checkBundleNames(bundle); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var fragments []*models.Fragment
+	layouts := make(map[string]bool, len(bundle.Layouts))
+	partials := make(map[string][]string, len(bundle.Partials))
+	for _, group := range []struct {
+		kind      string
+		fragments []models.BundleFragment
+	}{
+		{models.FragmentKindLayout, bundle.Layouts},
+		{models.FragmentKindPartial, bundle.Partials},
+	} {
+		for _, bf := range group.fragments {
+			fragment := &models.Fragment{
+				ID:          filesystem.FragmentID(group.kind, bf.Name),
+				Kind:        group.kind,
+				Name:        bf.Name,
+				Description: bf.Description,
+				HTMLContent: bf.HTMLContent,
+				TextContent: bf.TextContent,
+				CreatedAt:   now,
+				UpdatedAt:   now,
+			}
+			refs, err := checkFragmentContent(fragment)
+			if err != nil {
+				return fmt.Errorf("%s %q: %w", group.kind, bf.Name, err)
+			}
+
+			if group.kind == models.FragmentKindLayout {
+				layouts[bf.Name] = true
+			} else {
+				partials[bf.Name] = refs
+			}
+			fragments = append(fragments, fragment)
+		}
+	}
+	if cycle := render.FindCycle(partials); cycle != nil {
+		return newError(ErrCodeIncludeCycle, "%v", &render.CycleError{Path: cycle})
+	}
+
+	templates := make([]*models.Template, 0, len(bundle.Templates))
+	for _, bt := range bundle.Templates {
+		template := &models.Template{
+			ID:              filesystem.TemplateID(bt.Name),
+			Name:            bt.Name,
+			TemplateContent: bt.Content,
+			Status:          models.TemplateStatusPublished,
+			Version:         1,
+			PublishedAt:     &now,
+			SampleData:      bt.SampleData,
+			Samples:         bt.Samples,
+			Tags:            bt.Tags,
+			Category:        bt.Category,
+			Team:            bt.Team,
+			Description:     bt.Description,
+			CreatedAt:       now,
+			UpdatedAt:       now,
+		}
+		if err := s.checkTemplateMetadata(template); err != nil {
+			return fmt.Errorf("template %q: %w", bt.Name, err)
+		}
+		if err := validateSchemaDefinition(template.Schema); err != nil {
+			return fmt.Errorf("template %q: %w", bt.Name, err)
+		}
+		if _, err := checkTemplateContent(&template.TemplateContent); err != nil {
+			return fmt.Errorf("template %q: %w", bt.Name, err)
+		}
+
+		// 없는 레이아웃/부분 템플릿은 전송 시 에러가 되므로 미리 알린다
+		for _, warning := range missingIncludes(&template.TemplateContent, layouts, partials) {
+			log.Printf("Template directory: template %q: %s", bt.Name, warning)
+		}
+		templates = append(templates, template)
+	}
+
+	repo.Replace(templates, fragments)
+	s.cache.Flush()
+	log.Printf("Template directory: loaded %d templates, %d layouts, %d partials from %s", len(templates), len(bundle.Layouts), len(bundle.Partials), dir)
+	return nil
+}
+
+// missingIncludes 디렉터리에 없는 레이아웃/부분 템플릿 참조
+func missingIncludes(content *models.TemplateContent, layouts map[string]bool, partials map[string][]string) []string {
+	var warnings []string
+	if content.Layout != "" && !layouts[content.Layout] {
+		warnings = append(warnings, fmt.Sprintf("layout %q does not exist", content.Layout))
+	}
+
+	names, _ := render.ReferencedTemplates(includeSources(content)...)
+	for _, name := range names {
+		if _, ok := partials[name]; !ok && name != render.ContentTemplate {
+			warnings = append(warnings, fmt.Sprintf("partial %q does not exist", name))
+		}
+	}
+	return warnings
+}
+
+// SyncTemplateDir 디렉터리의 템플릿을 MongoDB에 반영 (바뀐 템플릿은 새 버전으로 게시)
+// 디렉터리에서 사라진 항목은 삭제하지 않는다.
+func (s *EmailService) SyncTemplateDir(ctx context.Context, dir string) (*models.ImportResult, error) {
+	bundle, err := filesystem.LoadBundle(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.ImportBundle(ContextWithActor(ctx, templateSyncActor), bundle, &models.ImportOptions{
+		Conflict: models.ImportConflictNewVersion,
+	})
+	if err != nil {
+		var serviceErr *Error
+		if errors.As(err, &serviceErr) {
+			for _, detail := range serviceErr.Details {
+				log.Printf("Template directory: %s: %s", detail.Field, detail.Message)
+			}
+		}
+		if result != nil {
+			for _, item := range result.Items {
+				log.Printf("Template directory: %s %q: %s (applied before failure)", item.Kind, item.Name, item.Action)
+			}
+		}
+		return nil, err
+	}
+
+	counts := make(map[string]int)
+	for _, item := range result.Items {
+		counts[item.Action]++
+	}
+	summary := make([]string, 0, len(counts))
+	for action, n := range counts {
+		summary = append(summary, fmt.Sprintf("%s=%d", action, n))
+	}
+	sort.Strings(summary)
+	log.Printf("Template directory: synced %s (%s)", dir, strings.Join(summary, ", "))
+	return result, nil
+}
@@ -0,0 +1,111 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/kihyun1998/prisma-market/prisma-email-service/internal/models"
+)
+
+// 테스트 전송 표시
+const (
+	TestSubjectPrefix = "[TEST] "
+	TestHeader        = "X-Test"
+)
+
+// TestSendTemplate 템플릿을 렌더링해 허용된 내부 주소로 테스트 전송
+// 테스트 메일은 X-Test 헤더와 제목 접두어로 표시하며, 콜백(전송 이벤트)을 보내지 않아 운영 통계에 집계되지 않는다.
+func (s *EmailService) TestSendTemplate(ctx context.Context, id primitive.ObjectID, req *models.TestSendRequest) (*models.TestSendResponse, error) {
+	to, err := s.testRecipients(req.To)
+	if err != nil {
+		return nil, err
+	}
+
+	template, err := s.getTemplate(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	rendered, err := s.render(ctx, template, &models.RenderRequest{
+		Variables: req.Variables,
+		Sample:    req.Sample,
+		Locale:    req.Locale,
+		Published: req.Published,
+		To:        to,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	subject := TestSubjectPrefix + rendered.Subject
+	headers := messageHeaders(template, rendered.Locale)
+	headers[TestHeader] = "true"
+	if template.Category == models.TemplateCategoryMarketing && s.unsubscribe.configured() {
+		// 본문의 수신 거부 링크와 같은 첫 수신자 기준 헤더
+		if _, err := s.withUnsubscribe(to[0], nil, headers); err != nil {
+			return nil, err
+		}
+	}
+
+	msg := s.smtpClient.NewMessage(to, subject, rendered.HTML, rendered.Text, headers)
+	if err := s.smtpClient.SendEmail(ctx, msg); err != nil {
+		return nil, fmt.Errorf("failed to send test email: %v", err)
+	}
+
+	s.audit(ctx, id, models.AuditActionTestSend, 0, strings.Join(to, ", "))
+
+	return &models.TestSendResponse{
+		MessageID:       primitive.NewObjectID().Hex(),
+		Status:          "sent",
+		To:              to,
+		Subject:         subject,
+		TemplateVersion: template.Version,
+		Draft:           rendered.Draft,
+		Locale:          rendered.Locale,
+		SentAt:          time.Now(),
+	}, nil
+}
+
+// testRecipients 수신자를 파싱해 허용 목록을 확인하고 주소만 반환 ("이름 <주소>"의 이름은 버린다)
+// 반환한 주소를 메시지와 SMTP RCPT TO에 그대로 사용한다.
+func (s *EmailService) testRecipients(recipients []string) ([]string, error) {
+	if len(recipients) == 0 {
+		return nil, newError(ErrCodeInvalidRequest, "at least one recipient is required")
+	}
+
+	addresses := make([]string, 0, len(recipients))
+	for _, to := range recipients {
+		addr, err := mail.ParseAddress(to)
+		if err != nil || !s.testAddressAllowed(addr.Address) {
+			return nil, newError(ErrCodeRecipientNotAllowed, "recipient %q is not in the test-send allowlist", to)
+		}
+		addresses = append(addresses, addr.Address)
+	}
+	return addresses, nil
+}
+
+// testAddressAllowed 허용 목록에 있는 주소인지 확인
+// 항목은 전체 주소(qa@example.com) 또는 도메인(@example.com)이며, 목록이 비어 있으면 모두 거부한다.
+func (s *EmailService) testAddressAllowed(address string) bool {
+	address = strings.ToLower(address)
+
+	for _, entry := range s.testAllowlist {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" {
+			continue
+		}
+		if strings.HasPrefix(entry, "@") {
+			if strings.HasSuffix(address, entry) {
+				return true
+			}
+		} else if address == entry {
+			return true
+		}
+	}
+	return false
+}
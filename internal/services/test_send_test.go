@@ -0,0 +1,50 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestTestRecipients(t *testing.T) {
+	s := &EmailService{testAllowlist: []string{"qa@example.com", " @Corp.example "}}
+
+	tests := []struct {
+		name string
+		to   []string
+		want []string
+		code string
+	}{
+		{name: "bare addresses", to: []string{"qa@example.com", "dev@corp.example"}, want: []string{"qa@example.com", "dev@corp.example"}},
+		// 메시지와 RCPT TO에는 표시 이름 없이 주소만 사용한다
+		{name: "display name", to: []string{`"QA Team" <QA@example.com>`, "Dev <dev@corp.example>"}, want: []string{"QA@example.com", "dev@corp.example"}},
+		{name: "no recipients", code: ErrCodeInvalidRequest},
+		{name: "not allowed", to: []string{"qa@example.com", "someone@gmail.com"}, code: ErrCodeRecipientNotAllowed},
+		{name: "similar domain", to: []string{"dev@evilcorp.example"}, code: ErrCodeRecipientNotAllowed},
+		{name: "unparsable", to: []string{"qa@example.com>"}, code: ErrCodeRecipientNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.testRecipients(tt.to)
+			if tt.code != "" {
+				var serviceErr *Error
+				if !errors.As(err, &serviceErr) || serviceErr.Code != tt.code {
+					t.Fatalf("error = %v, want %s", err, tt.code)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("addresses = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// 허용 목록이 비어 있으면 모두 거부한다
+	if _, err := (&EmailService{}).testRecipients([]string{"qa@example.com"}); err == nil {
+		t.Error("empty allowlist accepted a recipient")
+	}
+}
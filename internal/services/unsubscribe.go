@@ -0,0 +1,169 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"strings"
+
+	"github.com/kihyun1998/prisma-market/prisma-email-service/internal/models"
+)
+
+// UnsubscribeVariable 마케팅 템플릿에서 수신 거부 링크로 사용하는 변수 ({{.unsubscribe_url}})
+const UnsubscribeVariable = "unsubscribe_url"
+
+// UnsubscribeOptions 마케팅 메일 수신 거부 설정
+type UnsubscribeOptions struct {
+	BaseURL string // 수신 거부 엔드포인트 전체 URL (e.g., https://mail.example.com/api/v1/unsubscribe)
+	Secret  string // 토큰 서명 키
+	Mailto  string // List-Unsubscribe에 함께 넣을 메일 주소 (선택)
+}
+
+func (o UnsubscribeOptions) configured() bool {
+	return o.BaseURL != "" && o.Secret != ""
+}
+
+// unsubscribeToken 주소와 서명을 담은 수신 거부 토큰 (주소는 소문자로 정규화)
+func (s *EmailService) unsubscribeToken(email string) string {
+	email = strings.ToLower(email)
+	return base64.RawURLEncoding.EncodeToString([]byte(email)) + "." +
+		base64.RawURLEncoding.EncodeToString(s.unsubscribeSignature(email))
+}
+
+func (s *EmailService) unsubscribeSignature(email string) []byte {
+	mac := hmac.New(sha256.New, []byte(s.unsubscribe.Secret))
+	mac.Write([]byte(email))
+	return mac.Sum(nil)
+}
+
+// parseUnsubscribeToken 토큰 서명 확인 후 주소 반환
+func (s *EmailService) parseUnsubscribeToken(token string) (string, error) {
+	encodedEmail, encodedSignature, ok := strings.Cut(token, ".")
+	if !ok {
+		return "", errors.New("malformed token")
+	}
+	email, err := base64.RawURLEncoding.DecodeString(encodedEmail)
+	if err != nil {
+		return "", errors.New("malformed token")
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil {
+		return "", errors.New("malformed token")
+	}
+	if !hmac.Equal(signature, s.unsubscribeSignature(string(email))) {
+		return "", errors.New("signature mismatch")
+	}
+	return string(email), nil
+}
+
+// unsubscribeURL 수신자별 수신 거부 링크
+func (s *EmailService) unsubscribeURL(email string) string {
+	return s.unsubscribe.BaseURL + "?token=" + url.QueryEscape(s.unsubscribeToken(email))
+}
+
+// withUnsubscribe 수신자별 수신 거부 링크 변수와 List-Unsubscribe 헤더 추가 (RFC 2369, RFC 8058 원클릭)
+func (s *EmailService) withUnsubscribe(to string, variables map[string]interface{}, headers map[string]string) (map[string]interface{}, error) {
+	if !s.unsubscribe.configured() {
+		return nil, newError(ErrCodeUnsubscribeNotConfigured, "unsubscribe handling is not configured (UNSUBSCRIBE_BASE_URL, UNSUBSCRIBE_SECRET)")
+	}
+
+	link := s.unsubscribeURL(recipientAddress(to))
+	merged := make(map[string]interface{}, len(variables)+1)
+	for k, v := range variables {
+		merged[k] = v
+	}
+	merged[UnsubscribeVariable] = link
+
+	listUnsubscribe := "<" + link + ">"
+	if s.unsubscribe.Mailto != "" {
+		listUnsubscribe += ", <mailto:" + s.unsubscribe.Mailto + "?subject=unsubscribe>"
+	}
+	headers["List-Unsubscribe"] = listUnsubscribe
+	headers["List-Unsubscribe-Post"] = "List-Unsubscribe=One-Click"
+	return merged, nil
+}
+
+// marketingRecipients 수신 거부한 주소를 제외한 수신자와 제외된 수신자
+func (s *EmailService) marketingRecipients(ctx context.Context, to []string) ([]string, []string, error) {
+	addresses := make([]string, len(to))
+	for i, recipient := range to {
+		addresses[i] = recipientAddress(recipient)
+	}
+
+	suppressed, err := s.templateRepo.SuppressedAddresses(ctx, addresses)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to check suppressions: %v", err)
+	}
+
+	var allowed, excluded []string
+	for i, recipient := range to {
+		if suppressed[strings.ToLower(addresses[i])] {
+			excluded = append(excluded, recipient)
+		} else {
+			allowed = append(allowed, recipient)
+		}
+	}
+	return allowed, excluded, nil
+}
+
+// recipientAddress "이름 <주소>" 형식에서 주소만 추출 (파싱할 수 없으면 그대로 사용)
+func recipientAddress(recipient string) string {
+	if addr, err := mail.ParseAddress(recipient); err == nil {
+		return addr.Address
+	}
+	return recipient
+}
+
+// UnsubscribeAddress 수신 거부 토큰의 주소 확인 (등록하지 않는다, 확인 페이지용)
+func (s *EmailService) UnsubscribeAddress(token string) (string, error) {
+	if !s.unsubscribe.configured() {
+		return "", newError(ErrCodeUnsubscribeNotConfigured, "unsubscribe handling is not configured")
+	}
+
+	email, err := s.parseUnsubscribeToken(token)
+	if err != nil {
+		return "", newError(ErrCodeInvalidUnsubscribeToken, "invalid unsubscribe token: %v", err)
+	}
+	return email, nil
+}
+
+// Unsubscribe 확인 페이지 또는 원클릭 요청의 수신 거부 등록
+func (s *EmailService) Unsubscribe(ctx context.Context, token string) error {
+	email, err := s.UnsubscribeAddress(token)
+	if err != nil {
+		return err
+	}
+
+	return s.templateRepo.AddSuppression(ctx, &models.Suppression{
+		Email:  email,
+		Reason: models.SuppressionReasonUnsubscribe,
+	})
+}
+
+// Suppression Management
+
+// AddSuppression 운영자가 수신 거부 주소 등록
+func (s *EmailService) AddSuppression(ctx context.Context, suppression *models.Suppression) error {
+	addr, err := mail.ParseAddress(suppression.Email)
+	if err != nil {
+		return newError(ErrCodeInvalidRequest, "invalid email %q: %v", suppression.Email, err)
+	}
+	suppression.Email = addr.Address
+	if suppression.Reason == "" {
+		suppression.Reason = models.SuppressionReasonManual
+	}
+	return s.templateRepo.AddSuppression(ctx, suppression)
+}
+
+func (s *EmailService) DeleteSuppression(ctx context.Context, email string) error {
+	return s.templateRepo.DeleteSuppression(ctx, email)
+}
+
+func (s *EmailService) ListSuppressions(ctx context.Context) ([]*models.Suppression, error) {
+	return s.templateRepo.ListSuppressions(ctx)
+}
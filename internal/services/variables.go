@@ -0,0 +1,333 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/kihyun1998/prisma-market/prisma-email-service/internal/models"
+)
+
+// FieldError 변수 하나의 검증 실패
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+	Line    int    `json:"line,omitempty"`   // 템플릿 문법 오류 위치
+	Column  int    `json:"column,omitempty"` // 템플릿 문법 오류 위치
+}
+
+// prepareVariables 요청 변수를 템플릿 스키마로 검증하고 기본값을 채운 복사본 반환
+// 스키마가 없는 템플릿은 Variables 목록의 존재 여부만 검사한다.
+func prepareVariables(content *models.TemplateContent, variables map[string]interface{}) (map[string]interface{}, error) {
+	if content.Schema == nil {
+		for _, required := range content.Variables {
+			if _, exists := variables[required]; !exists {
+				return nil, &Error{
+					Code:    ErrCodeInvalidVariables,
+					Message: fmt.Sprintf("missing required variable: %s", required),
+					Details: []FieldError{{Field: required, Message: "is required"}},
+				}
+			}
+		}
+		return variables, nil
+	}
+
+	v := &variableValidator{}
+	var root interface{} = variables
+	if variables == nil {
+		root = map[string]interface{}{}
+	}
+
+	result := v.validate("", content.Schema, root, true)
+	if len(v.errors) > 0 {
+		messages := make([]string, len(v.errors))
+		for i, e := range v.errors {
+			messages[i] = e.Field + ": " + e.Message
+		}
+		return nil, &Error{
+			Code:    ErrCodeInvalidVariables,
+			Message: "invalid variables: " + strings.Join(messages, "; "),
+			Details: v.errors,
+		}
+	}
+
+	prepared, _ := result.(map[string]interface{})
+	return prepared, nil
+}
+
+type variableValidator struct {
+	errors []FieldError
+}
+
+func (v *variableValidator) fail(field, format string, args ...interface{}) {
+	if field == "" {
+		field = "$"
+	}
+	v.errors = append(v.errors, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// validate 값 하나를 검증하고 기본값이 적용된 값을 반환
+func (v *variableValidator) validate(path string, schema *models.VariableSchema, value interface{}, present bool) interface{} {
+	if !present || value == nil {
+		if schema.Default != nil {
+			return schema.Default
+		}
+		return nil
+	}
+
+	switch schema.Type {
+	case models.SchemaTypeObject:
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			v.fail(path, "expected object, got %s", typeName(value))
+			return value
+		}
+		return v.validateObject(path, schema, obj)
+
+	case models.SchemaTypeArray:
+		rv := reflect.ValueOf(value)
+		if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+			v.fail(path, "expected array, got %s", typeName(value))
+			return value
+		}
+		if schema.MinItems != nil && rv.Len() < *schema.MinItems {
+			v.fail(path, "must contain at least %d items", *schema.MinItems)
+		}
+		if schema.MaxItems != nil && rv.Len() > *schema.MaxItems {
+			v.fail(path, "must contain at most %d items", *schema.MaxItems)
+		}
+		items := make([]interface{}, rv.Len())
+		for i := range items {
+			items[i] = rv.Index(i).Interface()
+			if schema.Items != nil {
+				items[i] = v.validate(fmt.Sprintf("%s[%d]", path, i), schema.Items, items[i], true)
+			}
+		}
+		return items
+
+	case models.SchemaTypeString:
+		s, ok := value.(string)
+		if !ok {
+			v.fail(path, "expected string, got %s", typeName(value))
+			return value
+		}
+		v.validateString(path, schema, s)
+
+	case models.SchemaTypeNumber, models.SchemaTypeInteger:
+		n, ok := toFloat(value)
+		if !ok {
+			v.fail(path, "expected %s, got %s", schema.Type, typeName(value))
+			return value
+		}
+		if schema.Type == models.SchemaTypeInteger && n != math.Trunc(n) {
+			v.fail(path, "expected integer, got %v", n)
+		}
+		if schema.Minimum != nil && n < *schema.Minimum {
+			v.fail(path, "must be >= %v", *schema.Minimum)
+		}
+		if schema.Maximum != nil && n > *schema.Maximum {
+			v.fail(path, "must be <= %v", *schema.Maximum)
+		}
+
+	case models.SchemaTypeBoolean:
+		if _, ok := value.(bool); !ok {
+			v.fail(path, "expected boolean, got %s", typeName(value))
+			return value
+		}
+	}
+
+	if len(schema.Enum) > 0 && !inEnum(schema.Enum, value) {
+		v.fail(path, "must be one of %v", schema.Enum)
+	}
+
+	return value
+}
+
+func (v *variableValidator) validateObject(path string, schema *models.VariableSchema, obj map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(obj))
+	for k, val := range obj {
+		result[k] = val
+	}
+
+	for _, name := range schema.Required {
+		if val, ok := obj[name]; !ok || val == nil {
+			if prop := schema.Properties[name]; prop == nil || prop.Default == nil {
+				v.fail(joinPath(path, name), "is required")
+			}
+		}
+	}
+
+	// 결과 순서를 일정하게 유지하기 위해 이름순으로 검사
+	names := make([]string, 0, len(schema.Properties))
+	for name := range schema.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		val, present := obj[name]
+		if checked := v.validate(joinPath(path, name), schema.Properties[name], val, present); checked != nil {
+			result[name] = checked
+		}
+	}
+
+	return result
+}
+
+func (v *variableValidator) validateString(path string, schema *models.VariableSchema, s string) {
+	length := utf8.RuneCountInString(s)
+	if schema.MinLength != nil && length < *schema.MinLength {
+		v.fail(path, "must be at least %d characters", *schema.MinLength)
+	}
+	if schema.MaxLength != nil && length > *schema.MaxLength {
+		v.fail(path, "must be at most %d characters", *schema.MaxLength)
+	}
+	if schema.Pattern != "" {
+		if re, err := regexp.Compile(schema.Pattern); err == nil && !re.MatchString(s) {
+			v.fail(path, "must match pattern %s", schema.Pattern)
+		}
+	}
+
+	switch schema.Format {
+	case models.SchemaFormatEmail:
+		if addr, err := mail.ParseAddress(s); err != nil || addr.Address != s {
+			v.fail(path, "must be a valid email address")
+		}
+	case models.SchemaFormatURL:
+		if u, err := url.Parse(s); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			v.fail(path, "must be an absolute http(s) URL")
+		}
+	case models.SchemaFormatDate:
+		if _, err := time.Parse("2006-01-02", s); err != nil {
+			v.fail(path, "must be a date (YYYY-MM-DD)")
+		}
+	case models.SchemaFormatDateTime:
+		if _, err := time.Parse(time.RFC3339, s); err != nil {
+			v.fail(path, "must be an RFC 3339 date-time")
+		}
+	}
+}
+
+// validateSchemaDefinition 템플릿 저장 시 스키마 정의 자체를 검사
+func validateSchemaDefinition(schema *models.VariableSchema) error {
+	if schema == nil {
+		return nil
+	}
+	if schema.Type != models.SchemaTypeObject {
+		return newError(ErrCodeInvalidSchema, "schema: root type must be object")
+	}
+
+	var errs []FieldError
+	var walk func(path string, s *models.VariableSchema)
+	walk = func(path string, s *models.VariableSchema) {
+		if s == nil {
+			errs = append(errs, FieldError{Field: path, Message: "schema is empty"})
+			return
+		}
+		switch s.Type {
+		case models.SchemaTypeObject:
+			for _, name := range s.Required {
+				if _, ok := s.Properties[name]; !ok {
+					errs = append(errs, FieldError{Field: joinPath(path, name), Message: "required property is not defined"})
+				}
+			}
+			for name, prop := range s.Properties {
+				walk(joinPath(path, name), prop)
+			}
+		case models.SchemaTypeArray:
+			if s.Items != nil {
+				walk(path+"[]", s.Items)
+			}
+		case models.SchemaTypeString:
+			switch s.Format {
+			case "", models.SchemaFormatEmail, models.SchemaFormatURL, models.SchemaFormatDate, models.SchemaFormatDateTime:
+			default:
+				errs = append(errs, FieldError{Field: path, Message: fmt.Sprintf("unsupported format %q", s.Format)})
+			}
+			if s.Pattern != "" {
+				if _, err := regexp.Compile(s.Pattern); err != nil {
+					errs = append(errs, FieldError{Field: path, Message: fmt.Sprintf("invalid pattern: %v", err)})
+				}
+			}
+		case models.SchemaTypeNumber, models.SchemaTypeInteger, models.SchemaTypeBoolean:
+		default:
+			errs = append(errs, FieldError{Field: path, Message: fmt.Sprintf("unsupported type %q", s.Type)})
+		}
+	}
+	walk("", schema)
+
+	if len(errs) > 0 {
+		sort.Slice(errs, func(i, j int) bool { return errs[i].Field < errs[j].Field })
+		return &Error{Code: ErrCodeInvalidSchema, Message: "invalid variable schema", Details: errs}
+	}
+	return nil
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch n := value.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
+
+func inEnum(enum []interface{}, value interface{}) bool {
+	for _, e := range enum {
+		if ef, ok := toFloat(e); ok {
+			if vf, ok := toFloat(value); ok && ef == vf {
+				return true
+			}
+			continue
+		}
+		if reflect.DeepEqual(e, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func typeName(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	}
+	if _, ok := toFloat(value); ok {
+		return "number"
+	}
+	return fmt.Sprintf("%T", value)
+}
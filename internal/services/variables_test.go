@@ -0,0 +1,120 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/kihyun1998/prisma-market/prisma-email-service/internal/models"
+)
+
+const testSchema = `{
+	"type": "object",
+	"required": ["name", "email"],
+	"properties": {
+		"name":   {"type": "string", "min_length": 1, "max_length": 5},
+		"email":  {"type": "string", "format": "email"},
+		"url":    {"type": "string", "format": "url"},
+		"plan":   {"type": "string", "enum": ["free", "pro"], "default": "free"},
+		"count":  {"type": "integer", "minimum": 1},
+		"items":  {"type": "array", "max_items": 2, "items": {"type": "object", "required": ["sku"], "properties": {"sku": {"type": "string", "pattern": "^[A-Z]+$"}}}},
+		"active": {"type": "boolean"}
+	}
+}`
+
+func schemaContent(t *testing.T) *models.TemplateContent {
+	t.Helper()
+	var schema models.VariableSchema
+	if err := json.Unmarshal([]byte(testSchema), &schema); err != nil {
+		t.Fatal(err)
+	}
+	return &models.TemplateContent{Schema: &schema}
+}
+
+func TestPrepareVariablesAppliesDefaults(t *testing.T) {
+	variables := map[string]interface{}{"name": "Tom", "email": "tom@example.com", "count": float64(2)}
+	prepared, err := prepareVariables(schemaContent(t), variables)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if prepared["plan"] != "free" {
+		t.Errorf("plan = %v, want default free", prepared["plan"])
+	}
+	if _, ok := variables["plan"]; ok {
+		t.Error("request variables were modified")
+	}
+}
+
+func TestPrepareVariablesReportsEveryField(t *testing.T) {
+	variables := map[string]interface{}{
+		"name":   "Too long name",
+		"url":    "ftp://example.com",
+		"plan":   "enterprise",
+		"count":  1.5,
+		"items":  []interface{}{map[string]interface{}{"sku": "abc"}, map[string]interface{}{}, map[string]interface{}{"sku": "X"}},
+		"active": "yes",
+	}
+	_, err := prepareVariables(schemaContent(t), variables)
+
+	var serviceErr *Error
+	if !errors.As(err, &serviceErr) || serviceErr.Code != ErrCodeInvalidVariables {
+		t.Fatalf("error = %v, want %s", err, ErrCodeInvalidVariables)
+	}
+	fields := make(map[string]bool)
+	for _, detail := range serviceErr.Details {
+		fields[detail.Field] = true
+	}
+	for _, field := range []string{"email", "name", "url", "plan", "count", "items", "items[0].sku", "items[1].sku", "active"} {
+		if !fields[field] {
+			t.Errorf("missing error for %s (got %v)", field, serviceErr.Details)
+		}
+	}
+}
+
+func TestPrepareVariablesWithoutSchema(t *testing.T) {
+	content := &models.TemplateContent{Variables: []string{"name"}}
+	if _, err := prepareVariables(content, map[string]interface{}{"name": "Tom"}); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := prepareVariables(content, map[string]interface{}{})
+	var serviceErr *Error
+	if !errors.As(err, &serviceErr) || !reflect.DeepEqual(serviceErr.Details, []FieldError{{Field: "name", Message: "is required"}}) {
+		t.Fatalf("error = %v", err)
+	}
+}
+
+func TestValidateSchemaDefinition(t *testing.T) {
+	if err := validateSchemaDefinition(schemaContent(t).Schema); err != nil {
+		t.Fatalf("valid schema rejected: %v", err)
+	}
+
+	var schema models.VariableSchema
+	json.Unmarshal([]byte(`{
+		"type": "object",
+		"required": ["missing"],
+		"properties": {
+			"a": {"type": "strng"},
+			"b": {"type": "string", "format": "phone"},
+			"c": {"type": "string", "pattern": "("}
+		}
+	}`), &schema)
+
+	err := validateSchemaDefinition(&schema)
+	var serviceErr *Error
+	if !errors.As(err, &serviceErr) || serviceErr.Code != ErrCodeInvalidSchema {
+		t.Fatalf("error = %v, want %s", err, ErrCodeInvalidSchema)
+	}
+	var fields []string
+	for _, detail := range serviceErr.Details {
+		fields = append(fields, detail.Field)
+	}
+	if want := []string{"a", "b", "c", "missing"}; !reflect.DeepEqual(fields, want) {
+		t.Errorf("fields = %v, want %v", fields, want)
+	}
+
+	if err := validateSchemaDefinition(&models.VariableSchema{Type: models.SchemaTypeString}); err == nil {
+		t.Error("non-object root accepted")
+	}
+}
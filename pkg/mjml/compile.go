@@ -0,0 +1,455 @@
+package mjml
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// 컴포넌트별 기본 속성
+var defaults = map[string]map[string]string{
+	"mj-body": {
+		"width": "600px",
+	},
+	"mj-section": {
+		"padding":    "20px 0",
+		"text-align": "center",
+	},
+	"mj-column": {
+		"vertical-align": "top",
+	},
+	"mj-text": {
+		"align":       "left",
+		"color":       "#000000",
+		"font-family": "Ubuntu, Helvetica, Arial, sans-serif",
+		"font-size":   "13px",
+		"line-height": "1",
+		"padding":     "10px 25px",
+	},
+	"mj-button": {
+		"align":            "center",
+		"background-color": "#414141",
+		"border-radius":    "3px",
+		"color":            "#ffffff",
+		"font-family":      "Ubuntu, Helvetica, Arial, sans-serif",
+		"font-size":        "13px",
+		"font-weight":      "normal",
+		"inner-padding":    "10px 25px",
+		"line-height":      "120%",
+		"padding":          "10px 25px",
+	},
+	"mj-image": {
+		"align":   "center",
+		"padding": "10px 25px",
+	},
+	"mj-divider": {
+		"border-color": "#000000",
+		"border-style": "solid",
+		"border-width": "4px",
+		"padding":      "10px 25px",
+	},
+	"mj-spacer": {
+		"height": "20px",
+	},
+}
+
+// mj-column vertical-align에 허용하는 값
+// 값이 Outlook용 mso 마크업(문자열 리터럴)에도 들어가므로 템플릿 액션이나 임의의 CSS는 받지 않는다.
+var verticalAligns = map[string]bool{"top": true, "middle": true, "bottom": true}
+
+// 템플릿 액션 ({{ ... }}, 여러 줄 포함)
+var actionPattern = regexp.MustCompile(`(?s)\{\{.*?\}\}`)
+
+type compiler struct {
+	out        strings.Builder
+	attributes map[string]map[string]string // mj-attributes로 지정한 태그별 기본값 (mj-all 포함)
+	title      string
+	styles     []string
+	columns    map[string]string // 반응형 클래스 -> 너비
+	bodyWidth  int
+}
+
+// Compile 컴포넌트 마크업(<mjml><mj-body><mj-section><mj-column>...)을 표 기반 HTML로 변환
+// Outlook용 고정 폭 표는 mso 템플릿 함수(조건부 주석)로 출력하므로 결과는 html/template으로 실행해야 한다.
+// 템플릿 액션은 그대로 유지된다.
+func Compile(src string) (string, error) {
+	// 속성 안의 액션에 따옴표가 있어도 파싱되도록 액션을 자리표시자로 바꿔 두었다가 복원
+	src, actions := protectActions(src)
+
+	root, err := parse(src)
+	if err != nil {
+		return "", err
+	}
+
+	var mjml *node
+	for _, child := range root.children {
+		if child.name == "mjml" {
+			mjml = child
+		} else if child.name != "" {
+			return "", errorAt(child, "root element must be <mjml>")
+		}
+	}
+	if mjml == nil {
+		return "", &Error{Line: 1, Column: 1, Message: "missing <mjml> root element"}
+	}
+
+	c := &compiler{
+		attributes: make(map[string]map[string]string),
+		columns:    make(map[string]string),
+	}
+
+	var body *node
+	for _, child := range mjml.children {
+		switch child.name {
+		case "mj-head":
+			if err := c.head(child); err != nil {
+				return "", err
+			}
+		case "mj-body":
+			body = child
+		case "":
+		default:
+			return "", errorAt(child, fmt.Sprintf("<%s> is not allowed in <mjml>", child.name))
+		}
+	}
+	if body == nil {
+		return "", errorAt(mjml, "missing <mj-body>")
+	}
+
+	if err := c.body(body); err != nil {
+		return "", err
+	}
+	return restoreActions(c.document(), actions), nil
+}
+
+// protectActions 템플릿 액션을 자리표시자로 치환
+func protectActions(src string) (string, []string) {
+	var actions []string
+	protected := actionPattern.ReplaceAllStringFunc(src, func(action string) string {
+		actions = append(actions, action)
+		return fmt.Sprintf("__mjml_action_%d__", len(actions)-1)
+	})
+	return protected, actions
+}
+
+var placeholderPattern = regexp.MustCompile(`__mjml_action_(\d+)__`)
+
+func restoreActions(s string, actions []string) string {
+	return placeholderPattern.ReplaceAllStringFunc(s, func(placeholder string) string {
+		i, _ := strconv.Atoi(placeholderPattern.FindStringSubmatch(placeholder)[1])
+		return actions[i]
+	})
+}
+
+func errorAt(n *node, message string) *Error {
+	return &Error{Line: n.line, Column: n.column, Message: message}
+}
+
+// attr 요소 속성 > mj-attributes의 태그 기본값 > mj-all > 내장 기본값 순으로 조회
+func (c *compiler) attr(n *node, key string) string {
+	if v, ok := n.attrs[key]; ok {
+		return v
+	}
+	if v, ok := c.attributes[n.name][key]; ok {
+		return v
+	}
+	if v, ok := c.attributes["mj-all"][key]; ok && key != "padding" {
+		return v
+	}
+	return defaults[n.name][key]
+}
+
+func (c *compiler) head(head *node) error {
+	for _, child := range head.children {
+		switch child.name {
+		case "mj-title":
+			c.title = child.content
+		case "mj-style":
+			c.styles = append(c.styles, child.content)
+		case "mj-attributes":
+			for _, a := range child.children {
+				if a.name == "" {
+					continue
+				}
+				if c.attributes[a.name] == nil {
+					c.attributes[a.name] = make(map[string]string)
+				}
+				for k, v := range a.attrs {
+					c.attributes[a.name][k] = v
+				}
+			}
+		case "mj-preview":
+			return errorAt(child, "<mj-preview> is not supported; use the template preheader field")
+		case "":
+		default:
+			return errorAt(child, fmt.Sprintf("<%s> is not allowed in <mj-head>", child.name))
+		}
+	}
+	return nil
+}
+
+func (c *compiler) body(body *node) error {
+	c.bodyWidth = pixels(c.attr(body, "width"), 600)
+
+	background := c.attr(body, "background-color")
+	c.printf(`<body style="word-spacing:normal;%s">`, styleProps("background-color", background))
+	c.printf(`<div style="%s">`, styleProps("background-color", background))
+	for _, child := range body.children {
+		switch child.name {
+		case "mj-section":
+			if err := c.section(child); err != nil {
+				return err
+			}
+		case "mj-raw":
+			c.out.WriteString(child.content)
+		case "":
+			c.out.WriteString(child.content)
+		default:
+			return errorAt(child, fmt.Sprintf("<%s> must be inside <mj-column>", child.name))
+		}
+	}
+	c.out.WriteString(`</div></body>`)
+	return nil
+}
+
+func (c *compiler) section(section *node) error {
+	background := c.attr(section, "background-color")
+	width := c.bodyWidth
+
+	c.mso(fmt.Sprintf(`<table align="center" border="0" cellpadding="0" cellspacing="0" role="presentation" style="width:%dpx;" width="%d"><tr><td style="line-height:0px;font-size:0px;mso-line-height-rule:exactly;">`, width, width))
+	c.printf(`<div style="margin:0px auto;max-width:%dpx;%s">`, width, styleProps("background", background, "background-color", background))
+	c.printf(`<table align="center" border="0" cellpadding="0" cellspacing="0" role="presentation" style="width:100%%;%s">`, styleProps("background", background, "background-color", background))
+	c.printf(`<tbody><tr><td style="direction:ltr;font-size:0px;%s">`, styleProps("padding", c.attr(section, "padding"), "text-align", c.attr(section, "text-align")))
+	c.mso(`<table role="presentation" border="0" cellpadding="0" cellspacing="0"><tr>`)
+
+	var columns []*node
+	for _, child := range section.children {
+		switch child.name {
+		case "mj-column":
+			columns = append(columns, child)
+		case "":
+		default:
+			return errorAt(child, fmt.Sprintf("<%s> must be inside <mj-column>", child.name))
+		}
+	}
+
+	for _, child := range section.children {
+		if child.name == "" {
+			c.out.WriteString(child.content)
+			continue
+		}
+		if err := c.column(child, len(columns)); err != nil {
+			return err
+		}
+	}
+
+	c.mso(`</tr></table>`)
+	c.out.WriteString(`</td></tr></tbody></table></div>`)
+	c.mso(`</td></tr></table>`)
+	return nil
+}
+
+func (c *compiler) column(column *node, siblings int) error {
+	percent := 100.0 / float64(siblings)
+	width := c.attr(column, "width")
+	className := ""
+	var pixelWidth int
+
+	switch {
+	case strings.HasSuffix(width, "px"):
+		pixelWidth = pixels(width, 0)
+		className = fmt.Sprintf("mj-column-px-%d", pixelWidth)
+		c.columns[className] = fmt.Sprintf("%dpx", pixelWidth)
+	default:
+		if strings.HasSuffix(width, "%") {
+			if p, err := strconv.ParseFloat(strings.TrimSuffix(width, "%"), 64); err == nil {
+				percent = p
+			}
+		}
+		pixelWidth = int(float64(c.bodyWidth) * percent / 100)
+		className = "mj-column-per-" + strings.ReplaceAll(strconv.FormatFloat(percent, 'f', -1, 64), ".", "-")
+		c.columns[className] = strconv.FormatFloat(percent, 'f', -1, 64) + "%"
+	}
+
+	valign := strings.ToLower(strings.TrimSpace(c.attr(column, "vertical-align")))
+	if !verticalAligns[valign] {
+		return errorAt(column, "vertical-align must be top, middle or bottom")
+	}
+	c.mso(fmt.Sprintf(`<td style="vertical-align:%s;width:%dpx;">`, valign, pixelWidth))
+	c.printf(`<div class="%s" style="font-size:0px;text-align:left;direction:ltr;display:inline-block;vertical-align:%s;width:100%%;">`, className, valign)
+	c.printf(`<table border="0" cellpadding="0" cellspacing="0" role="presentation" style="vertical-align:%s;%s" width="100%%"><tbody>`,
+		valign, styleProps("background-color", c.attr(column, "background-color")))
+
+	// 안쪽 요소가 쓸 수 있는 너비 (기본 좌우 여백 25px)
+	boxWidth := pixelWidth - 50
+	for _, child := range column.children {
+		if child.name == "" {
+			c.out.WriteString(child.content)
+			continue
+		}
+		if err := c.component(child, boxWidth); err != nil {
+			return err
+		}
+	}
+
+	c.out.WriteString(`</tbody></table></div>`)
+	c.mso(`</td>`)
+	return nil
+}
+
+func (c *compiler) component(n *node, boxWidth int) error {
+	if n.name == "mj-raw" {
+		c.out.WriteString(n.content)
+		return nil
+	}
+	if _, ok := defaults[n.name]; !ok || n.name == "mj-body" || n.name == "mj-section" || n.name == "mj-column" {
+		return errorAt(n, fmt.Sprintf("<%s> is not allowed in <mj-column>", n.name))
+	}
+
+	align := c.attr(n, "align")
+	c.printf(`<tr><td %sstyle="font-size:0px;%sword-break:break-word;"%s>`,
+		attrIf("align", align), styleProps("padding", c.attr(n, "padding")), attrIf("class", c.attr(n, "css-class")))
+
+	switch n.name {
+	case "mj-text":
+		c.printf(`<div style="%s">%s</div>`, styleProps(
+			"font-family", c.attr(n, "font-family"),
+			"font-size", c.attr(n, "font-size"),
+			"font-weight", c.attr(n, "font-weight"),
+			"line-height", c.attr(n, "line-height"),
+			"text-align", align,
+			"color", c.attr(n, "color"),
+		), n.content)
+
+	case "mj-button":
+		background := c.attr(n, "background-color")
+		radius := c.attr(n, "border-radius")
+		c.printf(`<table border="0" cellpadding="0" cellspacing="0" role="presentation" style="border-collapse:separate;line-height:100%%;"><tbody><tr>`)
+		c.printf(`<td align="center" bgcolor="%s" role="presentation" style="border:none;cursor:auto;%s" valign="middle">`,
+			escapeAttr(background), styleProps("border-radius", radius, "mso-padding-alt", c.attr(n, "inner-padding"), "background", background))
+		c.printf(`<a %sstyle="display:inline-block;%smargin:0;text-decoration:none;text-transform:none;mso-padding-alt:0px;" target="_blank">%s</a>`,
+			attrIf("href", c.attr(n, "href")), styleProps(
+				"background", background,
+				"color", c.attr(n, "color"),
+				"font-family", c.attr(n, "font-family"),
+				"font-size", c.attr(n, "font-size"),
+				"font-weight", c.attr(n, "font-weight"),
+				"line-height", c.attr(n, "line-height"),
+				"padding", c.attr(n, "inner-padding"),
+				"border-radius", radius,
+			), n.content)
+		c.out.WriteString(`</td></tr></tbody></table>`)
+
+	case "mj-image":
+		width := pixels(c.attr(n, "width"), boxWidth)
+		if width > boxWidth && boxWidth > 0 {
+			width = boxWidth
+		}
+		c.printf(`<table border="0" cellpadding="0" cellspacing="0" role="presentation" style="border-collapse:collapse;border-spacing:0px;"><tbody><tr><td style="width:%dpx;">`, width)
+		href := c.attr(n, "href")
+		if href != "" {
+			c.printf(`<a href="%s" target="_blank">`, escapeAttr(href))
+		}
+		c.printf(`<img alt="%s" src="%s" style="border:0;display:block;outline:none;text-decoration:none;height:auto;width:100%%;font-size:13px;" width="%d" height="auto">`,
+			escapeAttr(c.attr(n, "alt")), escapeAttr(c.attr(n, "src")), width)
+		if href != "" {
+			c.out.WriteString(`</a>`)
+		}
+		c.out.WriteString(`</td></tr></tbody></table>`)
+
+	case "mj-divider":
+		border := fmt.Sprintf("border-top:%s %s %s;", c.attr(n, "border-style"), c.attr(n, "border-width"), c.attr(n, "border-color"))
+		c.printf(`<p style="%sfont-size:1px;margin:0px auto;width:100%%;"></p>`, escapeAttr(border))
+		c.mso(fmt.Sprintf(`<table align="center" border="0" cellpadding="0" cellspacing="0" style="%sfont-size:1px;margin:0px auto;width:%dpx;" role="presentation" width="%dpx"><tr><td style="height:0;line-height:0;"> &nbsp;</td></tr></table>`,
+			html.EscapeString(border), boxWidth, boxWidth))
+
+	case "mj-spacer":
+		height := c.attr(n, "height")
+		c.printf(`<div style="%s">&#8202;</div>`, styleProps("height", height, "line-height", height))
+	}
+
+	c.out.WriteString(`</td></tr>`)
+	return nil
+}
+
+// document 머리말과 반응형 스타일을 붙여 전체 문서 완성
+func (c *compiler) document() string {
+	var b strings.Builder
+	b.WriteString(`<!doctype html><html xmlns="http://www.w3.org/1999/xhtml" xmlns:v="urn:schemas-microsoft-com:vml" xmlns:o="urn:schemas-microsoft-com:office:office"><head>`)
+	if c.title != "" {
+		b.WriteString("<title>" + c.title + "</title>")
+	}
+	b.WriteString(`<meta http-equiv="X-UA-Compatible" content="IE=edge"><meta http-equiv="Content-Type" content="text/html; charset=UTF-8"><meta name="viewport" content="width=device-width, initial-scale=1">`)
+	b.WriteString(msoCall(`<noscript><xml><o:OfficeDocumentSettings><o:AllowPNG/><o:PixelsPerInch>96</o:PixelsPerInch></o:OfficeDocumentSettings></xml></noscript>`))
+	// 기본 스타일과 반응형 스타일은 인라인하지 않고 <style>에 남긴다 (data-embed)
+	b.WriteString(`<style type="text/css" data-embed>#outlook a{padding:0;}body{margin:0;padding:0;-webkit-text-size-adjust:100%;-ms-text-size-adjust:100%;}table,td{border-collapse:collapse;mso-table-lspace:0pt;mso-table-rspace:0pt;}img{border:0;height:auto;line-height:100%;outline:none;text-decoration:none;-ms-interpolation-mode:bicubic;}p{display:block;margin:13px 0;}</style>`)
+
+	// 넓은 화면에서만 열을 나란히 배치 (좁은 화면에서는 100%로 쌓인다)
+	if len(c.columns) > 0 {
+		names := make([]string, 0, len(c.columns))
+		for name := range c.columns {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		b.WriteString(`<style type="text/css" data-embed>@media only screen and (min-width:480px){`)
+		for _, name := range names {
+			fmt.Fprintf(&b, ".%s{width:%s !important;max-width:%s;}", name, c.columns[name], c.columns[name])
+		}
+		b.WriteString(`}</style>`)
+	}
+	for _, style := range c.styles {
+		b.WriteString(`<style type="text/css">` + style + `</style>`)
+	}
+	b.WriteString(`</head>`)
+	b.WriteString(c.out.String())
+	b.WriteString(`</html>`)
+	return b.String()
+}
+
+func (c *compiler) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&c.out, format, args...)
+}
+
+// mso Outlook 전용 마크업 출력 (html/template은 주석을 제거하므로 mso 템플릿 함수 호출로 남긴다)
+func (c *compiler) mso(markup string) {
+	c.out.WriteString(msoCall(markup))
+}
+
+func msoCall(markup string) string {
+	return "{{mso " + strconv.Quote(markup) + "}}"
+}
+
+// styleProps 값이 있는 CSS 속성만 "key:value;" 형식으로 연결
+func styleProps(pairs ...string) string {
+	var b strings.Builder
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if pairs[i+1] != "" {
+			b.WriteString(pairs[i] + ":" + escapeAttr(pairs[i+1]) + ";")
+		}
+	}
+	return b.String()
+}
+
+func attrIf(key, value string) string {
+	if value == "" {
+		return ""
+	}
+	return key + `="` + escapeAttr(value) + `" `
+}
+
+// escapeAttr 속성 값 이스케이프 (템플릿 액션은 자리표시자로 바뀌어 있으므로 영향을 받지 않는다)
+func escapeAttr(s string) string {
+	return html.EscapeString(s)
+}
+
+// pixels "600px" 형식의 값을 정수로 (해석할 수 없으면 기본값)
+func pixels(value string, fallback int) int {
+	n, err := strconv.Atoi(strings.TrimSuffix(strings.TrimSpace(value), "px"))
+	if err != nil {
+		return fallback
+	}
+	return n
+}
@@ -0,0 +1,78 @@
+package mjml
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/kihyun1998/prisma-market/prisma-email-service/pkg/render"
+)
+
+func TestCompileOutputRenders(t *testing.T) {
+	html, err := Compile(`<mjml><mj-body><mj-section><mj-column>
+<mj-text>Hello {{.name}}</mj-text>
+<mj-button href="{{.url}}">Go</mj-button>
+</mj-column></mj-section></mj-body></mjml>`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 컴파일 결과의 mso 호출은 문자열 리터럴이므로 저장 시 검사를 통과해야 한다
+	if err := render.CheckHTML(html); err != nil {
+		t.Fatalf("CheckHTML() error = %v", err)
+	}
+
+	tmpl, err := render.Compile(&render.Source{Subject: "s", HTML: html})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rendered, err := tmpl.Execute(map[string]interface{}{"name": "<b>Tom</b>", "url": "https://x.example/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"Hello &lt;b&gt;Tom&lt;/b&gt;", `href="https://x.example/"`, "<!--[if mso"} {
+		if !strings.Contains(rendered.HTML, want) {
+			t.Errorf("rendered HTML missing %q", want)
+		}
+	}
+}
+
+func TestCompileColumnVerticalAlign(t *testing.T) {
+	tests := []struct {
+		name    string
+		valign  string
+		want    string
+		wantErr bool
+	}{
+		{name: "default", want: "top"},
+		{name: "allowed", valign: ` vertical-align="Middle"`, want: "middle"},
+		// mso 마크업은 문자열 리터럴로 출력되므로 속성 값이 그대로 들어가면 안 된다
+		{name: "markup", valign: ` vertical-align="top;&quot;&gt;&lt;script&gt;alert(1)&lt;/script&gt;"`, wantErr: true},
+		{name: "template action", valign: ` vertical-align="{{.align}}"`, wantErr: true},
+		{name: "unknown value", valign: ` vertical-align="baseline"`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			html, err := Compile(`<mjml><mj-body><mj-section><mj-column` + tt.valign + `><mj-text>Hi</mj-text></mj-column></mj-section></mj-body></mjml>`)
+			if tt.wantErr {
+				var compileErr *Error
+				if !errors.As(err, &compileErr) || compileErr.Line != 1 {
+					t.Fatalf("error = %v, want a positioned compile error", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range []string{`<td style=\"vertical-align:` + tt.want + `;width:`, `display:inline-block;vertical-align:` + tt.want + `;`} {
+				if !strings.Contains(html, want) {
+					t.Errorf("compiled HTML missing %q", want)
+				}
+			}
+			if err := render.CheckHTML(html); err != nil {
+				t.Errorf("CheckHTML() error = %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package mjml
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Error 컴포넌트 마크업 오류 위치
+type Error struct {
+	Line    int
+	Column  int
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Message)
+}
+
+// node 컴포넌트 트리 노드
+type node struct {
+	name     string // 태그 이름 (텍스트 노드는 빈 문자열)
+	attrs    map[string]string
+	children []*node
+	content  string // mj-text/mj-button 등은 내부 HTML 원문, 텍스트 노드는 문자열
+	line     int
+	column   int
+}
+
+// 내부를 HTML 원문 그대로 사용하는 컴포넌트
+var rawContent = map[string]bool{
+	"mj-text":   true,
+	"mj-button": true,
+	"mj-raw":    true,
+	"mj-title":  true,
+	"mj-style":  true,
+}
+
+// parse 마크업을 컴포넌트 트리로 파싱
+// 본문 HTML(&nbsp;, <br> 등)을 허용하도록 느슨한 XML 모드를 사용한다.
+func parse(src string) (*node, error) {
+	dec := xml.NewDecoder(strings.NewReader(src))
+	dec.Strict = false
+	dec.AutoClose = xml.HTMLAutoClose
+	dec.Entity = xml.HTMLEntity
+
+	root := &node{name: "#document"}
+	stack := []*node{root}
+
+	for {
+		line, column := dec.InputPos()
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, syntaxError(dec, err)
+		}
+
+		parent := stack[len(stack)-1]
+		switch t := tok.(type) {
+		case xml.StartElement:
+			n := &node{name: strings.ToLower(t.Name.Local), attrs: make(map[string]string), line: line, column: column}
+			for _, a := range t.Attr {
+				n.attrs[strings.ToLower(a.Name.Local)] = a.Value
+			}
+			parent.children = append(parent.children, n)
+
+			if rawContent[n.name] {
+				content, err := readRaw(dec, src, n.name)
+				if err != nil {
+					return nil, err
+				}
+				n.content = content
+				continue
+			}
+			stack = append(stack, n)
+
+		case xml.EndElement:
+			name := strings.ToLower(t.Name.Local)
+			if len(stack) == 1 || stack[len(stack)-1].name != name {
+				return nil, &Error{Line: line, Column: column, Message: fmt.Sprintf("unexpected closing tag </%s>", name)}
+			}
+			stack = stack[:len(stack)-1]
+
+		case xml.CharData:
+			// 컴포넌트 사이의 템플릿 액션({{if}} 등)은 그대로 출력한다
+			if text := string(t); strings.TrimSpace(text) != "" {
+				parent.children = append(parent.children, &node{content: text, line: line, column: column})
+			}
+		}
+	}
+
+	if len(stack) > 1 {
+		open := stack[len(stack)-1]
+		return nil, &Error{Line: open.line, Column: open.column, Message: fmt.Sprintf("<%s> is not closed", open.name)}
+	}
+	return root, nil
+}
+
+// readRaw 현재 요소의 닫는 태그까지 원문을 읽는다
+func readRaw(dec *xml.Decoder, src, name string) (string, error) {
+	start := int(dec.InputOffset())
+	depth := 1
+	for depth > 0 {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			line, column := dec.InputPos()
+			return "", &Error{Line: line, Column: column, Message: fmt.Sprintf("<%s> is not closed", name)}
+		}
+		if err != nil {
+			return "", syntaxError(dec, err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if strings.EqualFold(t.Name.Local, name) {
+				depth++
+			}
+		case xml.EndElement:
+			if strings.EqualFold(t.Name.Local, name) {
+				depth--
+			}
+		}
+	}
+
+	end := int(dec.InputOffset())
+	inner := src[start:end]
+	if i := strings.LastIndex(inner, "</"); i >= 0 {
+		inner = inner[:i]
+	}
+	return strings.TrimSpace(inner), nil
+}
+
+func syntaxError(dec *xml.Decoder, err error) error {
+	var xmlErr *xml.SyntaxError
+	if errors.As(err, &xmlErr) {
+		return &Error{Line: xmlErr.Line, Column: 1, Message: xmlErr.Msg}
+	}
+	line, column := dec.InputPos()
+	return &Error{Line: line, Column: column, Message: err.Error()}
+}
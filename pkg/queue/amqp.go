@@ -0,0 +1,212 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+// 지연 발행용 대기 큐 접미사 (뒤에 대기 시간(ms)을 붙여 큐마다 TTL이 하나가 되도록 한다)
+const amqpDelaySuffix = "_delay_"
+
+// AMQPBroker RabbitMQ 브로커 (토픽 = 기본 exchange로 라우팅되는 durable 큐)
+type AMQPBroker struct {
+	conn      *amqp.Connection
+	channel   *amqp.Channel
+	publisher *ConfirmPublisher
+
+	mu       sync.Mutex
+	declared map[string]bool
+}
+
+func NewAMQPBroker(url string, confirmTimeout time.Duration) (*AMQPBroker, error) {
+	conn, err := amqp.Dial(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to RabbitMQ: %v", err)
+	}
+
+	ch, err := conn.Channel()
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to open channel: %v", err)
+	}
+
+	// 발행은 confirm 모드인 별도 채널에서 수행
+	publisher, err := NewConfirmPublisher(conn, confirmTimeout)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return &AMQPBroker{
+		conn:      conn,
+		channel:   ch,
+		publisher: publisher,
+		declared:  make(map[string]bool),
+	}, nil
+}
+
+// declare 큐 선언 (한 번만 수행)
+func (b *AMQPBroker) declare(name string, args amqp.Table) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.declared[name] {
+		return nil
+	}
+
+	_, err := b.channel.QueueDeclare(
+		name,
+		true,  // durable
+		false, // delete when unused
+		false, // exclusive
+		false, // no-wait
+		args,  // arguments
+	)
+	if err != nil {
+		return fmt.Errorf("failed to declare queue: %v", err)
+	}
+
+	b.declared[name] = true
+	return nil
+}
+
+func (b *AMQPBroker) Subscribe(ctx context.Context, topic string, prefetch int) (<-chan Delivery, error) {
+	if err := b.declare(topic, nil); err != nil {
+		return nil, err
+	}
+
+	// 동시에 처리 중인 메시지 수 제한
+	if err := b.channel.Qos(prefetch, 0, false); err != nil {
+		return nil, fmt.Errorf("failed to set QoS: %v", err)
+	}
+
+	tag := fmt.Sprintf("email-service-%d", time.Now().UnixNano())
+	msgs, err := b.channel.Consume(
+		topic,
+		tag,   // consumer
+		false, // auto-ack
+		false, // exclusive
+		false, // no-local
+		false, // no-wait
+		nil,   // args
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to register a consumer: %v", err)
+	}
+
+	// 컨텍스트 종료 시 소비 취소 - 브로커가 msgs 채널을 닫는다
+	go func() {
+		<-ctx.Done()
+		if err := b.channel.Cancel(tag, false); err != nil {
+			log.Printf("Error cancelling consumer: %v", err)
+		}
+	}()
+
+	out := make(chan Delivery)
+	go func() {
+		defer close(out)
+		for msg := range msgs {
+			out <- amqpDelivery(msg)
+		}
+	}()
+
+	return out, nil
+}
+
+func amqpDelivery(msg amqp.Delivery) Delivery {
+	headers := make(map[string]string, len(msg.Headers))
+	for k, v := range msg.Headers {
+		// 대기 큐에서 만료될 때 브로커가 붙이는 헤더는 다시 발행하지 않는다 (x-death는 배열이어야 한다)
+		if k == "x-death" || strings.HasPrefix(k, "x-first-death-") || strings.HasPrefix(k, "x-last-death-") {
+			continue
+		}
+		switch v := v.(type) {
+		case []byte:
+			headers[k] = string(v)
+		default:
+			headers[k] = fmt.Sprint(v)
+		}
+	}
+
+	return Delivery{
+		Message: Message{
+			ID:            msg.MessageId,
+			CorrelationID: msg.CorrelationId,
+			ContentType:   msg.ContentType,
+			Headers:       headers,
+			Body:          msg.Body,
+		},
+		ack: func() error {
+			return msg.Ack(false)
+		},
+		nack: func(requeue bool) error {
+			return msg.Nack(false, requeue)
+		},
+	}
+}
+
+func (b *AMQPBroker) Publish(ctx context.Context, topic string, msg Message) error {
+	if err := b.declare(topic, nil); err != nil {
+		return err
+	}
+	return b.publish(ctx, topic, msg)
+}
+
+// PublishDelayed TTL이 있는 대기 큐에 발행해 delay 뒤에 topic 큐로 옮겨지게 한다
+// 대기 큐에는 소비자가 없고, 만료된 메시지는 dead letter exchange(기본 exchange)를 통해 topic으로 라우팅된다.
+func (b *AMQPBroker) PublishDelayed(ctx context.Context, topic string, msg Message, delay time.Duration) error {
+	if err := b.declare(topic, nil); err != nil {
+		return err
+	}
+
+	ttl := delay.Milliseconds()
+	if ttl <= 0 {
+		return b.publish(ctx, topic, msg)
+	}
+
+	delayQueue := fmt.Sprintf("%s%s%d", topic, amqpDelaySuffix, ttl)
+	if err := b.declare(delayQueue, amqp.Table{
+		"x-message-ttl":             ttl,
+		"x-dead-letter-exchange":    "",
+		"x-dead-letter-routing-key": topic,
+	}); err != nil {
+		return err
+	}
+	return b.publish(ctx, delayQueue, msg)
+}
+
+// publish 선언된 큐에 발행하고 브로커 확인을 기다린다
+func (b *AMQPBroker) publish(ctx context.Context, queueName string, msg Message) error {
+	headers := make(amqp.Table, len(msg.Headers))
+	for k, v := range msg.Headers {
+		headers[k] = v
+	}
+
+	return b.publisher.PublishWithConfirm(ctx, queueName, amqp.Publishing{
+		Headers:       headers,
+		ContentType:   msg.ContentType,
+		MessageId:     msg.ID,
+		CorrelationId: msg.CorrelationID,
+		DeliveryMode:  amqp.Persistent,
+		Body:          msg.Body,
+	})
+}
+
+func (b *AMQPBroker) Close() error {
+	if err := b.publisher.Close(); err != nil {
+		return fmt.Errorf("error closing publish channel: %v", err)
+	}
+	if err := b.channel.Close(); err != nil {
+		return fmt.Errorf("error closing channel: %v", err)
+	}
+	if err := b.conn.Close(); err != nil {
+		return fmt.Errorf("error closing connection: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// 지원하는 브로커 종류
+const (
+	BackendAMQP   = "amqp"
+	BackendKafka  = "kafka"
+	BackendNATS   = "nats"
+	BackendRedis  = "redis"
+	BackendMemory = "memory"
+)
+
+// 브로커 공통 헤더 키
+const (
+	HeaderRetryCount       = "x-retry-count"
+	HeaderRetryAt          = "x-retry-at" // 재시도 가능 시각 (unix milli)
+	HeaderDeadLetterReason = "x-dead-letter-reason"
+	HeaderDeadLetterCode   = "x-dead-letter-code"
+	HeaderDeadLetterField  = "x-dead-letter-field"
+	HeaderOriginalTopic    = "x-original-topic"
+	HeaderMessageID        = "x-message-id"
+	HeaderCorrelationID    = "x-correlation-id"
+	HeaderContentType      = "content-type"
+)
+
+// DeadLetterSuffix 데드레터 토픽 접미사
+const DeadLetterSuffix = "_dead_letter"
+
+// Message 브로커 중립 메시지
+type Message struct {
+	ID            string
+	CorrelationID string
+	ContentType   string
+	Headers       map[string]string
+	Body          []byte
+}
+
+// Delivery 소비자가 수신한 메시지
+type Delivery struct {
+	Message
+	ack  func() error
+	nack func(requeue bool) error
+
+	// 같은 메시지를 delay 뒤에 다시 전달 (브로커가 지원하면 설정, 재시도 횟수는 전달 횟수로 계산)
+	nackDelay func(delay time.Duration) error
+}
+
+// Ack 처리 완료 확인
+func (d Delivery) Ack() error {
+	return d.ack()
+}
+
+// Nack 처리 실패 - requeue가 true면 브로커에 다시 돌려보낸다
+func (d Delivery) Nack(requeue bool) error {
+	return d.nack(requeue)
+}
+
+// Publisher 메시지 발행 인터페이스
+// Publish는 브로커가 메시지를 저장했다고 확인한 뒤에만 nil을 반환한다.
+type Publisher interface {
+	Publish(ctx context.Context, topic string, msg Message) error
+	Close() error
+}
+
+// Subscriber 메시지 구독 인터페이스
+// ctx가 끝나면 새 메시지 수신을 멈추고 채널을 닫는다. 이미 전달된 메시지는 Close 전까지 ack 할 수 있다.
+// prefetch는 브로커 QoS(AMQP, NATS) 또는 미리 읽어 둘 양(Kafka, Redis)에 사용한다. 받아 둔 메시지 수의 한도는 Consumer가 지킨다.
+type Subscriber interface {
+	Subscribe(ctx context.Context, topic string, prefetch int) (<-chan Delivery, error)
+	Close() error
+}
+
+// DelayedPublisher delay가 지난 뒤에 소비자에게 전달되도록 발행할 수 있는 브로커
+// 지원하지 않는 브로커의 재시도 메시지는 재시도 시각을 헤더에 기록하고, 소비자가 그 시각까지 워커에 넘기지 않고 보관한다.
+type DelayedPublisher interface {
+	PublishDelayed(ctx context.Context, topic string, msg Message, delay time.Duration) error
+}
+
+// Broker 발행과 구독을 모두 지원하는 메시지 브로커
+type Broker interface {
+	Publisher
+	Subscriber
+}
+
+// BrokerConfig 브로커 연결 설정
+type BrokerConfig struct {
+	Backend       string
+	ConsumerGroup string // Kafka 그룹 ID, NATS durable 이름, Redis 그룹 이름
+
+	AMQPURL            string
+	AMQPConfirmTimeout time.Duration
+	KafkaBrokers       []string
+	NATSURL            string
+	RedisAddr          string
+	RedisPassword      string
+	RedisDB            int
+}
+
+// NewBroker 설정에 맞는 브로커 생성
+func NewBroker(cfg BrokerConfig) (Broker, error) {
+	switch strings.ToLower(cfg.Backend) {
+	case "", BackendAMQP:
+		return NewAMQPBroker(cfg.AMQPURL, cfg.AMQPConfirmTimeout)
+	case BackendKafka:
+		return NewKafkaBroker(cfg.KafkaBrokers, cfg.ConsumerGroup)
+	case BackendNATS:
+		return NewNATSBroker(cfg.NATSURL, cfg.ConsumerGroup)
+	case BackendRedis:
+		return NewRedisBroker(cfg.RedisAddr, cfg.RedisPassword, cfg.RedisDB, cfg.ConsumerGroup)
+	case BackendMemory:
+		return NewMemoryBroker(0), nil
+	default:
+		return nil, fmt.Errorf("unsupported queue backend: %s", cfg.Backend)
+	}
+}
+
+// flattenHeaders 별도 속성 필드가 없는 브로커를 위해 메시지 속성을 헤더로 합친다
+func flattenHeaders(msg Message) map[string]string {
+	headers := make(map[string]string, len(msg.Headers)+3)
+	for k, v := range msg.Headers {
+		headers[k] = v
+	}
+	if msg.ID != "" {
+		headers[HeaderMessageID] = msg.ID
+	}
+	if msg.CorrelationID != "" {
+		headers[HeaderCorrelationID] = msg.CorrelationID
+	}
+	if msg.ContentType != "" {
+		headers[HeaderContentType] = msg.ContentType
+	}
+	return headers
+}
+
+// restoreMessage flattenHeaders의 역변환
+func restoreMessage(headers map[string]string, body []byte) Message {
+	msg := Message{
+		ID:            headers[HeaderMessageID],
+		CorrelationID: headers[HeaderCorrelationID],
+		ContentType:   headers[HeaderContentType],
+		Headers:       make(map[string]string, len(headers)),
+		Body:          body,
+	}
+	for k, v := range headers {
+		switch k {
+		case HeaderMessageID, HeaderContentType:
+			continue
+		}
+		msg.Headers[k] = v
+	}
+	return msg
+}
@@ -0,0 +1,138 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+// 발행 확인 실패 에러
+var (
+	ErrPublishNacked         = errors.New("broker rejected published message")
+	ErrPublishConfirmTimeout = errors.New("timed out waiting for publish confirmation")
+	ErrPublisherClosed       = errors.New("publisher channel closed")
+)
+
+// 기본 발행 확인 대기 시간
+const defaultConfirmTimeout = 5 * time.Second
+
+// ConfirmPublisher confirm 모드 채널로 발행하고 브로커 확인을 delivery tag 단위로 기다리는 AMQP 발행자
+type ConfirmPublisher struct {
+	channel *amqp.Channel
+	timeout time.Duration
+
+	mu      sync.Mutex
+	nextTag uint64
+	pending map[uint64]chan bool
+	closed  bool
+}
+
+func NewConfirmPublisher(conn *amqp.Connection, timeout time.Duration) (*ConfirmPublisher, error) {
+	if timeout <= 0 {
+		timeout = defaultConfirmTimeout
+	}
+
+	ch, err := conn.Channel()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open publish channel: %v", err)
+	}
+
+	if err := ch.Confirm(false); err != nil {
+		ch.Close()
+		return nil, fmt.Errorf("failed to enable publisher confirms: %v", err)
+	}
+
+	p := &ConfirmPublisher{
+		channel: ch,
+		timeout: timeout,
+		pending: make(map[uint64]chan bool),
+	}
+
+	confirms := ch.NotifyPublish(make(chan amqp.Confirmation, 256))
+	go p.listen(confirms)
+
+	return p, nil
+}
+
+// listen 브로커 확인을 대기 중인 발행에 전달
+func (p *ConfirmPublisher) listen(confirms <-chan amqp.Confirmation) {
+	for confirm := range confirms {
+		p.mu.Lock()
+		result, ok := p.pending[confirm.DeliveryTag]
+		delete(p.pending, confirm.DeliveryTag)
+		p.mu.Unlock()
+
+		if ok {
+			result <- confirm.Ack
+		}
+	}
+
+	// 채널이 닫히면 확인받지 못한 발행은 모두 실패 처리
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	for tag, result := range p.pending {
+		close(result)
+		delete(p.pending, tag)
+	}
+}
+
+// PublishWithConfirm 발행 후 브로커가 ack 할 때까지 대기
+func (p *ConfirmPublisher) PublishWithConfirm(ctx context.Context, queueName string, publishing amqp.Publishing) error {
+	result := make(chan bool, 1)
+
+	// 발행 순서가 곧 delivery tag이므로 태그 할당과 발행을 함께 잠근다
+	// 채널은 발행에 성공했을 때만 태그를 증가시키므로 성공한 뒤에 태그를 할당한다
+	// (확인은 listen이 같은 잠금을 얻은 뒤에 처리되므로 등록 전에 도착하지 않는다)
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return ErrPublisherClosed
+	}
+	var tag uint64
+	err := p.channel.Publish("", queueName, false, false, publishing)
+	if err == nil {
+		p.nextTag++
+		tag = p.nextTag
+		p.pending[tag] = result
+	}
+	p.mu.Unlock()
+
+	if err != nil {
+		return fmt.Errorf("failed to publish message: %v", err)
+	}
+
+	timer := time.NewTimer(p.timeout)
+	defer timer.Stop()
+
+	select {
+	case ack, ok := <-result:
+		if !ok {
+			return ErrPublisherClosed
+		}
+		if !ack {
+			return ErrPublishNacked
+		}
+		return nil
+	case <-timer.C:
+		p.forget(tag)
+		return ErrPublishConfirmTimeout
+	case <-ctx.Done():
+		p.forget(tag)
+		return ctx.Err()
+	}
+}
+
+func (p *ConfirmPublisher) forget(tag uint64) {
+	p.mu.Lock()
+	delete(p.pending, tag)
+	p.mu.Unlock()
+}
+
+func (p *ConfirmPublisher) Close() error {
+	return p.channel.Close()
+}
@@ -2,137 +2,379 @@ package queue
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
+	"errors"
+	"hash/fnv"
 	"log"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/kihyun1998/prisma-market/prisma-email-service/internal/models"
-	"github.com/streadway/amqp"
 )
 
+// ConsumerOptions 소비자 동시성 설정
+type ConsumerOptions struct {
+	Workers          int           // 동시에 메시지를 처리할 워커 수
+	Prefetch         int           // ack 하지 않은 채 받아 둘 수 있는 메시지 수 (처리 중 + 재시도 대기, 브로커 QoS에도 전달)
+	OrderByRecipient bool          // 같은 수신자의 메시지는 같은 워커에서 순서대로 처리
+	MessageTimeout   time.Duration // 메시지 하나의 처리 제한 시간 (0이면 제한 없음)
+	RetryDelay       time.Duration // 재시도 메시지를 다시 처리하기까지 대기 시간 (브로커에서 지연, 워커는 대기하지 않는다)
+}
+
+// Consumer 브로커 종류와 무관하게 동일한 재시도/데드레터 규칙으로 메시지를 처리한다
 type Consumer struct {
-	conn       *amqp.Connection
-	channel    *amqp.Channel
-	queue      amqp.Queue
+	broker     Broker
+	topic      string
 	processor  MessageProcessor
 	maxRetries int
+	options    ConsumerOptions
+
+	wg            sync.WaitGroup
+	stopConsuming context.CancelFunc
+
+	// 받은 뒤 아직 ack/nack 하지 않은 메시지 자리 (Prefetch개)
+	// prefetch를 지원하지 않는 브로커(Memory, Redis, Kafka)도 같은 한도를 지키도록 소비자가 직접 센다.
+	slots chan struct{}
+
+	// 처리 중인 메시지에 전달되는 컨텍스트 (종료 대기 시간 초과 시 취소)
+	processCtx    context.Context
+	cancelProcess context.CancelFunc
 }
 
-func NewConsumer(url, queueName string, maxRetries int, processor MessageProcessor) (*Consumer, error) {
-	conn, err := amqp.Dial(url)
-	if err != nil {
-		return nil, fmt.Errorf("failed to connect to RabbitMQ: %v", err)
-	}
+// 처리 대기 중인 메시지
+type job struct {
+	delivery Delivery
+	envelope *Envelope
+	request  *models.EmailRequest
+}
 
-	ch, err := conn.Channel()
-	if err != nil {
-		return nil, fmt.Errorf("failed to open channel: %v", err)
-	}
-
-	// 큐 선언
-	q, err := ch.QueueDeclare(
-		queueName,
-		true,  // durable
-		false, // delete when unused
-		false, // exclusive
-		false, // no-wait
-		nil,   // arguments
-	)
-	if err != nil {
-		return nil, fmt.Errorf("failed to declare queue: %v", err)
-	}
-
-	// // 데드레터 큐 설정
-	// deadLetterQueue, err := ch.QueueDeclare(
-	// 	queueName+"_dead_letter",
-	// 	true,
-	// 	false,
-	// 	false,
-	// 	false,
-	// 	nil,
-	// )
-	// if err != nil {
-	// 	return nil, fmt.Errorf("failed to declare dead letter queue: %v", err)
-	// }
+func NewConsumer(broker Broker, topic string, maxRetries int, options ConsumerOptions, processor MessageProcessor) *Consumer {
+	if options.Workers < 1 {
+		options.Workers = 1
+	}
+	if options.Prefetch < options.Workers {
+		options.Prefetch = options.Workers
+	}
 
 	return &Consumer{
-		conn:       conn,
-		channel:    ch,
-		queue:      q,
+		broker:     broker,
+		topic:      topic,
 		processor:  processor,
 		maxRetries: maxRetries,
-	}, nil
+		options:    options,
+		slots:      make(chan struct{}, options.Prefetch),
+	}
 }
 
 func (c *Consumer) Start(ctx context.Context) error {
-	msgs, err := c.channel.Consume(
-		c.queue.Name,
-		"",    // consumer
-		false, // auto-ack
-		false, // exclusive
-		false, // no-local
-		false, // no-wait
-		nil,   // args
-	)
+	// 소비 중단과 처리 취소를 분리해 진행 중인 메시지가 마무리될 수 있도록 한다
+	c.processCtx, c.cancelProcess = context.WithCancel(context.WithoutCancel(ctx))
+
+	consumeCtx, stop := context.WithCancel(ctx)
+	msgs, err := c.broker.Subscribe(consumeCtx, c.topic, c.options.Prefetch)
 	if err != nil {
-		return fmt.Errorf("failed to register a consumer: %v", err)
+		stop()
+		return err
+	}
+	c.stopConsuming = stop
+
+	// 워커별 작업 채널 생성 (순서 보장 모드가 아니면 하나의 채널을 공유)
+	queues := make([]chan job, c.options.Workers)
+	for i := range queues {
+		if c.options.OrderByRecipient || i == 0 {
+			queues[i] = make(chan job)
+		} else {
+			queues[i] = queues[0]
+		}
+	}
+
+	for i := 0; i < c.options.Workers; i++ {
+		c.wg.Add(1)
+		go func(jobs <-chan job) {
+			defer c.wg.Done()
+			for j := range jobs {
+				c.handle(j)
+				<-c.slots
+			}
+		}(queues[i])
 	}
 
+	// 디스패처: 브로커가 전달을 멈추면 (보관 중인 재시도 메시지를 정리한 뒤) 작업 채널을 닫아 워커를 종료시킨다
+	// 빈 자리가 생길 때까지 다음 메시지를 받지 않으므로 처리 중인 메시지와 보관 중인 재시도 메시지는 합쳐서 Prefetch개를 넘지 않는다.
+	var held sync.WaitGroup
+	c.wg.Add(1)
 	go func() {
-		for msg := range msgs {
-			var emailReq models.EmailRequest
-			if err := json.Unmarshal(msg.Body, &emailReq); err != nil {
-				log.Printf("Error unmarshaling message: %v", err)
-				msg.Nack(false, false)
-				continue
+		defer c.wg.Done()
+		defer func() {
+			held.Wait()
+			closed := make(map[chan job]bool)
+			for _, q := range queues {
+				if !closed[q] {
+					close(q)
+					closed[q] = true
+				}
 			}
+		}()
 
-			// 재시도 횟수 확인
-			retryCount := 0
-			if xRetry, ok := msg.Headers["x-retry-count"].(int); ok {
-				retryCount = xRetry
+		for {
+			c.slots <- struct{}{}
+			msg, ok := <-msgs
+			if !ok {
+				<-c.slots
+				return
 			}
 
-			if err := c.processor.ProcessMessage(&emailReq); err != nil {
-				if retryCount >= c.maxRetries {
-					// 최대 재시도 횟수 초과 - 데드레터 큐로 이동
-					log.Printf("Message failed after %d retries: %v", retryCount, err)
-					msg.Nack(false, false)
-				} else {
-					// 재시도 큐에 메시지 다시 추가
-					retryCount++
-					headers := amqp.Table{"x-retry-count": retryCount}
-					c.channel.Publish(
-						"",
-						c.queue.Name,
-						false,
-						false,
-						amqp.Publishing{
-							Headers:     headers,
-							ContentType: "application/json",
-							Body:        msg.Body,
-							Expiration:  fmt.Sprintf("%d", int64(time.Second)*5), // 5초 후 재시도
-						},
-					)
-					msg.Ack(false)
-				}
+			emailReq, envelope, err := DecodeEmailRequest(msg.Body)
+			if err != nil {
+				log.Printf("Error decoding message: %v", err)
+				c.deadLetter(msg, retryCountFromHeaders(msg.Headers), err)
+				<-c.slots
 				continue
 			}
 
-			msg.Ack(false)
+			j := job{delivery: msg, envelope: envelope, request: emailReq}
+			jobs := queues[c.workerIndex(emailReq)]
+			if wait := retryWait(msg.Headers); wait > 0 {
+				held.Add(1)
+				go func() {
+					defer held.Done()
+					c.dispatchAt(consumeCtx, jobs, j, wait)
+				}()
+				continue
+			}
+			jobs <- j
 		}
 	}()
 
 	return nil
 }
 
-func (c *Consumer) Close() error {
-	if err := c.channel.Close(); err != nil {
-		return fmt.Errorf("error closing channel: %v", err)
+// workerIndex 메시지를 처리할 워커 선택
+func (c *Consumer) workerIndex(req *models.EmailRequest) int {
+	if !c.options.OrderByRecipient || c.options.Workers == 1 {
+		return 0
 	}
-	if err := c.conn.Close(); err != nil {
-		return fmt.Errorf("error closing connection: %v", err)
+
+	h := fnv.New32a()
+	h.Write([]byte(recipientKey(req)))
+	return int(h.Sum32() % uint32(c.options.Workers))
+}
+
+// recipientKey 순서 보장에 사용할 수신자 키
+func recipientKey(req *models.EmailRequest) string {
+	if len(req.To) == 0 {
+		return ""
 	}
-	return nil
+	return strings.ToLower(strings.TrimSpace(req.To[0]))
+}
+
+// handle 단일 메시지 처리 및 ack/재시도
+func (c *Consumer) handle(j job) {
+	msg := j.delivery
+
+	// 재시도 횟수 확인
+	retryCount := retryCountFromHeaders(msg.Headers)
+
+	md := metadataFromMessage(msg.Message, retryCount)
+	if md.MessageID == "" {
+		md.MessageID = j.envelope.ID
+	}
+	md.Producer = j.envelope.Producer
+
+	ctx := ContextWithMetadata(c.processCtx, md)
+	if c.options.MessageTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.options.MessageTimeout)
+		defer cancel()
+	}
+
+	if err := c.processor.ProcessMessage(ctx, j.request); err != nil {
+		var permanent *PermanentError
+		if errors.As(err, &permanent) {
+			// 요청 자체의 문제는 재시도하지 않는다
+			log.Printf("Message failed permanently: %v", err)
+			c.deadLetter(msg, retryCount, err)
+		} else if retryCount >= c.maxRetries {
+			// 최대 재시도 횟수 초과 - 데드레터 큐로 이동
+			log.Printf("Message failed after %d retries: %v", retryCount, err)
+			c.deadLetter(msg, retryCount, err)
+		} else {
+			// 재시도 큐에 메시지 다시 추가
+			c.retry(msg, retryCount+1)
+		}
+		return
+	}
+
+	msg.Ack()
+}
+
+// retryWait 헤더의 재시도 시각까지 남은 시간 (없거나 지났으면 0)
+func retryWait(headers map[string]string) time.Duration {
+	retryAt, err := strconv.ParseInt(headers[HeaderRetryAt], 10, 64)
+	if err != nil {
+		return 0
+	}
+	return time.Until(time.UnixMilli(retryAt))
+}
+
+// dispatchAt 재시도 시각까지 보관했다가 워커에 넘긴다 (지연 발행을 지원하지 않는 브로커용)
+// 보관 중에는 워커를 차지하지 않지만 prefetch 자리는 차지한다. 그 사이 소비를 멈추면 메시지를 브로커로 되돌리고 자리를 비운다.
+func (c *Consumer) dispatchAt(ctx context.Context, jobs chan<- job, j job, wait time.Duration) {
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		select {
+		case jobs <- j:
+			return
+		case <-ctx.Done():
+		}
+	case <-ctx.Done():
+	}
+
+	if err := j.delivery.Nack(true); err != nil {
+		log.Printf("Error requeueing delayed message: %v", err)
+	}
+	<-c.slots
+}
+
+// retry RetryDelay 뒤에 다시 처리되도록 브로커에 돌려보낸다
+// 같은 메시지를 지연 재전달할 수 있으면 그대로 돌려보내고, 아니면 재시도 횟수를 올려 같은 토픽에 다시 발행한다.
+// 원본 메시지는 재발행이 확인된 뒤에만 ack 하고, 실패하면 브로커로 되돌린다.
+func (c *Consumer) retry(msg Delivery, retryCount int) {
+	if msg.nackDelay != nil {
+		if err := msg.nackDelay(c.options.RetryDelay); err != nil {
+			log.Printf("Error scheduling message redelivery: %v", err)
+		}
+		return
+	}
+
+	retryMsg := copyMessage(msg.Message)
+	retryMsg.Headers[HeaderRetryCount] = strconv.Itoa(retryCount)
+	if _, ok := c.broker.(DelayedPublisher); !ok && c.options.RetryDelay > 0 {
+		retryMsg.Headers[HeaderRetryAt] = strconv.FormatInt(time.Now().Add(c.options.RetryDelay).UnixMilli(), 10)
+	}
+
+	c.republish(msg, c.topic, retryMsg, c.options.RetryDelay)
+}
+
+// deadLetter 실패 원인을 헤더에 기록해 데드레터 토픽으로 이동
+func (c *Consumer) deadLetter(msg Delivery, retryCount int, cause error) {
+	dlqMsg := copyMessage(msg.Message)
+	dlqMsg.Headers[HeaderRetryCount] = strconv.Itoa(retryCount)
+	dlqMsg.Headers[HeaderDeadLetterReason] = cause.Error()
+	dlqMsg.Headers[HeaderOriginalTopic] = c.topic
+	delete(dlqMsg.Headers, HeaderRetryAt)
+
+	// 디코딩 실패는 원인 필드까지 기록
+	var decodeErr *DecodeError
+	var permanent *PermanentError
+	if errors.As(cause, &decodeErr) {
+		dlqMsg.Headers[HeaderDeadLetterCode] = decodeErr.Code
+		if decodeErr.Field != "" {
+			dlqMsg.Headers[HeaderDeadLetterField] = decodeErr.Field
+		}
+	} else if errors.As(cause, &permanent) && permanent.Code != "" {
+		dlqMsg.Headers[HeaderDeadLetterCode] = permanent.Code
+	}
+
+	c.republish(msg, c.topic+DeadLetterSuffix, dlqMsg, 0)
+}
+
+// republish 새 메시지 발행이 확인되면 원본을 ack, 실패하면 원본을 requeue
+// delay가 있고 브로커가 지연 발행을 지원하면 delay 뒤에 전달되도록 발행한다.
+func (c *Consumer) republish(original Delivery, topic string, msg Message, delay time.Duration) {
+	var err error
+	if delayed, ok := c.broker.(DelayedPublisher); ok && delay > 0 {
+		err = delayed.PublishDelayed(c.processCtx, topic, msg, delay)
+	} else {
+		err = c.broker.Publish(c.processCtx, topic, msg)
+	}
+	if err != nil {
+		log.Printf("Error republishing message to %s, requeueing original: %v", topic, err)
+		if err := original.Nack(true); err != nil {
+			log.Printf("Error requeueing message: %v", err)
+		}
+		return
+	}
+
+	if err := original.Ack(); err != nil {
+		log.Printf("Error acknowledging message: %v", err)
+	}
+}
+
+// copyMessage 헤더를 복사한 새 메시지 생성
+func copyMessage(msg Message) Message {
+	headers := make(map[string]string, len(msg.Headers)+3)
+	for k, v := range msg.Headers {
+		headers[k] = v
+	}
+	msg.Headers = headers
+	if msg.ContentType == "" {
+		msg.ContentType = "application/json"
+	}
+	return msg
+}
+
+// retryCountFromHeaders 헤더의 재시도 횟수 추출
+func retryCountFromHeaders(headers map[string]string) int {
+	count, err := strconv.Atoi(headers[HeaderRetryCount])
+	if err != nil {
+		return 0
+	}
+	return count
+}
+
+// metadataFromMessage 메시지 속성과 헤더에서 메타데이터 추출
+func metadataFromMessage(msg Message, retryCount int) Metadata {
+	md := Metadata{
+		MessageID:     msg.ID,
+		CorrelationID: msg.CorrelationID,
+		RetryCount:    retryCount,
+	}
+	if md.CorrelationID == "" {
+		md.CorrelationID = msg.Headers[HeaderCorrelationID]
+	}
+	md.TraceParent = msg.Headers["traceparent"]
+	md.TraceState = msg.Headers["tracestate"]
+	return md
+}
+
+// Shutdown 소비를 멈추고 처리 중인 메시지가 끝날 때까지 기다린 뒤 연결 종료
+func (c *Consumer) Shutdown(ctx context.Context) error {
+	if c.stopConsuming != nil {
+		c.stopConsuming()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		c.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		// 대기 시간 초과 - 진행 중인 처리를 취소하고 정리될 때까지 잠시 기다린다
+		log.Printf("Timed out waiting for in-flight messages: %v", ctx.Err())
+		if c.cancelProcess != nil {
+			c.cancelProcess()
+		}
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+		}
+	}
+
+	if c.cancelProcess != nil {
+		c.cancelProcess()
+	}
+
+	return c.Close()
+}
+
+func (c *Consumer) Close() error {
+	return c.broker.Close()
 }
@@ -0,0 +1,267 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kihyun1998/prisma-market/prisma-email-service/internal/models"
+)
+
+// processorFunc 함수를 MessageProcessor로 사용
+type processorFunc func(ctx context.Context, req *models.EmailRequest) error
+
+func (f processorFunc) ProcessMessage(ctx context.Context, req *models.EmailRequest) error {
+	return f(ctx, req)
+}
+
+// attempt 처리 시도 기록
+type attempt struct {
+	name       string
+	retryCount int
+	at         time.Time
+}
+
+// recorder 처리 시도를 기록하고, 이름별로 지정한 횟수만큼 실패한다
+type recorder struct {
+	mu       sync.Mutex
+	attempts []attempt
+	failures map[string]error
+	done     chan struct{}
+}
+
+func newRecorder(failures map[string]error) *recorder {
+	return &recorder{failures: failures, done: make(chan struct{}, 100)}
+}
+
+func (r *recorder) ProcessMessage(ctx context.Context, req *models.EmailRequest) error {
+	md, _ := MetadataFromContext(ctx)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	defer func() { r.done <- struct{}{} }()
+
+	r.attempts = append(r.attempts, attempt{name: req.TemplateName, retryCount: md.RetryCount, at: time.Now()})
+	return r.failures[req.TemplateName]
+}
+
+func (r *recorder) wait(t *testing.T, n int) []attempt {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		select {
+		case <-r.done:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for attempt %d", i+1)
+		}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]attempt(nil), r.attempts...)
+}
+
+func publishRequest(t *testing.T, b Broker, topic, name string) {
+	t.Helper()
+	publishRequestWithHeaders(t, b, topic, name, map[string]string{})
+}
+
+func publishRequestWithHeaders(t *testing.T, b Broker, topic, name string, headers map[string]string) {
+	t.Helper()
+	envelope, err := NewEmailRequestEnvelope(&models.EmailRequest{TemplateName: name, To: []string{"a@example.com"}}, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := json.Marshal(envelope)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Publish(context.Background(), topic, Message{ID: envelope.ID, Headers: headers, Body: body}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func startConsumer(t *testing.T, b Broker, maxRetries int, options ConsumerOptions, p MessageProcessor) {
+	t.Helper()
+	c := NewConsumer(b, "emails", maxRetries, options, p)
+	if err := c.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		c.Shutdown(ctx)
+	})
+}
+
+func TestConsumerRetryDoesNotBlockWorker(t *testing.T) {
+	const delay = 300 * time.Millisecond
+	b := NewMemoryBroker(0)
+	r := newRecorder(map[string]error{"flaky": errors.New("smtp unavailable")})
+	startConsumer(t, b, 1, ConsumerOptions{Workers: 1, RetryDelay: delay}, r)
+
+	start := time.Now()
+	publishRequest(t, b, "emails", "flaky")
+	publishRequest(t, b, "emails", "other")
+
+	attempts := r.wait(t, 3)
+
+	// 재시도를 기다리는 동안 하나뿐인 워커가 다음 메시지를 처리한다
+	if attempts[1].name != "other" || attempts[1].at.Sub(start) >= delay {
+		t.Errorf("second attempt = %+v after %v, want other before the retry delay", attempts[1], attempts[1].at.Sub(start))
+	}
+	if attempts[2].name != "flaky" || attempts[2].retryCount != 1 {
+		t.Errorf("third attempt = %+v, want flaky retry 1", attempts[2])
+	}
+	// 재시도 시각은 밀리초 단위로 기록된다
+	if elapsed := attempts[2].at.Sub(attempts[0].at); elapsed < delay-time.Millisecond {
+		t.Errorf("retried after %v, want at least %v", elapsed, delay)
+	}
+}
+
+func TestConsumerDeadLettersAfterMaxRetries(t *testing.T) {
+	b := NewMemoryBroker(0)
+	r := newRecorder(map[string]error{"broken": errors.New("smtp unavailable")})
+	startConsumer(t, b, 2, ConsumerOptions{Workers: 2}, r)
+
+	dlq, err := b.Subscribe(context.Background(), "emails"+DeadLetterSuffix, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publishRequest(t, b, "emails", "broken")
+
+	attempts := r.wait(t, 3)
+	for i, a := range attempts {
+		if a.retryCount != i {
+			t.Errorf("attempt %d retry count = %d", i, a.retryCount)
+		}
+	}
+
+	select {
+	case msg := <-dlq:
+		if msg.Headers[HeaderRetryCount] != "2" || msg.Headers[HeaderDeadLetterReason] != "smtp unavailable" || msg.Headers[HeaderOriginalTopic] != "emails" {
+			t.Errorf("dead letter headers = %v", msg.Headers)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("message was not dead-lettered")
+	}
+}
+
+// delayedMemoryBroker 지연 발행 요청을 기록하는 메모리 브로커
+type delayedMemoryBroker struct {
+	*MemoryBroker
+	delays chan time.Duration
+}
+
+func (b *delayedMemoryBroker) PublishDelayed(ctx context.Context, topic string, msg Message, delay time.Duration) error {
+	b.delays <- delay
+	return b.Publish(ctx, topic, msg)
+}
+
+func TestConsumerRetryUsesBrokerDelay(t *testing.T) {
+	b := &delayedMemoryBroker{MemoryBroker: NewMemoryBroker(0), delays: make(chan time.Duration, 10)}
+	r := newRecorder(map[string]error{"flaky": errors.New("smtp unavailable")})
+	startConsumer(t, b, 1, ConsumerOptions{RetryDelay: time.Minute}, r)
+
+	publishRequest(t, b, "emails", "flaky")
+	attempts := r.wait(t, 2)
+
+	if delay := <-b.delays; delay != time.Minute {
+		t.Errorf("delay = %v, want %v", delay, time.Minute)
+	}
+	// 브로커가 지연시키므로 소비자는 재시도 시각을 기록하거나 기다리지 않는다
+	if attempts[1].retryCount != 1 || attempts[1].at.Sub(attempts[0].at) > time.Second {
+		t.Errorf("retry attempt = %+v", attempts[1])
+	}
+}
+
+func TestConsumerShutdownRequeuesHeldRetries(t *testing.T) {
+	b := NewMemoryBroker(0)
+	r := newRecorder(map[string]error{"flaky": errors.New("smtp unavailable")})
+	c := NewConsumer(b, "emails", 3, ConsumerOptions{RetryDelay: time.Minute}, r)
+	if err := c.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	publishRequest(t, b, "emails", "flaky")
+	r.wait(t, 1)
+
+	// 재시도 시각까지 보관 중인 메시지가 종료를 막지 않고 브로커로 되돌아간다
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	start := time.Now()
+	if err := c.Shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("shutdown took %v", elapsed)
+	}
+
+	select {
+	case msg := <-b.topics["emails"]:
+		if msg.Headers[HeaderRetryCount] != "1" || msg.Headers[HeaderRetryAt] == "" {
+			t.Errorf("requeued headers = %v", msg.Headers)
+		}
+	default:
+		t.Fatal("held retry was not requeued")
+	}
+}
+
+func TestConsumerDeadLettersPermanentErrorsWithoutRetry(t *testing.T) {
+	b := NewMemoryBroker(0)
+	r := newRecorder(map[string]error{"missing": Permanent("TEMPLATE_NAME_NOT_FOUND", errors.New("template not found"))})
+	startConsumer(t, b, 5, ConsumerOptions{}, r)
+
+	dlq, err := b.Subscribe(context.Background(), "emails"+DeadLetterSuffix, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publishRequest(t, b, "emails", "missing")
+
+	select {
+	case msg := <-dlq:
+		if msg.Headers[HeaderRetryCount] != "0" || msg.Headers[HeaderDeadLetterCode] != "TEMPLATE_NAME_NOT_FOUND" {
+			t.Errorf("dead letter headers = %v", msg.Headers)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("message was not dead-lettered")
+	}
+	if attempts := r.wait(t, 1); len(attempts) != 1 {
+		t.Errorf("attempts = %d, want 1", len(attempts))
+	}
+}
+
+func TestConsumerHeldRetriesCountAgainstPrefetch(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		prefetch int
+		want     bool
+	}{
+		{name: "prefetch full", prefetch: 2, want: false},
+		{name: "free slot", prefetch: 3, want: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewMemoryBroker(0)
+			r := newRecorder(nil)
+			startConsumer(t, b, 3, ConsumerOptions{Workers: 1, Prefetch: tt.prefetch}, r)
+
+			retryAt := strconv.FormatInt(time.Now().Add(time.Minute).UnixMilli(), 10)
+			for _, name := range []string{"held-1", "held-2"} {
+				publishRequestWithHeaders(t, b, "emails", name, map[string]string{HeaderRetryCount: "1", HeaderRetryAt: retryAt})
+			}
+			publishRequest(t, b, "emails", "other")
+
+			// 재시도를 기다리는 메시지도 prefetch 자리를 차지하므로 자리가 없으면 다음 메시지를 받지 않는다
+			select {
+			case <-r.done:
+				if !tt.want {
+					t.Error("processed a message while prefetch was full of held retries")
+				}
+			case <-time.After(200 * time.Millisecond):
+				if tt.want {
+					t.Error("message was not processed with a free prefetch slot")
+				}
+			}
+		})
+	}
+}
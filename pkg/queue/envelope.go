@@ -0,0 +1,280 @@
+package queue
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/mail"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/kihyun1998/prisma-market/prisma-email-service/internal/models"
+)
+
+// 이메일 전송 요청 메시지 타입과 현재 버전
+const (
+	EmailRequestType           = "email.send"
+	CurrentEmailRequestVersion = 1
+)
+
+// 디코딩 실패 코드 (데드레터 헤더에 기록)
+const (
+	ErrCodeInvalidEnvelope    = "invalid_envelope"
+	ErrCodeUnsupportedType    = "unsupported_type"
+	ErrCodeUnsupportedVersion = "unsupported_version"
+	ErrCodeInvalidPayload     = "invalid_payload"
+)
+
+// Envelope 큐 메시지 공통 봉투
+type Envelope struct {
+	Type       string          `json:"type"`
+	Version    int             `json:"version"`
+	ID         string          `json:"id"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Producer   string          `json:"producer,omitempty"`
+	Payload    json.RawMessage `json:"payload"`
+}
+
+// DecodeError 메시지 디코딩 실패 (어느 필드가 왜 잘못됐는지 기록)
+type DecodeError struct {
+	Code    string
+	Field   string
+	Message string
+}
+
+func (e *DecodeError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("%s: %s", e.Code, e.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", e.Code, e.Field, e.Message)
+}
+
+// emailRequestV1 버전 1 페이로드 형식
+// 선택 필드 추가는 하위 호환이므로 버전을 올리지 않는다. 필드의 의미나 형태가 바뀔 때 새 버전과 upgrade 함수를 추가한다.
+type emailRequestV1 struct {
+	TemplateID      string                 `json:"template_id,omitempty"`
+	TemplateName    string                 `json:"template_name,omitempty"`
+	TemplateVersion int                    `json:"template_version,omitempty"`
+	To              []string               `json:"to"`
+	Variables       map[string]interface{} `json:"variables"`
+	Locale          string                 `json:"locale,omitempty"`
+	CallbackURL     string                 `json:"callback_url,omitempty"`
+}
+
+// 버전별 페이로드 디코더 - 각 디코더는 최신 models.EmailRequest로 변환(upgrade)해 반환한다
+var emailRequestDecoders = map[int]func(payload json.RawMessage) (*models.EmailRequest, error){
+	1: decodeEmailRequestV1,
+}
+
+func decodeEmailRequestV1(payload json.RawMessage) (*models.EmailRequest, error) {
+	var v1 emailRequestV1
+	if err := decodeStrict(payload, &v1, ErrCodeInvalidPayload, "payload"); err != nil {
+		return nil, err
+	}
+	return validateEmailRequestV1(&v1)
+}
+
+// decodeLegacyEmailRequest 봉투 없는 기존 생산자의 메시지를 버전 1 페이로드로 디코딩
+// 기존 생산자는 필드 검사 없이 보내 왔으므로 알 수 없는 필드는 거부하지 않고 로그만 남긴다.
+func decodeLegacyEmailRequest(body []byte, fields map[string]json.RawMessage) (*models.EmailRequest, error) {
+	var v1 emailRequestV1
+	if err := decodeJSON(body, &v1, ErrCodeInvalidPayload, "payload", false); err != nil {
+		return nil, err
+	}
+
+	var unknown []string
+	for name := range fields {
+		if !emailRequestV1Fields[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		log.Printf("Ignoring unknown fields in legacy email request: %s", strings.Join(unknown, ", "))
+	}
+
+	return validateEmailRequestV1(&v1)
+}
+
+// emailRequestV1Fields 버전 1 페이로드의 JSON 필드 이름
+var emailRequestV1Fields = jsonFieldNames(reflect.TypeOf(emailRequestV1{}))
+
+func jsonFieldNames(t reflect.Type) map[string]bool {
+	names := make(map[string]bool, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		names[name] = true
+	}
+	return names
+}
+
+// validateEmailRequestV1 버전 1 페이로드 필수 필드 검사
+func validateEmailRequestV1(v1 *emailRequestV1) (*models.EmailRequest, error) {
+	if strings.TrimSpace(v1.TemplateID) == "" && strings.TrimSpace(v1.TemplateName) == "" {
+		return nil, payloadError("payload.template_id", "template_id or template_name is required")
+	}
+	if v1.TemplateVersion < 0 {
+		return nil, payloadError("payload.template_version", "must not be negative")
+	}
+	if len(v1.To) == 0 {
+		return nil, payloadError("payload.to", "must contain at least one recipient")
+	}
+	for i, to := range v1.To {
+		if _, err := mail.ParseAddress(to); err != nil {
+			return nil, payloadError(fmt.Sprintf("payload.to[%d]", i), fmt.Sprintf("invalid email address %q", to))
+		}
+	}
+
+	return upgradeEmailRequestV1(v1), nil
+}
+
+// upgradeEmailRequestV1 버전 1 페이로드를 최신 구조로 변환
+func upgradeEmailRequestV1(v1 *emailRequestV1) *models.EmailRequest {
+	return &models.EmailRequest{
+		TemplateID:      v1.TemplateID,
+		TemplateName:    v1.TemplateName,
+		TemplateVersion: v1.TemplateVersion,
+		To:              v1.To,
+		Variables:       v1.Variables,
+		Locale:          v1.Locale,
+		CallbackURL:     v1.CallbackURL,
+	}
+}
+
+// DecodeEmailRequest 메시지 본문을 이메일 요청으로 디코딩
+// 봉투 없이 EmailRequest JSON만 보내는 기존 생산자의 메시지는 버전 1 페이로드로 처리한다 (알 수 없는 필드 허용).
+func DecodeEmailRequest(body []byte) (*models.EmailRequest, *Envelope, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil, nil, &DecodeError{Code: ErrCodeInvalidEnvelope, Message: fmt.Sprintf("malformed JSON: %v", err)}
+	}
+
+	if _, ok := fields["payload"]; !ok {
+		if _, ok := fields["type"]; !ok {
+			req, err := decodeLegacyEmailRequest(body, fields)
+			if err != nil {
+				return nil, nil, err
+			}
+			return req, &Envelope{Type: EmailRequestType, Version: 1, Payload: body}, nil
+		}
+	}
+
+	var env Envelope
+	if err := decodeStrict(body, &env, ErrCodeInvalidEnvelope, ""); err != nil {
+		return nil, nil, err
+	}
+
+	if err := env.validate(); err != nil {
+		return nil, nil, err
+	}
+
+	decode, ok := emailRequestDecoders[env.Version]
+	if !ok {
+		return nil, nil, &DecodeError{
+			Code:    ErrCodeUnsupportedVersion,
+			Field:   "version",
+			Message: fmt.Sprintf("version %d is not supported (latest %d)", env.Version, CurrentEmailRequestVersion),
+		}
+	}
+
+	req, err := decode(env.Payload)
+	if err != nil {
+		return nil, nil, err
+	}
+	return req, &env, nil
+}
+
+// validate 봉투 필수 필드 검사
+func (e *Envelope) validate() error {
+	switch {
+	case e.Type == "":
+		return &DecodeError{Code: ErrCodeInvalidEnvelope, Field: "type", Message: "is required"}
+	case e.Type != EmailRequestType:
+		return &DecodeError{Code: ErrCodeUnsupportedType, Field: "type", Message: fmt.Sprintf("unsupported message type %q", e.Type)}
+	case e.Version <= 0:
+		return &DecodeError{Code: ErrCodeInvalidEnvelope, Field: "version", Message: "must be a positive integer"}
+	case e.ID == "":
+		return &DecodeError{Code: ErrCodeInvalidEnvelope, Field: "id", Message: "is required"}
+	case e.OccurredAt.IsZero():
+		return &DecodeError{Code: ErrCodeInvalidEnvelope, Field: "occurred_at", Message: "is required"}
+	case len(e.Payload) == 0 || string(e.Payload) == "null":
+		return &DecodeError{Code: ErrCodeInvalidEnvelope, Field: "payload", Message: "is required"}
+	}
+	return nil
+}
+
+// NewEmailRequestEnvelope 최신 버전 봉투로 이메일 요청을 감싼다
+func NewEmailRequestEnvelope(req *models.EmailRequest, producer string) (*Envelope, error) {
+	payload, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Envelope{
+		Type:       EmailRequestType,
+		Version:    CurrentEmailRequestVersion,
+		ID:         primitive.NewObjectID().Hex(),
+		OccurredAt: time.Now().UTC(),
+		Producer:   producer,
+		Payload:    payload,
+	}, nil
+}
+
+// decodeStrict 알 수 없는 필드와 타입 오류를 필드 경로와 함께 보고하는 디코딩
+func decodeStrict(data []byte, v interface{}, code, path string) error {
+	return decodeJSON(data, v, code, path, true)
+}
+
+// decodeJSON 타입 오류를 필드 경로와 함께 보고하는 디코딩 (strict이면 알 수 없는 필드도 거부)
+func decodeJSON(data []byte, v interface{}, code, path string, strict bool) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if strict {
+		dec.DisallowUnknownFields()
+	}
+
+	fail := func(field, message string) error {
+		return &DecodeError{Code: code, Field: joinField(path, field), Message: message}
+	}
+
+	if err := dec.Decode(v); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		var syntaxErr *json.SyntaxError
+		switch {
+		case errors.As(err, &typeErr):
+			return fail(typeErr.Field, fmt.Sprintf("expected %s, got %s", typeErr.Type, typeErr.Value))
+		case errors.As(err, &syntaxErr):
+			return fail("", fmt.Sprintf("malformed JSON at offset %d: %v", syntaxErr.Offset, err))
+		case strings.HasPrefix(err.Error(), "json: unknown field "):
+			return fail(strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`), "unknown field")
+		default:
+			return fail("", err.Error())
+		}
+	}
+
+	if _, err := dec.Token(); err != io.EOF {
+		return fail("", "unexpected data after JSON value")
+	}
+	return nil
+}
+
+func joinField(path, field string) string {
+	switch {
+	case path == "":
+		return field
+	case field == "":
+		return path
+	default:
+		return path + "." + field
+	}
+}
+
+func payloadError(field, message string) *DecodeError {
+	return &DecodeError{Code: ErrCodeInvalidPayload, Field: field, Message: message}
+}
@@ -0,0 +1,96 @@
+package queue
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/kihyun1998/prisma-market/prisma-email-service/internal/models"
+)
+
+func TestDecodeEmailRequestRoundTrip(t *testing.T) {
+	req := &models.EmailRequest{TemplateName: "welcome", To: []string{"a@example.com"}, Variables: map[string]interface{}{"name": "Tom"}}
+	envelope, err := NewEmailRequestEnvelope(req, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := json.Marshal(envelope)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, decodedEnvelope, err := DecodeEmailRequest(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.TemplateName != "welcome" || decoded.To[0] != "a@example.com" || decoded.Variables["name"] != "Tom" {
+		t.Errorf("request = %+v", decoded)
+	}
+	if decodedEnvelope.ID != envelope.ID || decodedEnvelope.Producer != "test" {
+		t.Errorf("envelope = %+v", decodedEnvelope)
+	}
+}
+
+func TestDecodeEmailRequestLegacyBody(t *testing.T) {
+	// 봉투 없는 기존 생산자의 메시지는 버전 1 페이로드로 처리한다
+	req, envelope, err := DecodeEmailRequest([]byte(`{"template_id":"abc","to":["a@example.com"],"variables":{}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.TemplateID != "abc" || envelope.Version != 1 || envelope.ID != "" {
+		t.Errorf("request = %+v, envelope = %+v", req, envelope)
+	}
+}
+
+func TestDecodeEmailRequestLegacyBodyAllowsUnknownFields(t *testing.T) {
+	// 기존 생산자가 보내던 추가 필드 때문에 데드레터로 보내지 않는다 (봉투가 있는 메시지는 여전히 거부)
+	req, _, err := DecodeEmailRequest([]byte(`{"template_name":"welcome","to":["a@example.com"],"cc":["b@example.com"],"variables":{}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.TemplateName != "welcome" || len(req.To) != 1 {
+		t.Errorf("request = %+v", req)
+	}
+
+	// 필수 필드와 타입 검사는 그대로 적용한다
+	_, _, err = DecodeEmailRequest([]byte(`{"template_name":"welcome","to":"a@example.com","cc":[]}`))
+	var decodeErr *DecodeError
+	if !errors.As(err, &decodeErr) || decodeErr.Code != ErrCodeInvalidPayload || decodeErr.Field != "payload.to" {
+		t.Errorf("error = %v, want invalid payload.to", err)
+	}
+}
+
+func TestDecodeEmailRequestErrors(t *testing.T) {
+	const envelope = `"type":"email.send","version":1,"id":"m-1","occurred_at":"2024-01-01T00:00:00Z"`
+	tests := []struct {
+		name  string
+		body  string
+		code  string
+		field string
+	}{
+		{name: "malformed", body: `{`, code: ErrCodeInvalidEnvelope},
+		{name: "unknown type", body: `{"type":"sms.send","version":1,"id":"m-1","occurred_at":"2024-01-01T00:00:00Z","payload":{}}`, code: ErrCodeUnsupportedType, field: "type"},
+		{name: "future version", body: `{"type":"email.send","version":9,"id":"m-1","occurred_at":"2024-01-01T00:00:00Z","payload":{}}`, code: ErrCodeUnsupportedVersion, field: "version"},
+		{name: "missing id", body: `{"type":"email.send","version":1,"occurred_at":"2024-01-01T00:00:00Z","payload":{}}`, code: ErrCodeInvalidEnvelope, field: "id"},
+		{name: "missing payload", body: `{` + envelope + `}`, code: ErrCodeInvalidEnvelope, field: "payload"},
+		{name: "unknown envelope field", body: `{` + envelope + `,"extra":1,"payload":{}}`, code: ErrCodeInvalidEnvelope, field: "extra"},
+		{name: "missing template", body: `{` + envelope + `,"payload":{"to":["a@example.com"]}}`, code: ErrCodeInvalidPayload, field: "payload.template_id"},
+		{name: "no recipients", body: `{` + envelope + `,"payload":{"template_name":"x","to":[]}}`, code: ErrCodeInvalidPayload, field: "payload.to"},
+		{name: "invalid recipient", body: `{` + envelope + `,"payload":{"template_name":"x","to":["a@example.com","nope"]}}`, code: ErrCodeInvalidPayload, field: "payload.to[1]"},
+		{name: "wrong type", body: `{` + envelope + `,"payload":{"template_name":"x","to":"a@example.com"}}`, code: ErrCodeInvalidPayload, field: "payload.to"},
+		{name: "unknown payload field", body: `{` + envelope + `,"payload":{"template_name":"x","to":["a@example.com"],"cc":[]}}`, code: ErrCodeInvalidPayload, field: "payload.cc"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := DecodeEmailRequest([]byte(tt.body))
+			var decodeErr *DecodeError
+			if !errors.As(err, &decodeErr) {
+				t.Fatalf("error = %v, want *DecodeError", err)
+			}
+			if decodeErr.Code != tt.code || decodeErr.Field != tt.field {
+				t.Errorf("error = %s/%s (%v), want %s/%s", decodeErr.Code, decodeErr.Field, err, tt.code, tt.field)
+			}
+		})
+	}
+}
@@ -0,0 +1,198 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// KafkaBroker Kafka 브로커 (토픽 = Kafka 토픽, 컨슈머 그룹으로 소비)
+type KafkaBroker struct {
+	brokers []string
+	groupID string
+	writer  *kafka.Writer
+
+	mu      sync.Mutex
+	readers []*kafka.Reader
+}
+
+func NewKafkaBroker(brokers []string, groupID string) (*KafkaBroker, error) {
+	if len(brokers) == 0 {
+		return nil, errors.New("kafka brokers are not configured")
+	}
+
+	return &KafkaBroker{
+		brokers: brokers,
+		groupID: groupID,
+		writer: &kafka.Writer{
+			Addr:                   kafka.TCP(brokers...),
+			Balancer:               &kafka.Hash{},
+			RequiredAcks:           kafka.RequireAll,
+			AllowAutoTopicCreation: true,
+		},
+	}, nil
+}
+
+func (b *KafkaBroker) Subscribe(ctx context.Context, topic string, prefetch int) (<-chan Delivery, error) {
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:       b.brokers,
+		GroupID:       b.groupID,
+		Topic:         topic,
+		QueueCapacity: prefetch,
+	})
+
+	// 이미 전달된 메시지의 커밋을 위해 Close 전까지 reader를 유지한다
+	b.mu.Lock()
+	b.readers = append(b.readers, reader)
+	b.mu.Unlock()
+
+	tracker := newOffsetTracker()
+	out := make(chan Delivery)
+	go func() {
+		defer close(out)
+		for {
+			m, err := reader.FetchMessage(ctx)
+			if err != nil {
+				if ctx.Err() != nil || errors.Is(err, io.EOF) {
+					return
+				}
+				log.Printf("Error fetching kafka message: %v", err)
+				time.Sleep(time.Second)
+				continue
+			}
+			tracker.add(m)
+			out <- b.delivery(reader, tracker, topic, m)
+		}
+	}()
+
+	return out, nil
+}
+
+func (b *KafkaBroker) delivery(reader *kafka.Reader, tracker *offsetTracker, topic string, m kafka.Message) Delivery {
+	headers := make(map[string]string, len(m.Headers))
+	for _, h := range m.Headers {
+		headers[h.Key] = string(h.Value)
+	}
+	msg := restoreMessage(headers, m.Value)
+
+	// 워커가 여럿이면 메시지가 순서와 다르게 끝나므로 앞선 메시지가 모두 끝난 위치까지만 커밋한다
+	commit := func() error {
+		return tracker.complete(m, func(last kafka.Message) error {
+			return reader.CommitMessages(context.Background(), last)
+		})
+	}
+
+	return Delivery{
+		Message: msg,
+		ack:     commit,
+		nack: func(requeue bool) error {
+			// 오프셋 커밋은 개별 메시지를 되돌릴 수 없으므로 재발행 후 커밋한다
+			if requeue {
+				if err := b.Publish(context.Background(), topic, msg); err != nil {
+					return err
+				}
+			}
+			return commit()
+		},
+	}
+}
+
+func (b *KafkaBroker) Publish(ctx context.Context, topic string, msg Message) error {
+	headers := flattenHeaders(msg)
+	kafkaHeaders := make([]kafka.Header, 0, len(headers))
+	for k, v := range headers {
+		kafkaHeaders = append(kafkaHeaders, kafka.Header{Key: k, Value: []byte(v)})
+	}
+
+	return b.writer.WriteMessages(ctx, kafka.Message{
+		Topic:   topic,
+		Key:     []byte(msg.ID),
+		Value:   msg.Body,
+		Headers: kafkaHeaders,
+	})
+}
+
+func (b *KafkaBroker) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var errs []error
+	for _, r := range b.readers {
+		if err := r.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := b.writer.Close(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("error closing kafka broker: %v", err)
+	}
+	return nil
+}
+
+// offsetTracker 파티션별로 받은 오프셋과 처리 완료 여부를 추적
+// 오프셋 커밋은 그 이전 메시지까지 모두 처리했다는 뜻이므로, 처리 중인 메시지보다 뒤의 오프셋은 커밋하지 않는다.
+type offsetTracker struct {
+	mu        sync.Mutex
+	pending   map[int][]int64 // 파티션별 커밋 전 오프셋 (받은 순서)
+	completed map[int]map[int64]bool
+}
+
+func newOffsetTracker() *offsetTracker {
+	return &offsetTracker{
+		pending:   make(map[int][]int64),
+		completed: make(map[int]map[int64]bool),
+	}
+}
+
+// add 받은 메시지 등록 (리밸런스 후 다시 받은 오프셋은 한 번만 등록)
+func (t *offsetTracker) add(m kafka.Message) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	offsets := t.pending[m.Partition]
+	if n := len(offsets); n > 0 && m.Offset <= offsets[n-1] {
+		return
+	}
+	t.pending[m.Partition] = append(offsets, m.Offset)
+}
+
+// complete 처리 완료 표시 후, 앞에서부터 연속으로 끝난 마지막 메시지가 있으면 commit 호출
+// 커밋 순서가 뒤바뀌지 않도록 잠금을 유지한 채 커밋한다.
+func (t *offsetTracker) complete(m kafka.Message, commit func(last kafka.Message) error) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	done := t.completed[m.Partition]
+	if done == nil {
+		done = make(map[int64]bool)
+		t.completed[m.Partition] = done
+	}
+	done[m.Offset] = true
+
+	offsets := t.pending[m.Partition]
+	n := 0
+	for n < len(offsets) && done[offsets[n]] {
+		n++
+	}
+	if n == 0 {
+		return nil
+	}
+
+	last := kafka.Message{Topic: m.Topic, Partition: m.Partition, Offset: offsets[n-1]}
+	if err := commit(last); err != nil {
+		return err
+	}
+	for _, offset := range offsets[:n] {
+		delete(done, offset)
+	}
+	t.pending[m.Partition] = offsets[n:]
+	return nil
+}
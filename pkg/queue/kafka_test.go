@@ -0,0 +1,87 @@
+package queue
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+)
+
+func TestOffsetTrackerCommitsOnlyContiguousPrefix(t *testing.T) {
+	tracker := newOffsetTracker()
+	msg := func(partition int, offset int64) kafka.Message {
+		return kafka.Message{Topic: "emails", Partition: partition, Offset: offset}
+	}
+	for _, offset := range []int64{10, 11, 12, 13} {
+		tracker.add(msg(0, offset))
+	}
+	tracker.add(msg(1, 5))
+
+	var committed []kafka.Message
+	commit := func(last kafka.Message) error {
+		committed = append(committed, last)
+		return nil
+	}
+
+	// 뒤의 메시지가 먼저 끝나도 앞선 메시지가 처리 중이면 커밋하지 않는다
+	for _, offset := range []int64{12, 11} {
+		if err := tracker.complete(msg(0, offset), commit); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(committed) != 0 {
+		t.Fatalf("committed %v while offset 10 is in flight", committed)
+	}
+
+	if err := tracker.complete(msg(0, 10), commit); err != nil {
+		t.Fatal(err)
+	}
+	if err := tracker.complete(msg(1, 5), commit); err != nil {
+		t.Fatal(err)
+	}
+	if err := tracker.complete(msg(0, 13), commit); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []kafka.Message{msg(0, 12), msg(1, 5), msg(0, 13)}
+	if !reflect.DeepEqual(committed, want) {
+		t.Errorf("committed %v, want %v", committed, want)
+	}
+}
+
+func TestOffsetTrackerRetriesFailedCommit(t *testing.T) {
+	tracker := newOffsetTracker()
+	tracker.add(kafka.Message{Offset: 1})
+	tracker.add(kafka.Message{Offset: 2})
+
+	err := tracker.complete(kafka.Message{Offset: 1}, func(kafka.Message) error { return errors.New("rebalancing") })
+	if err == nil {
+		t.Fatal("expected commit error")
+	}
+
+	var last int64 = -1
+	if err := tracker.complete(kafka.Message{Offset: 2}, func(m kafka.Message) error {
+		last = m.Offset
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if last != 2 {
+		t.Errorf("committed offset %d, want 2", last)
+	}
+}
+
+func TestOffsetTrackerIgnoresRedelivery(t *testing.T) {
+	tracker := newOffsetTracker()
+	tracker.add(kafka.Message{Offset: 1})
+	tracker.add(kafka.Message{Offset: 1})
+
+	calls := 0
+	if err := tracker.complete(kafka.Message{Offset: 1}, func(kafka.Message) error { calls++; return nil }); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 1 || len(tracker.pending[0]) != 0 {
+		t.Errorf("calls = %d, pending = %v", calls, tracker.pending[0])
+	}
+}
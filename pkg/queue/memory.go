@@ -0,0 +1,128 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"sync"
+)
+
+// 메모리 브로커 토픽별 기본 버퍼 크기
+const defaultMemoryBuffer = 1024
+
+// MemoryBroker 프로세스 내부 채널 기반 브로커 (테스트 및 단일 바이너리 개발용)
+type MemoryBroker struct {
+	buffer int
+
+	mu     sync.Mutex
+	topics map[string]chan Message
+	closed bool
+}
+
+func NewMemoryBroker(buffer int) *MemoryBroker {
+	if buffer <= 0 {
+		buffer = defaultMemoryBuffer
+	}
+	return &MemoryBroker{
+		buffer: buffer,
+		topics: make(map[string]chan Message),
+	}
+}
+
+func (b *MemoryBroker) topic(name string) (chan Message, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return nil, errors.New("memory broker is closed")
+	}
+
+	ch, ok := b.topics[name]
+	if !ok {
+		ch = make(chan Message, b.buffer)
+		b.topics[name] = ch
+	}
+	return ch, nil
+}
+
+func (b *MemoryBroker) Subscribe(ctx context.Context, topic string, prefetch int) (<-chan Delivery, error) {
+	ch, err := b.topic(topic)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan Delivery)
+	go func() {
+		defer close(out)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg := <-ch:
+				select {
+				case out <- b.delivery(topic, msg):
+				case <-ctx.Done():
+					// 전달하지 못한 메시지는 토픽으로 되돌린다
+					ch <- msg
+					return
+				}
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+func (b *MemoryBroker) delivery(topic string, msg Message) Delivery {
+	return Delivery{
+		Message: msg,
+		ack: func() error {
+			return nil
+		},
+		nack: func(requeue bool) error {
+			if requeue {
+				return b.Publish(context.Background(), topic, msg)
+			}
+			return nil
+		},
+	}
+}
+
+func (b *MemoryBroker) Publish(ctx context.Context, topic string, msg Message) error {
+	ch, err := b.topic(topic)
+	if err != nil {
+		return err
+	}
+
+	// 데드레터 토픽은 보통 소비자가 없으므로 가득 차면 가장 오래된 메시지를 버린다 (워커가 발행에서 멈추지 않도록)
+	if strings.HasSuffix(topic, DeadLetterSuffix) {
+		for {
+			select {
+			case ch <- msg:
+				return nil
+			default:
+			}
+			select {
+			case dropped := <-ch:
+				log.Printf("Memory broker: dead letter topic %s is full, dropped message %s", topic, dropped.ID)
+			default:
+			}
+		}
+	}
+
+	select {
+	case ch <- msg:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (b *MemoryBroker) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	return nil
+}
@@ -0,0 +1,50 @@
+package queue
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryBrokerDeadLetterTopicDropsOldest(t *testing.T) {
+	b := NewMemoryBroker(2)
+	topic := "emails" + DeadLetterSuffix
+
+	// 소비자가 없어도 데드레터 발행은 막히지 않는다
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	for _, id := range []string{"1", "2", "3"} {
+		if err := b.Publish(ctx, topic, Message{ID: id}); err != nil {
+			t.Fatalf("publish %s: %v", id, err)
+		}
+	}
+
+	msgs, err := b.Subscribe(ctx, topic, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"2", "3"} {
+		select {
+		case msg := <-msgs:
+			if msg.ID != want {
+				t.Errorf("message = %s, want %s", msg.ID, want)
+			}
+		case <-ctx.Done():
+			t.Fatalf("timed out waiting for message %s", want)
+		}
+	}
+}
+
+func TestMemoryBrokerPublishWaitsWhenFull(t *testing.T) {
+	b := NewMemoryBroker(1)
+	if err := b.Publish(context.Background(), "emails", Message{ID: "1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 일반 토픽은 버리지 않고 자리가 날 때까지 기다린다
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := b.Publish(ctx, "emails", Message{ID: "2"}); err != context.DeadlineExceeded {
+		t.Errorf("error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
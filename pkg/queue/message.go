@@ -1,7 +1,56 @@
 package queue
 
-import "github.com/kihyun1998/prisma-market/prisma-email-service/internal/models"
+import (
+	"context"
+
+	"github.com/kihyun1998/prisma-market/prisma-email-service/internal/models"
+)
 
 type MessageProcessor interface {
-	ProcessMessage(msg *models.EmailRequest) error
+	ProcessMessage(ctx context.Context, msg *models.EmailRequest) error
+}
+
+// PermanentError 재시도해도 같은 결과인 처리 실패 (소비자가 재시도하지 않고 바로 데드레터로 보낸다)
+type PermanentError struct {
+	Code string // 데드레터 헤더에 기록할 원인 코드 (없으면 비워 둔다)
+	Err  error
+}
+
+func (e *PermanentError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *PermanentError) Unwrap() error {
+	return e.Err
+}
+
+// Permanent 처리 실패를 재시도하지 않을 에러로 표시
+func Permanent(code string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &PermanentError{Code: code, Err: err}
+}
+
+// Metadata 메시지 헤더에서 추출한 추적 정보
+type Metadata struct {
+	MessageID     string
+	CorrelationID string
+	Producer      string
+	TraceParent   string // W3C traceparent
+	TraceState    string // W3C tracestate
+	RetryCount    int
+}
+
+type metadataKey struct{}
+
+// ContextWithMetadata 컨텍스트에 메시지 메타데이터 저장
+func ContextWithMetadata(ctx context.Context, md Metadata) context.Context {
+	return context.WithValue(ctx, metadataKey{}, md)
+}
+
+// MetadataFromContext 컨텍스트에서 메시지 메타데이터 조회
+func MetadataFromContext(ctx context.Context) (Metadata, bool) {
+	md, ok := ctx.Value(metadataKey{}).(Metadata)
+	return md, ok
 }
@@ -0,0 +1,203 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// natsAckWait JetStream이 ack를 기다리는 시간 (지나면 같은 메시지를 다시 전달한다)
+const natsAckWait = 30 * time.Second
+
+// natsProgressInterval 전달한 메시지를 ack 할 때까지 처리 중임을 알리는 간격
+// 워커 대기나 느린 SMTP 전송으로 처리가 ack 대기 시간을 넘어도 처리 중인 메시지가 다시 전달되지 않도록 한다.
+var natsProgressInterval = natsAckWait / 3
+
+// NATSBroker NATS JetStream 브로커 (토픽 = subject, 토픽마다 스트림 하나)
+type NATSBroker struct {
+	conn    *nats.Conn
+	js      jetstream.JetStream
+	durable string
+
+	mu      sync.Mutex
+	streams map[string]bool
+}
+
+func NewNATSBroker(url, durable string) (*NATSBroker, error) {
+	conn, err := nats.Connect(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to NATS: %v", err)
+	}
+
+	js, err := jetstream.New(conn)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to create JetStream context: %v", err)
+	}
+
+	return &NATSBroker{
+		conn:    conn,
+		js:      js,
+		durable: durable,
+		streams: make(map[string]bool),
+	}, nil
+}
+
+// streamName subject를 스트림 이름으로 사용할 수 있게 변환
+func streamName(topic string) string {
+	return strings.NewReplacer(".", "_", "*", "_", ">", "_", " ", "_").Replace(strings.ToUpper(topic))
+}
+
+// ensureStream 토픽을 저장할 스트림 생성 (한 번만 수행)
+func (b *NATSBroker) ensureStream(ctx context.Context, topic string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.streams[topic] {
+		return nil
+	}
+
+	_, err := b.js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:     streamName(topic),
+		Subjects: []string{topic},
+		Storage:  jetstream.FileStorage,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create stream: %v", err)
+	}
+
+	b.streams[topic] = true
+	return nil
+}
+
+func (b *NATSBroker) Subscribe(ctx context.Context, topic string, prefetch int) (<-chan Delivery, error) {
+	if err := b.ensureStream(ctx, topic); err != nil {
+		return nil, err
+	}
+
+	consumer, err := b.js.CreateOrUpdateConsumer(ctx, streamName(topic), jetstream.ConsumerConfig{
+		Durable:       b.durable,
+		AckPolicy:     jetstream.AckExplicitPolicy,
+		AckWait:       natsAckWait,
+		MaxAckPending: prefetch,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create consumer: %v", err)
+	}
+
+	iter, err := consumer.Messages(jetstream.PullMaxMessages(prefetch))
+	if err != nil {
+		return nil, fmt.Errorf("failed to start consuming: %v", err)
+	}
+
+	// 컨텍스트 종료 시 버퍼에 남은 메시지까지 전달한 뒤 종료
+	go func() {
+		<-ctx.Done()
+		iter.Drain()
+	}()
+
+	out := make(chan Delivery)
+	go func() {
+		defer close(out)
+		for {
+			m, err := iter.Next()
+			if err != nil {
+				if errors.Is(err, jetstream.ErrMsgIteratorClosed) {
+					return
+				}
+				log.Printf("Error fetching NATS message: %v", err)
+				time.Sleep(time.Second)
+				continue
+			}
+			out <- natsDelivery(m)
+		}
+	}()
+
+	return out, nil
+}
+
+func natsDelivery(m jetstream.Msg) Delivery {
+	headers := make(map[string]string, len(m.Headers()))
+	for k := range m.Headers() {
+		headers[strings.ToLower(k)] = m.Headers().Get(k)
+	}
+
+	// 재시도는 같은 메시지를 지연 재전달(NakWithDelay)하므로 재시도 횟수에 이전 전달 횟수를 더한다
+	if meta, err := m.Metadata(); err == nil && meta.NumDelivered > 1 {
+		headers[HeaderRetryCount] = strconv.Itoa(retryCountFromHeaders(headers) + int(meta.NumDelivered) - 1)
+	}
+
+	// ack/nack 할 때까지 처리 중 알림을 보낸다
+	done := make(chan struct{})
+	var once sync.Once
+	finish := func() {
+		once.Do(func() { close(done) })
+	}
+	go func() {
+		ticker := time.NewTicker(natsProgressInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := m.InProgress(); err != nil {
+					log.Printf("Error extending NATS ack deadline: %v", err)
+					return
+				}
+			}
+		}
+	}()
+
+	return Delivery{
+		Message: restoreMessage(headers, m.Data()),
+		ack: func() error {
+			finish()
+			return m.Ack()
+		},
+		nack: func(requeue bool) error {
+			finish()
+			if requeue {
+				return m.Nak()
+			}
+			return m.Term()
+		},
+		nackDelay: func(delay time.Duration) error {
+			finish()
+			return m.NakWithDelay(delay)
+		},
+	}
+}
+
+func (b *NATSBroker) Publish(ctx context.Context, topic string, msg Message) error {
+	if err := b.ensureStream(ctx, topic); err != nil {
+		return err
+	}
+
+	header := nats.Header{}
+	for k, v := range flattenHeaders(msg) {
+		header.Set(k, v)
+	}
+
+	_, err := b.js.PublishMsg(ctx, &nats.Msg{
+		Subject: topic,
+		Header:  header,
+		Data:    msg.Body,
+	})
+	return err
+}
+
+func (b *NATSBroker) Close() error {
+	if err := b.conn.Drain(); err != nil {
+		return fmt.Errorf("error closing NATS connection: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+
+	"github.com/kihyun1998/prisma-market/prisma-email-service/internal/models"
+)
+
+// fakeNATSMsg ack 호출을 기록하는 JetStream 메시지
+type fakeNATSMsg struct {
+	jetstream.Msg // 사용하지 않는 메서드
+
+	data []byte
+
+	mu         sync.Mutex
+	inProgress int
+	acks       int
+	naks       int
+}
+
+func (m *fakeNATSMsg) Data() []byte         { return m.data }
+func (m *fakeNATSMsg) Headers() nats.Header { return nats.Header{} }
+func (m *fakeNATSMsg) Metadata() (*jetstream.MsgMetadata, error) {
+	return &jetstream.MsgMetadata{NumDelivered: 1}, nil
+}
+
+func (m *fakeNATSMsg) InProgress() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.inProgress++
+	return nil
+}
+
+func (m *fakeNATSMsg) Ack() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.acks++
+	return nil
+}
+
+func (m *fakeNATSMsg) Nak() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.naks++
+	return nil
+}
+
+func (m *fakeNATSMsg) NakWithDelay(time.Duration) error { return m.Nak() }
+func (m *fakeNATSMsg) Term() error                      { return m.Nak() }
+
+func (m *fakeNATSMsg) counts() (inProgress, acks, naks int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.inProgress, m.acks, m.naks
+}
+
+// deliveryBroker 정해진 전달만 내보내는 브로커
+type deliveryBroker struct {
+	deliveries []Delivery
+}
+
+func (b *deliveryBroker) Subscribe(ctx context.Context, topic string, prefetch int) (<-chan Delivery, error) {
+	out := make(chan Delivery)
+	go func() {
+		defer close(out)
+		for _, d := range b.deliveries {
+			out <- d
+		}
+		<-ctx.Done()
+	}()
+	return out, nil
+}
+
+func (b *deliveryBroker) Publish(ctx context.Context, topic string, msg Message) error {
+	return errors.New("publish is not supported")
+}
+
+func (b *deliveryBroker) Close() error {
+	return nil
+}
+
+func TestNATSDeliveryExtendsAckDeadlineForSlowHandler(t *testing.T) {
+	defer func(interval time.Duration) { natsProgressInterval = interval }(natsProgressInterval)
+	natsProgressInterval = 10 * time.Millisecond
+
+	envelope, _ := NewEmailRequestEnvelope(&models.EmailRequest{TemplateName: "slow", To: []string{"a@example.com"}}, "test")
+	body, _ := json.Marshal(envelope)
+	msg := &fakeNATSMsg{data: body}
+
+	processed := make(chan struct{})
+	slow := processorFunc(func(ctx context.Context, req *models.EmailRequest) error {
+		defer close(processed)
+		time.Sleep(80 * time.Millisecond)
+		return nil
+	})
+	startConsumer(t, &deliveryBroker{deliveries: []Delivery{natsDelivery(msg)}}, 3, ConsumerOptions{}, slow)
+
+	select {
+	case <-processed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("message was not processed")
+	}
+	time.Sleep(30 * time.Millisecond)
+
+	// 처리하는 동안 처리 중 알림을 보내 서버가 다시 전달하지 않게 하고, ack 뒤에는 멈춘다
+	inProgress, acks, naks := msg.counts()
+	if inProgress < 3 || acks != 1 || naks != 0 {
+		t.Errorf("in progress = %d, acks = %d, naks = %d", inProgress, acks, naks)
+	}
+	time.Sleep(30 * time.Millisecond)
+	if after, _, _ := msg.counts(); after != inProgress {
+		t.Errorf("in progress notifications continued after ack: %d -> %d", inProgress, after)
+	}
+}
@@ -0,0 +1,158 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Redis 스트림 엔트리 필드
+const (
+	redisBodyField   = "body"
+	redisHeaderField = "h:"
+)
+
+// RedisBroker Redis Streams 브로커 (토픽 = 스트림 키, 컨슈머 그룹으로 소비)
+type RedisBroker struct {
+	client   *redis.Client
+	group    string
+	consumer string
+}
+
+func NewRedisBroker(addr, password string, db int, group string) (*RedisBroker, error) {
+	client := redis.NewClient(&redis.Options{
+		Addr:     addr,
+		Password: password,
+		DB:       db,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to connect to Redis: %v", err)
+	}
+
+	// 재시작 후에도 자신의 미처리 메시지를 다시 읽을 수 있도록 호스트 이름을 소비자 이름으로 사용
+	consumer, err := os.Hostname()
+	if err != nil || consumer == "" {
+		consumer = fmt.Sprintf("email-service-%d", os.Getpid())
+	}
+
+	return &RedisBroker{
+		client:   client,
+		group:    group,
+		consumer: consumer,
+	}, nil
+}
+
+func (b *RedisBroker) Subscribe(ctx context.Context, topic string, prefetch int) (<-chan Delivery, error) {
+	err := b.client.XGroupCreateMkStream(ctx, topic, b.group, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return nil, fmt.Errorf("failed to create consumer group: %v", err)
+	}
+
+	out := make(chan Delivery)
+	go func() {
+		defer close(out)
+
+		// 먼저 이전에 받았지만 ack 하지 못한 메시지를 처리한 뒤 새 메시지를 읽는다
+		// 미처리 목록은 마지막으로 받은 ID 다음부터 이어 읽는다 (처리 중인 항목을 다시 받지 않도록)
+		lastID := "0"
+		for ctx.Err() == nil {
+			streams, err := b.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+				Group:    b.group,
+				Consumer: b.consumer,
+				Streams:  []string{topic, lastID},
+				Count:    int64(prefetch),
+				Block:    5 * time.Second,
+			}).Result()
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				if !errors.Is(err, redis.Nil) {
+					log.Printf("Error reading Redis stream: %v", err)
+					time.Sleep(time.Second)
+				}
+				continue
+			}
+
+			received := 0
+			for _, stream := range streams {
+				for _, m := range stream.Messages {
+					received++
+					if lastID != ">" {
+						lastID = m.ID
+					}
+					out <- b.delivery(topic, m)
+				}
+			}
+			if lastID != ">" && received == 0 {
+				lastID = ">"
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+func (b *RedisBroker) delivery(topic string, m redis.XMessage) Delivery {
+	headers := make(map[string]string)
+	var body []byte
+	for k, v := range m.Values {
+		value, _ := v.(string)
+		switch {
+		case k == redisBodyField:
+			body = []byte(value)
+		case strings.HasPrefix(k, redisHeaderField):
+			headers[strings.TrimPrefix(k, redisHeaderField)] = value
+		}
+	}
+	msg := restoreMessage(headers, body)
+
+	ack := func() error {
+		return b.client.XAck(context.Background(), topic, b.group, m.ID).Err()
+	}
+
+	return Delivery{
+		Message: msg,
+		ack:     ack,
+		nack: func(requeue bool) error {
+			if requeue {
+				if err := b.Publish(context.Background(), topic, msg); err != nil {
+					return err
+				}
+			}
+			return ack()
+		},
+	}
+}
+
+func (b *RedisBroker) Publish(ctx context.Context, topic string, msg Message) error {
+	values := map[string]interface{}{
+		redisBodyField: msg.Body,
+	}
+	for k, v := range flattenHeaders(msg) {
+		values[redisHeaderField+k] = v
+	}
+
+	return b.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: topic,
+		Values: values,
+	}).Err()
+}
+
+func (b *RedisBroker) Close() error {
+	if err := b.client.Close(); err != nil {
+		return fmt.Errorf("error closing Redis client: %v", err)
+	}
+	return nil
+}
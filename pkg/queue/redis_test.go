@@ -0,0 +1,69 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+)
+
+// REDIS_ADDR가 설정된 경우에만 실행 (e.g., REDIS_ADDR=localhost:6379)
+func testRedisBroker(t *testing.T, group string) *RedisBroker {
+	t.Helper()
+	addr := os.Getenv("REDIS_ADDR")
+	if addr == "" {
+		t.Skip("REDIS_ADDR is not set")
+	}
+	b, err := NewRedisBroker(addr, os.Getenv("REDIS_PASSWORD"), 0, group)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { b.Close() })
+	return b
+}
+
+func TestRedisPendingRecoveryDeliversEachEntryOnce(t *testing.T) {
+	topic := fmt.Sprintf("test_pending_%d", time.Now().UnixNano())
+	b := testRedisBroker(t, "test")
+	defer b.client.Del(context.Background(), topic)
+
+	for i := 0; i < 5; i++ {
+		if err := b.Publish(context.Background(), topic, Message{Body: []byte(fmt.Sprint(i))}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 받기만 하고 ack 하지 않은 채 종료 (재시작 전 처리 중이던 메시지)
+	ctx, cancel := context.WithCancel(context.Background())
+	deliveries, err := b.Subscribe(ctx, topic, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		<-deliveries
+	}
+	cancel()
+	for range deliveries {
+	}
+
+	// 재시작 후 미처리 메시지를 ack 하지 않고 천천히 처리해도 같은 항목을 다시 받지 않아야 한다
+	ctx, cancel = context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	deliveries, err = b.Subscribe(ctx, topic, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := make(map[string]int)
+	for d := range deliveries {
+		seen[string(d.Body)]++
+	}
+	if len(seen) != 5 {
+		t.Fatalf("recovered %d distinct entries, want 5: %v", len(seen), seen)
+	}
+	for body, n := range seen {
+		if n != 1 {
+			t.Errorf("entry %s delivered %d times", body, n)
+		}
+	}
+}
@@ -0,0 +1,311 @@
+package render
+
+import (
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+// SyntaxError 템플릿 문법 오류 위치
+type SyntaxError struct {
+	Line    int
+	Column  int
+	Message string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Message)
+}
+
+// "template: name:12: message" 또는 "template: name:12:5: message" 형식
+var parseErrorPattern = regexp.MustCompile(`^template: [^:]*:(\d+):(?:(\d+):)?\s*(.*)$`)
+
+// 액션 시작 위치 탐색용
+var actionPattern = regexp.MustCompile(`\{\{`)
+
+// CheckText 텍스트 템플릿 문법 검사
+func CheckText(src string) error {
+	tmpl, err := template.New("check").Funcs(Funcs).Parse(src)
+	if err != nil {
+		return locate(src, err)
+	}
+	for _, t := range tmpl.Templates() {
+		if err := checkLiteralOnly(t.Tree); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CheckHTML HTML 템플릿 문법 및 컨텍스트 이스케이프 가능 여부 검사
+func CheckHTML(src string) error {
+	if err := CheckText(src); err != nil {
+		return err
+	}
+
+	tmpl, err := htmltemplate.New("check").Funcs(htmlFuncs).Parse(src)
+	if err != nil {
+		return locate(src, err)
+	}
+
+	// 레이아웃/부분 템플릿은 렌더링할 때 결합되므로 빈 템플릿으로 대신한다
+	names, err := ReferencedTemplates(src)
+	if err != nil {
+		return locate(src, err)
+	}
+	for _, name := range names {
+		if _, err := tmpl.New(name).Parse(""); err != nil {
+			return err
+		}
+	}
+
+	// 이스케이프 분석은 첫 실행 시 수행되므로 빈 데이터로 실행해 본다 (실행 오류는 무시)
+	err = tmpl.Execute(io.Discard, nil)
+	var escapeErr *htmltemplate.Error
+	if errors.As(err, &escapeErr) && escapeErr.ErrorCode != htmltemplate.ErrorCode(0) {
+		line := escapeErr.Line
+		if line == 0 {
+			line = 1
+		}
+		return &SyntaxError{Line: line, Column: 1, Message: escapeErr.Description}
+	}
+	return nil
+}
+
+// locate 파서 오류 메시지에서 줄을 찾고, 오류를 일으킨 액션을 찾아 열을 계산
+func locate(src string, err error) error {
+	m := parseErrorPattern.FindStringSubmatch(err.Error())
+	if m == nil {
+		return &SyntaxError{Line: 1, Column: 1, Message: err.Error()}
+	}
+
+	line, _ := strconv.Atoi(m[1])
+	message := m[3]
+	if m[2] != "" {
+		column, _ := strconv.Atoi(m[2])
+		return &SyntaxError{Line: line, Column: column, Message: message}
+	}
+
+	return &SyntaxError{Line: line, Column: errorColumn(src, err.Error(), line), Message: message}
+}
+
+// errorColumn 액션 단위로 앞부분만 파싱해 같은 오류가 처음 나타나는 액션의 열을 반환
+func errorColumn(src, fullErr string, line int) int {
+	for _, loc := range actionPattern.FindAllStringIndex(src, -1) {
+		start := loc[0]
+		end := strings.Index(src[start:], "}}")
+		if end < 0 {
+			end = len(src)
+		} else {
+			end += start + 2
+		}
+
+		if _, err := template.New("check").Funcs(Funcs).Parse(src[:end]); err != nil && err.Error() == fullErr {
+			return columnOf(src, start, line)
+		}
+	}
+
+	// 해당 줄의 첫 액션, 없으면 줄의 시작
+	lines := strings.Split(src, "\n")
+	if line >= 1 && line <= len(lines) {
+		if i := strings.Index(lines[line-1], "{{"); i >= 0 {
+			return len([]rune(lines[line-1][:i])) + 1
+		}
+	}
+	return 1
+}
+
+// columnOf 오프셋의 열 번호 (1부터, 문자 단위). 오프셋이 다른 줄이면 1
+func columnOf(src string, offset, line int) int {
+	if strings.Count(src[:offset], "\n")+1 != line {
+		return 1
+	}
+	lineStart := strings.LastIndex(src[:offset], "\n") + 1
+	return len([]rune(src[lineStart:offset])) + 1
+}
+
+// ReferencedVariables 템플릿들이 최상위 데이터에서 참조하는 변수 이름 (정렬됨)
+// range/with 블록 안의 .Field는 다른 값을 가리키므로 제외하고, $.Field는 포함한다.
+func ReferencedVariables(sources ...string) ([]string, error) {
+	found := make(map[string]bool)
+	for _, src := range sources {
+		if src == "" {
+			continue
+		}
+		tmpl, err := template.New("vars").Funcs(Funcs).Parse(src)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range tmpl.Templates() {
+			if t.Tree != nil && t.Tree.Root != nil {
+				walk(t.Tree.Root, true, found)
+			}
+		}
+	}
+
+	names := make([]string, 0, len(found))
+	for name := range found {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// walk rootDot이 true면 현재 dot이 최상위 데이터다
+func walk(node parse.Node, rootDot bool, found map[string]bool) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			walk(child, rootDot, found)
+		}
+	case *parse.ActionNode:
+		walk(n.Pipe, rootDot, found)
+	case *parse.IfNode:
+		walk(n.Pipe, rootDot, found)
+		walk(n.List, rootDot, found)
+		walk(n.ElseList, rootDot, found)
+	case *parse.RangeNode:
+		walk(n.Pipe, rootDot, found)
+		walk(n.List, false, found)
+		walk(n.ElseList, rootDot, found)
+	case *parse.WithNode:
+		walk(n.Pipe, rootDot, found)
+		walk(n.List, false, found)
+		walk(n.ElseList, rootDot, found)
+	case *parse.TemplateNode:
+		walk(n.Pipe, rootDot, found)
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		for _, cmd := range n.Cmds {
+			walk(cmd, rootDot, found)
+		}
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			walk(arg, rootDot, found)
+		}
+	case *parse.ChainNode:
+		walk(n.Node, rootDot, found)
+	case *parse.FieldNode:
+		if rootDot && len(n.Ident) > 0 {
+			found[n.Ident[0]] = true
+		}
+	case *parse.VariableNode:
+		if len(n.Ident) > 1 && n.Ident[0] == "$" {
+			found[n.Ident[1]] = true
+		}
+	}
+}
+
+// literalOnlyFuncs 이스케이프하지 않은 마크업을 출력하므로 문자열 리터럴 인자만 허용하는 함수
+// 변수를 넘기면 요청 값이 그대로 HTML에 들어간다.
+var literalOnlyFuncs = map[string]bool{msoFunc: true}
+
+// checkLiteralOnly literalOnlyFuncs 호출이 문자열 리터럴 하나만 인자로 받는지 검사 (파이프라인 입력도 허용하지 않음)
+func checkLiteralOnly(tree *parse.Tree) error {
+	cmd := findNonLiteralCall(tree.Root)
+	if cmd == nil {
+		return nil
+	}
+
+	line, column := 1, 1
+	location, _ := tree.ErrorContext(cmd)
+	if parts := strings.Split(location, ":"); len(parts) >= 3 {
+		line, _ = strconv.Atoi(parts[len(parts)-2])
+		column, _ = strconv.Atoi(parts[len(parts)-1])
+	}
+	name := cmd.Args[0].(*parse.IdentifierNode).Ident
+	return &SyntaxError{Line: line, Column: column, Message: fmt.Sprintf("%s accepts only a string literal argument", name)}
+}
+
+// findNonLiteralCall 리터럴이 아닌 인자로 literalOnlyFuncs를 호출하는 첫 명령
+func findNonLiteralCall(node parse.Node) *parse.CommandNode {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return nil
+		}
+		for _, child := range n.Nodes {
+			if cmd := findNonLiteralCall(child); cmd != nil {
+				return cmd
+			}
+		}
+	case *parse.ActionNode:
+		return findNonLiteralCall(n.Pipe)
+	case *parse.IfNode:
+		return findNonLiteralBranch(&n.BranchNode)
+	case *parse.RangeNode:
+		return findNonLiteralBranch(&n.BranchNode)
+	case *parse.WithNode:
+		return findNonLiteralBranch(&n.BranchNode)
+	case *parse.TemplateNode:
+		return findNonLiteralCall(n.Pipe)
+	case *parse.PipeNode:
+		if n == nil {
+			return nil
+		}
+		for i, cmd := range n.Cmds {
+			if ident, ok := cmd.Args[0].(*parse.IdentifierNode); ok && literalOnlyFuncs[ident.Ident] {
+				// 파이프라인 입력은 마지막 인자로 전달되므로 첫 명령이어야 한다
+				if i > 0 || len(cmd.Args) != 2 {
+					return cmd
+				}
+				if _, ok := cmd.Args[1].(*parse.StringNode); !ok {
+					return cmd
+				}
+			}
+			for _, arg := range cmd.Args {
+				if found := findNonLiteralCall(arg); found != nil {
+					return found
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func findNonLiteralBranch(n *parse.BranchNode) *parse.CommandNode {
+	if cmd := findNonLiteralCall(n.Pipe); cmd != nil {
+		return cmd
+	}
+	if cmd := findNonLiteralCall(n.List); cmd != nil {
+		return cmd
+	}
+	return findNonLiteralCall(n.ElseList)
+}
+
+// checkLiteralOnly 모든 본문/레이아웃/부분 템플릿의 literalOnlyFuncs 호출 검사
+// 저장 시 검사를 거치지 않은 소스도 있으므로 Compile에서 다시 확인한다. 문법 오류는 이후 파싱 단계에서 보고한다.
+func (s *Source) checkLiteralOnly() error {
+	sources := []string{s.Subject, s.HTML, s.Text, s.Preheader, s.Markdown}
+	if s.Layout != nil {
+		sources = append(sources, s.Layout.HTML, s.Layout.Text)
+	}
+	for _, partial := range s.Partials {
+		sources = append(sources, partial.HTML, partial.Text)
+	}
+
+	for _, src := range sources {
+		tmpl, err := template.New("check").Funcs(Funcs).Parse(src)
+		if err != nil {
+			continue
+		}
+		for _, t := range tmpl.Templates() {
+			if err := checkLiteralOnly(t.Tree); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package render
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCheckTextMSOLiteralOnly(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		wantErr bool
+	}{
+		{name: "string literal", src: `{{mso "<table><tr><td>"}}`},
+		{name: "raw string literal", src: "{{mso `<!--[if mso]>`}}"},
+		{name: "variable", src: `{{mso .payload}}`, wantErr: true},
+		{name: "pipeline input", src: `{{.payload | mso}}`, wantErr: true},
+		{name: "literal piped into mso", src: `{{"<b>" | mso}}`, wantErr: true},
+		{name: "nested call", src: `{{mso (printf "%s" .payload)}}`, wantErr: true},
+		{name: "inside if", src: `{{if .show}}{{mso .payload}}{{end}}`, wantErr: true},
+		{name: "inside range else", src: `{{range .items}}x{{else}}{{mso .payload}}{{end}}`, wantErr: true},
+		{name: "inside define", src: `{{define "x"}}{{mso .payload}}{{end}}`, wantErr: true},
+		{name: "as argument", src: `{{printf "%s" (mso .payload)}}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckText(tt.src)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CheckText() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckTextMSOPosition(t *testing.T) {
+	err := CheckText("<p>\n  {{mso .payload}}</p>")
+	var syntaxErr *SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		t.Fatalf("CheckText() error = %v, want *SyntaxError", err)
+	}
+	if syntaxErr.Line != 2 {
+		t.Errorf("Line = %d, want 2", syntaxErr.Line)
+	}
+}
+
+func TestCompileRejectsNonLiteralMSO(t *testing.T) {
+	sources := map[string]*Source{
+		"html":    {Subject: "s", HTML: `{{mso .payload}}`},
+		"layout":  {Subject: "s", HTML: "b", Layout: &Fragment{HTML: `{{mso .payload}}{{template "content" .}}`}},
+		"partial": {Subject: "s", HTML: `{{template "p" .}}`, Partials: map[string]Fragment{"p": {HTML: `{{mso .payload}}`}}},
+	}
+	for name, src := range sources {
+		t.Run(name, func(t *testing.T) {
+			_, err := Compile(src)
+			if err == nil || !strings.Contains(err.Error(), "string literal") {
+				t.Fatalf("Compile() error = %v, want string literal error", err)
+			}
+		})
+	}
+}
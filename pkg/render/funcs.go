@@ -0,0 +1,331 @@
+package render
+
+import (
+	"fmt"
+	htmltemplate "html/template"
+	"math"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+	"unicode/utf8"
+
+	// 컨테이너에 시간대 데이터가 없어도 LoadLocation이 동작하도록 포함
+	_ "time/tzdata"
+)
+
+// Funcs 제목/HTML/텍스트 템플릿에서 공통으로 사용하는 함수
+// 파이프라인으로 값을 넘길 수 있도록 값은 항상 마지막 인자다. 예) {{.name | default "고객"}}
+var Funcs = template.FuncMap{
+	"date":     formatDate,
+	"currency": formatCurrency,
+	"number":   formatNumber,
+	"plural":   plural,
+	"truncate": truncate,
+	"default":  defaultValue,
+	"upper":    strings.ToUpper,
+	"lower":    strings.ToLower,
+	"url":      buildURL,
+	msoFunc:    msoConditional,
+}
+
+var htmlFuncs = htmltemplate.FuncMap(Funcs)
+
+// 자주 쓰는 날짜 형식 이름
+var dateLayouts = map[string]string{
+	"date":     "2006-01-02",
+	"datetime": "2006-01-02 15:04",
+	"time":     "15:04",
+	"rfc3339":  time.RFC3339,
+	"ko":       "2006년 1월 2일",
+	"ko-long":  "2006년 1월 2일 15시 04분",
+	"ja":       "2006年1月2日",
+	"en":       "January 2, 2006",
+}
+
+// formatDate 날짜/시간 형식화: {{date "2006-01-02" .at}} 또는 {{date "ko" "Asia/Seoul" .at}}
+// 값은 time.Time, RFC3339/날짜 문자열, 유닉스 초(숫자)를 받는다. 시간대를 생략하면 UTC.
+func formatDate(layout string, args ...interface{}) (string, error) {
+	if len(args) == 0 || len(args) > 2 {
+		return "", fmt.Errorf("date: expected layout, [time zone,] value")
+	}
+
+	loc := time.UTC
+	if len(args) == 2 {
+		name, ok := args[0].(string)
+		if !ok {
+			return "", fmt.Errorf("date: time zone must be a string")
+		}
+		var err error
+		if loc, err = time.LoadLocation(name); err != nil {
+			return "", fmt.Errorf("date: unknown time zone %q", name)
+		}
+	}
+
+	t, err := toTime(args[len(args)-1])
+	if err != nil {
+		return "", fmt.Errorf("date: %v", err)
+	}
+
+	if named, ok := dateLayouts[layout]; ok {
+		layout = named
+	}
+	return t.In(loc).Format(layout), nil
+}
+
+func toTime(v interface{}) (time.Time, error) {
+	switch t := v.(type) {
+	case time.Time:
+		return t, nil
+	case *time.Time:
+		if t != nil {
+			return *t, nil
+		}
+	case string:
+		for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02"} {
+			if parsed, err := time.Parse(layout, t); err == nil {
+				return parsed, nil
+			}
+		}
+		return time.Time{}, fmt.Errorf("cannot parse %q as time", t)
+	default:
+		if f, ok := toNumber(v); ok {
+			sec, frac := math.Modf(f)
+			return time.Unix(int64(sec), int64(frac*1e9)), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("cannot use %T as time", v)
+}
+
+// 통화별 소수 자릿수와 기호
+var currencies = map[string]struct {
+	symbol   string
+	decimals int
+}{
+	"KRW": {"₩", 0},
+	"USD": {"$", 2},
+	"JPY": {"¥", 0},
+	"EUR": {"€", 2},
+}
+
+// 언어별 숫자 구분 기호
+var numberSeparators = map[string]struct{ group, decimal string }{
+	"ko": {",", "."},
+	"ja": {",", "."},
+	"en": {",", "."},
+	"de": {".", ","},
+	"fr": {" ", ","},
+}
+
+// formatCurrency 통화 형식화: {{currency "KRW" .price}} 또는 {{currency "USD" "en-US" .price}}
+// ko 언어의 KRW는 "12,000원", ja 언어의 JPY는 "1,200円"처럼 현지 표기를 사용한다.
+func formatCurrency(code string, args ...interface{}) (string, error) {
+	if len(args) == 0 || len(args) > 2 {
+		return "", fmt.Errorf("currency: expected code, [locale,] value")
+	}
+
+	code = strings.ToUpper(code)
+	c, ok := currencies[code]
+	if !ok {
+		return "", fmt.Errorf("currency: unsupported currency %q", code)
+	}
+
+	locale := ""
+	if len(args) == 2 {
+		if locale, ok = args[0].(string); !ok {
+			return "", fmt.Errorf("currency: locale must be a string")
+		}
+	}
+
+	amount, ok := toNumber(args[len(args)-1])
+	if !ok {
+		return "", fmt.Errorf("currency: cannot use %T as amount", args[len(args)-1])
+	}
+
+	lang := strings.ToLower(strings.SplitN(strings.ReplaceAll(locale, "_", "-"), "-", 2)[0])
+	sep, ok := numberSeparators[lang]
+	if !ok {
+		sep = numberSeparators["en"]
+	}
+
+	formatted := groupNumber(math.Abs(amount), c.decimals, sep.group, sep.decimal)
+
+	var result string
+	switch {
+	case lang == "ko" && code == "KRW":
+		result = formatted + "원"
+	case lang == "ja" && code == "JPY":
+		result = formatted + "円"
+	case lang == "de" || lang == "fr":
+		result = formatted + " " + c.symbol
+	default:
+		result = c.symbol + formatted
+	}
+
+	if isNegative(amount, formatted) {
+		result = "-" + result
+	}
+	return result, nil
+}
+
+// formatNumber 천 단위 구분: {{number .count}} 또는 소수 자릿수 지정 {{number 2 .ratio}}
+func formatNumber(args ...interface{}) (string, error) {
+	if len(args) == 0 || len(args) > 2 {
+		return "", fmt.Errorf("number: expected [decimals,] value")
+	}
+
+	decimals := 0
+	if len(args) == 2 {
+		d, ok := toNumber(args[0])
+		if !ok || d < 0 {
+			return "", fmt.Errorf("number: decimals must be a non-negative number")
+		}
+		decimals = int(d)
+	}
+
+	n, ok := toNumber(args[len(args)-1])
+	if !ok {
+		return "", fmt.Errorf("number: cannot use %T as number", args[len(args)-1])
+	}
+
+	formatted := groupNumber(math.Abs(n), decimals, ",", ".")
+	if isNegative(n, formatted) {
+		formatted = "-" + formatted
+	}
+	return formatted, nil
+}
+
+// groupNumber 0 이상의 수를 자릿수 구분 기호와 함께 형식화
+func groupNumber(n float64, decimals int, group, decimal string) string {
+	s := strconv.FormatFloat(n, 'f', decimals, 64)
+	intPart, fracPart, _ := strings.Cut(s, ".")
+
+	var b strings.Builder
+	for i, r := range intPart {
+		if i > 0 && (len(intPart)-i)%3 == 0 {
+			b.WriteString(group)
+		}
+		b.WriteRune(r)
+	}
+	if fracPart != "" {
+		b.WriteString(decimal)
+		b.WriteString(fracPart)
+	}
+	return b.String()
+}
+
+// isNegative 형식화한 값이 0이 아닌 음수인지 (반올림해서 0이 되면 "-0.00"처럼 부호를 붙이지 않는다)
+func isNegative(n float64, formatted string) bool {
+	return n < 0 && strings.ContainsAny(formatted, "123456789")
+}
+
+// plural 수량에 맞는 단어: {{.count}} {{plural .count "item" "items"}}
+func plural(count interface{}, singular, pluralForm string) (string, error) {
+	n, ok := toNumber(count)
+	if !ok {
+		return "", fmt.Errorf("plural: cannot use %T as count", count)
+	}
+	if n == 1 {
+		return singular, nil
+	}
+	return pluralForm, nil
+}
+
+// truncate 문자 수 기준으로 자르고 말줄임표 추가: {{.title | truncate 20}}
+func truncate(length int, s string) string {
+	if length < 0 || utf8.RuneCountInString(s) <= length {
+		return s
+	}
+	runes := []rune(s)
+	if length == 0 {
+		return ""
+	}
+	return string(runes[:length-1]) + "…"
+}
+
+// defaultValue 값이 비어 있으면 기본값 사용: {{.name | default "고객"}}
+func defaultValue(def interface{}, value ...interface{}) interface{} {
+	if len(value) == 0 || isEmpty(value[0]) {
+		return def
+	}
+	return value[0]
+}
+
+func isEmpty(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.String, reflect.Map, reflect.Slice, reflect.Array:
+		return rv.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return rv.IsNil()
+	default:
+		return rv.IsZero()
+	}
+}
+
+// buildURL 쿼리 파라미터를 인코딩해 URL 생성: {{url "https://example.com/orders" "id" .orderID "utm_source" "email"}}
+// http, https, mailto 이외의 스킴은 거부한다.
+func buildURL(base string, pairs ...interface{}) (string, error) {
+	if len(pairs)%2 != 0 {
+		return "", fmt.Errorf("url: query parameters must be key/value pairs")
+	}
+
+	u, err := url.Parse(base)
+	if err != nil {
+		return "", fmt.Errorf("url: %v", err)
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https", "mailto":
+	default:
+		return "", fmt.Errorf("url: unsupported scheme %q", u.Scheme)
+	}
+
+	query := u.Query()
+	for i := 0; i < len(pairs); i += 2 {
+		key, ok := pairs[i].(string)
+		if !ok {
+			return "", fmt.Errorf("url: query key must be a string")
+		}
+		query.Add(key, fmt.Sprint(pairs[i+1]))
+	}
+	u.RawQuery = query.Encode()
+
+	return u.String(), nil
+}
+
+// msoFunc Outlook 조건부 주석 함수 이름
+const msoFunc = "mso"
+
+// msoConditional Outlook(MSO)에서만 보이는 조건부 주석 마크업
+// html/template은 템플릿의 주석을 제거하므로 컴파일된 컴포넌트 마크업은 이 함수로 조건부 주석을 출력한다.
+// 인자는 그대로 출력되므로 문자열 리터럴만 허용한다 (저장 시와 Compile에서 checkLiteralOnly로 검사).
+func msoConditional(markup string) htmltemplate.HTML {
+	return htmltemplate.HTML("<!--[if mso | IE]>" + markup + "<![endif]-->")
+}
+
+// toNumber JSON 숫자(float64)와 Go 숫자 타입, 숫자 문자열을 float64로 변환
+func toNumber(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(n), 64)
+		return f, err == nil
+	case nil:
+		return 0, false
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	}
+	return 0, false
+}
@@ -0,0 +1,95 @@
+package render
+
+import (
+	"strings"
+	"testing"
+	"text/template"
+)
+
+func TestFuncs(t *testing.T) {
+	tests := []struct {
+		name    string
+		tmpl    string
+		data    interface{}
+		want    string
+		wantErr string
+	}{
+		// date
+		{name: "date named layout", tmpl: `{{date "ko" "2024-03-05"}}`, want: "2024년 3월 5일"},
+		{name: "date string with time zone to UTC", tmpl: `{{date "datetime" "2024-01-02T15:04:05+09:00"}}`, want: "2024-01-02 06:04"},
+		{name: "date string with time zone to location", tmpl: `{{date "datetime" "Asia/Seoul" "2024-01-01T21:30:00Z"}}`, want: "2024-01-02 06:30"},
+		{name: "date unix seconds", tmpl: `{{date "rfc3339" .at}}`, data: map[string]interface{}{"at": float64(86400)}, want: "1970-01-02T00:00:00Z"},
+		{name: "date custom layout", tmpl: `{{date "02/01/2006" "2024-03-05 10:00:00"}}`, want: "05/03/2024"},
+		{name: "date unknown time zone", tmpl: `{{date "date" "Mars/Base" "2024-01-01"}}`, wantErr: "unknown time zone"},
+		{name: "date unparsable", tmpl: `{{date "date" "yesterday"}}`, wantErr: "cannot parse"},
+
+		// currency
+		{name: "currency KRW ko", tmpl: `{{currency "KRW" "ko" 12000}}`, want: "12,000원"},
+		{name: "currency KRW default locale", tmpl: `{{currency "krw" 12000.4}}`, want: "₩12,000"},
+		{name: "currency EUR de", tmpl: `{{currency "EUR" "de" 1234.5}}`, want: "1.234,50\u00a0€"}, // 기호 앞은 줄바꿈하지 않는 공백
+		{name: "currency EUR fr", tmpl: `{{currency "EUR" "fr_FR" 1234.5}}`, want: "1\u202f234,50\u00a0€"},
+		{name: "currency JPY ja", tmpl: `{{currency "JPY" "ja-JP" 1200}}`, want: "1,200円"},
+		{name: "currency negative", tmpl: `{{currency "USD" "en-US" -1234.5}}`, want: "-$1,234.50"},
+		{name: "currency negative KRW ko", tmpl: `{{currency "KRW" "ko" -500}}`, want: "-500원"},
+		{name: "currency rounds to zero", tmpl: `{{currency "USD" -0.001}}`, want: "$0.00"},
+		{name: "currency string amount", tmpl: `{{.price | currency "USD"}}`, data: map[string]interface{}{"price": "9.99"}, want: "$9.99"},
+		{name: "currency unsupported", tmpl: `{{currency "GBP" 1}}`, wantErr: "unsupported currency"},
+		{name: "currency not a number", tmpl: `{{currency "USD" "free"}}`, wantErr: "cannot use"},
+
+		// number
+		{name: "number", tmpl: `{{number 1234567}}`, want: "1,234,567"},
+		{name: "number 2 decimals", tmpl: `{{number 2 3.14159}}`, want: "3.14"},
+		{name: "number negative", tmpl: `{{number 2 -1234.5}}`, want: "-1,234.50"},
+		{name: "number rounds to zero", tmpl: `{{number 2 -0.001}}`, want: "0.00"},
+		{name: "number negative decimals", tmpl: `{{number -1 3}}`, wantErr: "non-negative"},
+
+		// plural
+		{name: "plural one", tmpl: `{{plural 1 "item" "items"}}`, want: "item"},
+		{name: "plural many", tmpl: `{{plural .n "item" "items"}}`, data: map[string]interface{}{"n": float64(3)}, want: "items"},
+
+		// truncate
+		{name: "truncate 0", tmpl: `{{truncate 0 "안녕하세요"}}`, want: ""},
+		{name: "truncate 1", tmpl: `{{truncate 1 "안녕하세요"}}`, want: "…"},
+		{name: "truncate n", tmpl: `{{"안녕하세요" | truncate 3}}`, want: "안녕…"},
+		{name: "truncate shorter", tmpl: `{{truncate 10 "hello"}}`, want: "hello"},
+
+		// default, upper, lower
+		{name: "default empty", tmpl: `{{.name | default "고객"}}`, data: map[string]interface{}{"name": ""}, want: "고객"},
+		{name: "default missing", tmpl: `{{default "고객" .name}}`, data: map[string]interface{}{}, want: "고객"},
+		{name: "default set", tmpl: `{{.name | default "고객"}}`, data: map[string]interface{}{"name": "Tom"}, want: "Tom"},
+		{name: "upper", tmpl: `{{upper "abc"}}`, want: "ABC"},
+		{name: "lower", tmpl: `{{lower "ABC"}}`, want: "abc"},
+
+		// url
+		{name: "url query", tmpl: `{{url "https://example.com/orders?a=1" "id" "7 & 8" "utm_source" "email"}}`, want: "https://example.com/orders?a=1&id=7+%26+8&utm_source=email"},
+		{name: "url mailto", tmpl: `{{url "mailto:help@example.com"}}`, want: "mailto:help@example.com"},
+		{name: "url javascript scheme", tmpl: `{{url "javascript:alert(1)"}}`, wantErr: "unsupported scheme"},
+		{name: "url odd pairs", tmpl: `{{url "https://example.com" "id"}}`, wantErr: "key/value pairs"},
+
+		// mso
+		{name: "mso", tmpl: `{{mso "<table>"}}`, want: "<!--[if mso | IE]><table><![endif]-->"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := template.New("").Funcs(Funcs).Option("missingkey=zero").Parse(tt.tmpl)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var b strings.Builder
+			err = tmpl.Execute(&b, tt.data)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if b.String() != tt.want {
+				t.Errorf("got %q, want %q", b.String(), tt.want)
+			}
+		})
+	}
+}
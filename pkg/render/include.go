@@ -0,0 +1,126 @@
+package render
+
+import (
+	"sort"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+// CycleError 부분 템플릿이 서로를 포함하는 순환 참조
+type CycleError struct {
+	Path []string // 순환 경로 (첫 이름과 마지막 이름이 같다)
+}
+
+func (e *CycleError) Error() string {
+	return "template include cycle: " + strings.Join(e.Path, " -> ")
+}
+
+// ReferencedTemplates 템플릿들이 {{template "name"}}으로 포함하는 템플릿 이름 (정렬됨)
+// 같은 소스 안에서 {{define}}으로 정의한 템플릿은 제외한다.
+func ReferencedTemplates(sources ...string) ([]string, error) {
+	found := make(map[string]bool)
+	for _, src := range sources {
+		if src == "" {
+			continue
+		}
+		tmpl, err := template.New("refs").Funcs(Funcs).Parse(src)
+		if err != nil {
+			return nil, err
+		}
+
+		defined := make(map[string]bool)
+		refs := make(map[string]bool)
+		for _, t := range tmpl.Templates() {
+			defined[t.Name()] = true
+			if t.Tree != nil && t.Tree.Root != nil {
+				walkTemplates(t.Tree.Root, refs)
+			}
+		}
+		for name := range refs {
+			if !defined[name] {
+				found[name] = true
+			}
+		}
+	}
+
+	names := make([]string, 0, len(found))
+	for name := range found {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func walkTemplates(node parse.Node, found map[string]bool) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			walkTemplates(child, found)
+		}
+	case *parse.IfNode:
+		walkTemplates(n.List, found)
+		walkTemplates(n.ElseList, found)
+	case *parse.RangeNode:
+		walkTemplates(n.List, found)
+		walkTemplates(n.ElseList, found)
+	case *parse.WithNode:
+		walkTemplates(n.List, found)
+		walkTemplates(n.ElseList, found)
+	case *parse.TemplateNode:
+		found[n.Name] = true
+	}
+}
+
+// FindCycle 포함 관계 그래프에서 순환 경로를 찾는다 (없으면 nil)
+// 그래프에 없는 이름(아직 정의되지 않은 템플릿)은 끝점으로 본다.
+func FindCycle(graph map[string][]string) []string {
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make(map[string]int, len(graph))
+	var stack []string
+
+	var visit func(name string) []string
+	visit = func(name string) []string {
+		switch state[name] {
+		case visiting:
+			for i, n := range stack {
+				if n == name {
+					return append(append([]string{}, stack[i:]...), name)
+				}
+			}
+		case done:
+			return nil
+		}
+
+		state[name] = visiting
+		stack = append(stack, name)
+		for _, next := range graph[name] {
+			if cycle := visit(next); cycle != nil {
+				return cycle
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[name] = done
+		return nil
+	}
+
+	// 결과가 항상 같도록 이름순으로 탐색
+	names := make([]string, 0, len(graph))
+	for name := range graph {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if cycle := visit(name); cycle != nil {
+			return cycle
+		}
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+// 마크다운 변환기 (GFM 표/취소선/자동 링크 지원, 원시 HTML은 출력하지 않는다)
+var markdown = goldmark.New(goldmark.WithExtensions(extension.GFM))
+
+var (
+	markdownImagePattern = regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`)
+	markdownLinkPattern  = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)(?:\s+"[^"]*")?\)`)
+)
+
+// MarkdownToHTML 마크다운을 HTML로 변환
+func MarkdownToHTML(src string) (string, error) {
+	var buf bytes.Buffer
+	if err := markdown.Convert([]byte(src), &buf); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// MarkdownToText 마크다운 원문을 텍스트 본문으로 정리
+// 마크다운은 그대로도 읽을 수 있으므로 링크만 "텍스트 [URL]", 이미지는 대체 텍스트로 바꾼다.
+func MarkdownToText(src string) string {
+	text := markdownImagePattern.ReplaceAllString(src, "$1")
+	text = markdownLinkPattern.ReplaceAllString(text, "$1 [$2]")
+	return text
+}
+
+// markdownEscapeFunc 마크다운 본문의 출력 액션 끝에 붙이는 이스케이프 함수 이름
+const markdownEscapeFunc = "markdownEscape"
+
+// EscapeMarkdown 마크다운 문법으로 해석되지 않도록 ASCII 구두점을 백슬래시로 이스케이프
+// 변수 값의 링크, 강조, 제목, HTML 태그 등이 본문 마크업이 되지 않고 글자 그대로 표시된다.
+// 코드 스팬/코드 블록 안에서는 백슬래시 이스케이프가 처리되지 않으므로 변수는 코드 밖에 둔다.
+func EscapeMarkdown(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, r := range s {
+		if strings.ContainsRune("!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~", r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// markdownEscapeValue 파이프라인 결과를 문자열로 바꿔 이스케이프 (값이 없으면 빈 문자열)
+func markdownEscapeValue(args ...interface{}) string {
+	if len(args) == 1 && args[0] == nil {
+		return ""
+	}
+	return EscapeMarkdown(fmt.Sprint(args...))
+}
+
+// escapeMarkdownActions 마크다운 템플릿의 모든 출력 액션에 이스케이프 함수를 붙인다
+// 변수는 마크다운 변환 전에 적용되므로, 이스케이프하지 않으면 변수 값이 링크나 마크업으로 변환된다.
+// 부분 템플릿 포함({{template}})은 템플릿 작성자의 마크다운이므로 그대로 둔다.
+func escapeMarkdownActions(tmpl *template.Template) {
+	for _, t := range tmpl.Templates() {
+		escapeMarkdownNode(t.Tree, t.Tree.Root)
+	}
+	tmpl.Funcs(template.FuncMap{markdownEscapeFunc: markdownEscapeValue})
+}
+
+func escapeMarkdownNode(tree *parse.Tree, node parse.Node) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			escapeMarkdownNode(tree, child)
+		}
+	case *parse.ActionNode:
+		// 변수 선언({{$x := ...}})은 출력하지 않는다
+		if len(n.Pipe.Decl) > 0 {
+			return
+		}
+		n.Pipe.Cmds = append(n.Pipe.Cmds, &parse.CommandNode{
+			NodeType: parse.NodeCommand,
+			Pos:      n.Pipe.Pos,
+			Args:     []parse.Node{parse.NewIdentifier(markdownEscapeFunc).SetTree(tree).SetPos(n.Pipe.Pos)},
+		})
+	case *parse.IfNode:
+		escapeMarkdownNode(tree, n.List)
+		escapeMarkdownNode(tree, n.ElseList)
+	case *parse.RangeNode:
+		escapeMarkdownNode(tree, n.List)
+		escapeMarkdownNode(tree, n.ElseList)
+	case *parse.WithNode:
+		escapeMarkdownNode(tree, n.List)
+		escapeMarkdownNode(tree, n.ElseList)
+	}
+}
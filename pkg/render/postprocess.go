@@ -0,0 +1,417 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/andybalholm/cascadia"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// GmailClipSize Gmail이 본문을 잘라 "메시지 전체 보기" 링크로 대신하는 HTML 크기
+const GmailClipSize = 102 * 1024
+
+// 전체 문서인지 본문 조각인지 판단
+var documentPattern = regexp.MustCompile(`(?i)<(!doctype|html|body)[\s>]`)
+
+// 연속 공백 (줄바꿈 없는 공백 U+00A0은 의도한 것이므로 포함하지 않는다)
+var whitespacePattern = regexp.MustCompile(`[ \t\n\r\f]+`)
+
+// 인라인할 수 없는 선택자 (상태/의사 요소는 <style>에 남긴다)
+var dynamicSelectorPattern = regexp.MustCompile(`(?i)::|:(hover|focus|active|visited|link|target|checked|focus-within|focus-visible)\b`)
+
+// 공백만 있는 텍스트를 지워도 표시가 바뀌지 않는 요소
+var blockContainers = map[atom.Atom]bool{
+	atom.Html: true, atom.Head: true, atom.Body: true, atom.Table: true, atom.Thead: true,
+	atom.Tbody: true, atom.Tfoot: true, atom.Tr: true, atom.Ul: true, atom.Ol: true,
+}
+
+// 공백을 그대로 유지해야 하는 요소
+var preformatted = map[atom.Atom]bool{
+	atom.Pre: true, atom.Textarea: true, atom.Script: true, atom.Style: true,
+}
+
+// PostProcess 렌더링된 HTML을 이메일 클라이언트에 맞게 후처리
+// <style> 규칙을 각 요소의 style 속성으로 인라인하고, 숨겨진 미리보기 문구(preheader)를 넣고, 공백을 줄인다.
+// 미디어 쿼리나 :hover처럼 인라인할 수 없는 규칙은 <style>에 남긴다. data-embed 속성이 있는 <style>은 건드리지 않는다.
+func PostProcess(src, preheader string) (string, []string, error) {
+	if strings.TrimSpace(src) == "" {
+		return src, nil, nil
+	}
+
+	document := documentPattern.MatchString(src)
+	root, err := parseHTML(src, document)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to parse HTML: %v", err)
+	}
+
+	inlineCSS(root)
+	if preheader != "" {
+		insertPreheader(root, preheader)
+	}
+	minify(root)
+
+	var buf bytes.Buffer
+	for c := root.FirstChild; c != nil; c = c.NextSibling {
+		if err := html.Render(&buf, c); err != nil {
+			return "", nil, fmt.Errorf("failed to render HTML: %v", err)
+		}
+	}
+	out := buf.String()
+
+	var warnings []string
+	if len(out) > GmailClipSize {
+		warnings = append(warnings, fmt.Sprintf("HTML is %d bytes; Gmail clips messages larger than %d bytes", len(out), GmailClipSize))
+	}
+	return out, warnings, nil
+}
+
+// parseHTML 전체 문서는 그대로, 조각은 <body> 안의 내용으로 파싱해 문서 노드 아래에 둔다
+func parseHTML(src string, document bool) (*html.Node, error) {
+	if document {
+		return html.Parse(strings.NewReader(src))
+	}
+
+	context := &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+	nodes, err := html.ParseFragment(strings.NewReader(src), context)
+	if err != nil {
+		return nil, err
+	}
+
+	root := &html.Node{Type: html.DocumentNode}
+	for _, n := range nodes {
+		root.AppendChild(n)
+	}
+	return root, nil
+}
+
+type cssDeclaration struct {
+	property  string
+	value     string
+	important bool
+}
+
+type cssRule struct {
+	selector     string
+	declarations []cssDeclaration
+}
+
+// appliedDeclaration 요소에 적용될 선언과 우선순위 정보
+type appliedDeclaration struct {
+	cssDeclaration
+	inline      bool
+	specificity cascadia.Specificity
+	order       int
+}
+
+// wins CSS 우선순위: !important > 인라인 style > 선택자 명시도 > 나중에 선언된 규칙
+func (a appliedDeclaration) wins(b appliedDeclaration) bool {
+	if a.important != b.important {
+		return a.important
+	}
+	if a.inline != b.inline {
+		return a.inline
+	}
+	if a.specificity != b.specificity {
+		return b.specificity.Less(a.specificity)
+	}
+	return a.order > b.order
+}
+
+// inlineCSS <style> 규칙을 요소의 style 속성으로 옮긴다
+func inlineCSS(root *html.Node) {
+	var styles []*html.Node
+	walkNodes(root, func(n *html.Node) {
+		if n.Type != html.ElementNode || n.DataAtom != atom.Style {
+			return
+		}
+		if hasAttr(n, "data-embed") {
+			removeAttr(n, "data-embed")
+			return
+		}
+		styles = append(styles, n)
+	})
+	if len(styles) == 0 {
+		return
+	}
+
+	var css strings.Builder
+	for _, s := range styles {
+		for c := s.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type == html.TextNode {
+				css.WriteString(c.Data)
+				css.WriteString("\n")
+			}
+		}
+	}
+	rules, residual := parseCSS(css.String())
+
+	applied := make(map[*html.Node][]appliedDeclaration)
+	var matched []*html.Node
+	order := 0
+	for _, rule := range rules {
+		if dynamicSelectorPattern.MatchString(rule.selector) {
+			residual = append(residual, rule.selector+"{"+declarationText(rule.declarations)+"}")
+			continue
+		}
+		sel, err := cascadia.Parse(rule.selector)
+		if err != nil {
+			residual = append(residual, rule.selector+"{"+declarationText(rule.declarations)+"}")
+			continue
+		}
+		for _, n := range cascadia.QueryAll(root, sel) {
+			if _, ok := applied[n]; !ok {
+				matched = append(matched, n)
+			}
+			for _, d := range rule.declarations {
+				order++
+				applied[n] = append(applied[n], appliedDeclaration{cssDeclaration: d, specificity: sel.Specificity(), order: order})
+			}
+		}
+	}
+
+	for _, n := range matched {
+		declarations := applied[n]
+		for _, d := range parseDeclarations(getAttr(n, "style")) {
+			order++
+			declarations = append(declarations, appliedDeclaration{cssDeclaration: d, inline: true, order: order})
+		}
+		setAttr(n, "style", resolveDeclarations(declarations))
+	}
+
+	// 인라인할 수 없는 규칙은 첫 번째 <style>에 모으고 나머지는 제거
+	for i, s := range styles {
+		if i == 0 && len(residual) > 0 {
+			for c := s.FirstChild; c != nil; {
+				next := c.NextSibling
+				s.RemoveChild(c)
+				c = next
+			}
+			s.AppendChild(&html.Node{Type: html.TextNode, Data: strings.Join(residual, "\n")})
+			continue
+		}
+		s.Parent.RemoveChild(s)
+	}
+}
+
+// resolveDeclarations 속성별로 우선순위가 가장 높은 선언만 남긴 style 값 (처음 나타난 순서 유지)
+func resolveDeclarations(declarations []appliedDeclaration) string {
+	winners := make(map[string]appliedDeclaration)
+	var properties []string
+	for _, d := range declarations {
+		current, ok := winners[d.property]
+		if !ok {
+			properties = append(properties, d.property)
+		}
+		if !ok || d.wins(current) {
+			winners[d.property] = d
+		}
+	}
+
+	parts := make([]string, 0, len(properties))
+	for _, p := range properties {
+		d := winners[p]
+		value := d.value
+		if d.important {
+			value += " !important"
+		}
+		parts = append(parts, p+": "+value)
+	}
+	return strings.Join(parts, "; ")
+}
+
+// parseCSS 스타일시트를 선택자별 규칙과 인라인할 수 없는 @규칙(원문)으로 분리
+func parseCSS(css string) ([]cssRule, []string) {
+	css = stripComments(css)
+
+	var rules []cssRule
+	var residual []string
+	for {
+		open := strings.Index(css, "{")
+		if open < 0 {
+			break
+		}
+		prelude := strings.TrimSpace(css[:open])
+
+		// 짝이 맞는 닫는 괄호 탐색 (@media 안의 중첩 블록 포함)
+		depth, end := 0, -1
+		for i := open; i < len(css); i++ {
+			if css[i] == '{' {
+				depth++
+			} else if css[i] == '}' {
+				depth--
+				if depth == 0 {
+					end = i
+					break
+				}
+			}
+		}
+		if end < 0 {
+			break
+		}
+		body := css[open+1 : end]
+		css = css[end+1:]
+
+		if strings.HasPrefix(prelude, "@") {
+			residual = append(residual, prelude+"{"+body+"}")
+			continue
+		}
+
+		declarations := parseDeclarations(body)
+		for _, selector := range strings.Split(prelude, ",") {
+			if selector = strings.TrimSpace(selector); selector != "" {
+				rules = append(rules, cssRule{selector: selector, declarations: declarations})
+			}
+		}
+	}
+	return rules, residual
+}
+
+// parseDeclarations "color: red; margin: 0 !important" 형식의 선언 목록
+func parseDeclarations(text string) []cssDeclaration {
+	var declarations []cssDeclaration
+	for _, part := range strings.Split(text, ";") {
+		property, value, ok := strings.Cut(part, ":")
+		if !ok {
+			continue
+		}
+		property = strings.ToLower(strings.TrimSpace(property))
+		value = strings.TrimSpace(value)
+
+		important := false
+		if i := strings.Index(strings.ToLower(value), "!important"); i >= 0 {
+			important = true
+			value = strings.TrimSpace(value[:i])
+		}
+		if property == "" || value == "" {
+			continue
+		}
+		declarations = append(declarations, cssDeclaration{property: property, value: value, important: important})
+	}
+	return declarations
+}
+
+func declarationText(declarations []cssDeclaration) string {
+	parts := make([]string, 0, len(declarations))
+	for _, d := range declarations {
+		value := d.value
+		if d.important {
+			value += " !important"
+		}
+		parts = append(parts, d.property+":"+value)
+	}
+	return strings.Join(parts, ";")
+}
+
+func stripComments(css string) string {
+	var b strings.Builder
+	for {
+		start := strings.Index(css, "/*")
+		if start < 0 {
+			b.WriteString(css)
+			return b.String()
+		}
+		b.WriteString(css[:start])
+		end := strings.Index(css[start+2:], "*/")
+		if end < 0 {
+			return b.String()
+		}
+		css = css[start+2+end+2:]
+	}
+}
+
+// preheaderStyle 본문에는 보이지 않고 받은편지함 미리보기에만 노출되는 스타일
+const preheaderStyle = "display: none; font-size: 1px; line-height: 1px; max-height: 0; max-width: 0; opacity: 0; overflow: hidden; mso-hide: all"
+
+// insertPreheader 본문 맨 앞에 숨겨진 미리보기 문구 삽입
+// 뒤에 공백 문자를 채워 미리보기에 본문 내용이 이어서 노출되지 않도록 한다.
+func insertPreheader(root *html.Node, preheader string) {
+	parent := root
+	walkNodes(root, func(n *html.Node) {
+		if parent == root && n.Type == html.ElementNode && n.DataAtom == atom.Body {
+			parent = n
+		}
+	})
+
+	div := &html.Node{
+		Type:     html.ElementNode,
+		Data:     "div",
+		DataAtom: atom.Div,
+		Attr:     []html.Attribute{{Key: "style", Val: preheaderStyle}},
+	}
+	div.AppendChild(&html.Node{Type: html.TextNode, Data: preheader + strings.Repeat("\u200c\u00a0", 90)})
+	parent.InsertBefore(div, parent.FirstChild)
+}
+
+// minify 연속 공백을 하나로 줄이고 블록 사이의 빈 텍스트와 일반 주석 제거 (Outlook 조건부 주석은 유지)
+func minify(n *html.Node) {
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+		switch c.Type {
+		case html.CommentNode:
+			if !strings.HasPrefix(strings.TrimSpace(c.Data), "[if") && !strings.HasPrefix(strings.TrimSpace(c.Data), "<![endif") {
+				n.RemoveChild(c)
+			}
+		case html.TextNode:
+			if n.Type == html.ElementNode && preformatted[n.DataAtom] {
+				break
+			}
+			c.Data = whitespacePattern.ReplaceAllString(c.Data, " ")
+			if c.Data == " " && (n.Type == html.DocumentNode || blockContainers[n.DataAtom]) {
+				n.RemoveChild(c)
+			}
+		case html.ElementNode:
+			if !preformatted[c.DataAtom] {
+				minify(c)
+			}
+		}
+		c = next
+	}
+}
+
+func walkNodes(n *html.Node, fn func(*html.Node)) {
+	fn(n)
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		walkNodes(c, fn)
+	}
+}
+
+func hasAttr(n *html.Node, key string) bool {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+func getAttr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func removeAttr(n *html.Node, key string) {
+	for i, a := range n.Attr {
+		if a.Key == key {
+			n.Attr = append(n.Attr[:i], n.Attr[i+1:]...)
+			return
+		}
+	}
+}
+
+func setAttr(n *html.Node, key, value string) {
+	for i, a := range n.Attr {
+		if a.Key == key {
+			n.Attr[i].Val = value
+			return
+		}
+	}
+	n.Attr = append(n.Attr, html.Attribute{Key: key, Val: value})
+}
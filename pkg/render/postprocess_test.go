@@ -0,0 +1,78 @@
+package render
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPostProcessInlinesCSS(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want string
+	}{
+		{
+			name: "specificity and inline style",
+			html: `<style>p { color: red; margin: 0 } .note { color: blue } #x { color: green }</style><p class="note" id="x" style="margin: 4px">hi</p>`,
+			want: `<p class="note" id="x" style="color: green; margin: 4px">hi</p>`,
+		},
+		{
+			name: "important beats inline style",
+			html: `<style>p { color: red !important }</style><p style="color: blue">hi</p>`,
+			want: `<p style="color: red !important">hi</p>`,
+		},
+		{
+			name: "later rule wins at equal specificity",
+			html: `<style>.a { color: red } .b { color: blue }</style><p class="b a">hi</p>`,
+			want: `<p class="b a" style="color: blue">hi</p>`,
+		},
+		{
+			name: "media queries and hover stay in style",
+			html: `<style>a { color: red } a:hover { color: blue } @media (max-width: 600px) { a { color: green } }</style><a href="#">x</a>`,
+			want: `<style>@media (max-width: 600px){ a { color: green } }` + "\n" + `a:hover{color:blue}</style><a href="#" style="color: red">x</a>`,
+		},
+		{
+			name: "embedded style is kept as is",
+			html: `<style data-embed>p { color: red }</style><p>hi</p>`,
+			want: `<style>p { color: red }</style><p>hi</p>`,
+		},
+		{
+			name: "whitespace and comments",
+			html: "<table>\n  <tr>\n    <td>a   b</td>\n  </tr>\n</table><!-- note --><!--[if mso]>x<![endif]--><pre>a   b</pre>",
+			want: "<table><tbody><tr><td>a b</td></tr></tbody></table><!--[if mso]>x<![endif]--><pre>a   b</pre>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, err := PostProcess(tt.html, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("PostProcess() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPostProcessPreheader(t *testing.T) {
+	got, _, err := PostProcess(`<html><body><p>hi</p></body></html>`, "Your order shipped")
+	if err != nil {
+		t.Fatal(err)
+	}
+	i := strings.Index(got, "<body><div style=\""+preheaderStyle+"\">Your order shipped")
+	if i < 0 || i > strings.Index(got, "<p>hi</p>") {
+		t.Errorf("preheader is not the first element of the body:\n%s", got)
+	}
+}
+
+func TestPostProcessWarnsAboutGmailClipping(t *testing.T) {
+	_, warnings, err := PostProcess("<p>"+strings.Repeat("x", GmailClipSize)+"</p>", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "Gmail clips") {
+		t.Errorf("warnings = %v", warnings)
+	}
+}
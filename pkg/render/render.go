@@ -0,0 +1,284 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	"text/template"
+)
+
+// ContentTemplate 레이아웃에서 본문을 삽입하는 템플릿 이름 ({{template "content" .}})
+const ContentTemplate = "content"
+
+// 마크다운 본문에 변수를 적용하고 변환한 결과를 레이아웃에 넣는 함수 (결과를 다시 템플릿으로 실행하지 않기 위해 사용)
+// 본문 위치의 데이터(레이아웃이 "content"에 넘긴 값)로 마크다운 본문을 실행한다.
+const (
+	markdownHTMLFunc = "markdownHTML"
+	markdownTextFunc = "markdownText"
+)
+
+// Fragment 레이아웃 또는 부분 템플릿 본문
+type Fragment struct {
+	HTML string
+	Text string
+}
+
+// Source 렌더링할 템플릿 본문과 함께 사용할 레이아웃/부분 템플릿
+type Source struct {
+	Subject   string
+	HTML      string
+	Text      string
+	Preheader string // 받은편지함 미리보기 문구 (HTML 본문 앞에 숨겨서 삽입)
+
+	// 마크다운 본문 (설정되면 HTML 대신 사용하고, Text가 비어 있으면 텍스트 본문으로도 사용)
+	Markdown string
+
+	Layout   *Fragment           // nil이면 본문만 렌더링
+	Partials map[string]Fragment // {{template "name" .}}으로 포함하는 부분 템플릿
+}
+
+// Rendered 변수가 적용된 최종 본문
+type Rendered struct {
+	Subject string
+	HTML    string
+	Text    string
+
+	Warnings []string // 후처리 경고 (e.g., Gmail 잘림 크기 초과)
+}
+
+// Template 파싱이 끝난 렌더링 템플릿 (여러 고루틴에서 동시에 Execute할 수 있다)
+type Template struct {
+	subject   *template.Template
+	html      *htmltemplate.Template
+	preheader *template.Template
+	text      *template.Template // nil이면 HTML에서 텍스트 본문을 생성
+	markdown  *template.Template // nil이면 마크다운 본문 없음 (변수 값은 마크다운 이스케이프)
+
+	// 변수 값을 이스케이프하지 않은 마크다운 본문 (텍스트 본문 생성용, 텍스트에는 백슬래시가 남지 않아야 한다)
+	markdownText *template.Template
+}
+
+// Render 제목/HTML/텍스트 템플릿에 변수를 적용
+// 레이아웃이 있으면 본문을 "content" 템플릿으로 정의하고 레이아웃을 실행한다.
+func Render(src *Source, variables map[string]interface{}) (*Rendered, error) {
+	tmpl, err := Compile(src)
+	if err != nil {
+		return nil, err
+	}
+	return tmpl.Execute(variables)
+}
+
+// Compile 제목/HTML/텍스트 템플릿과 레이아웃/부분 템플릿을 파싱
+func Compile(src *Source) (*Template, error) {
+	if cycle := FindCycle(src.graph()); cycle != nil {
+		return nil, &CycleError{Path: cycle}
+	}
+	if err := src.checkLiteralOnly(); err != nil {
+		return nil, err
+	}
+
+	t := &Template{}
+	var err error
+
+	// 제목 (헤더이므로 HTML 이스케이프하지 않는다)
+	if t.subject, err = template.New("subject").Funcs(Funcs).Parse(src.Subject); err != nil {
+		return nil, fmt.Errorf("failed to parse subject template: %v", err)
+	}
+
+	// 마크다운 본문 (변수 적용 후 HTML로 변환해 본문 위치에 넣는다)
+	if src.Markdown != "" {
+		if t.markdown, err = src.composeMarkdown(); err != nil {
+			return nil, fmt.Errorf("failed to parse markdown template: %v", err)
+		}
+		escapeMarkdownActions(t.markdown)
+		if t.markdownText, err = src.composeMarkdown(); err != nil {
+			return nil, fmt.Errorf("failed to parse markdown template: %v", err)
+		}
+	}
+
+	// HTML 템플릿
+	body := src.HTML
+	if t.markdown != nil {
+		body = "{{" + markdownHTMLFunc + " .}}"
+	}
+	t.html, err = src.composeHTML(body, htmltemplate.FuncMap{
+		markdownHTMLFunc: func(data interface{}) (htmltemplate.HTML, error) {
+			markdown, err := executeMarkdown(t.markdown, data)
+			if err != nil {
+				return "", err
+			}
+			converted, err := MarkdownToHTML(markdown)
+			if err != nil {
+				return "", fmt.Errorf("failed to convert markdown: %v", err)
+			}
+			return htmltemplate.HTML(converted), nil
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse HTML template: %v", err)
+	}
+
+	// 미리보기 문구 (HTML에 텍스트로 삽입되므로 여기서는 이스케이프하지 않는다)
+	if t.preheader, err = template.New("preheader").Funcs(Funcs).Parse(src.Preheader); err != nil {
+		return nil, fmt.Errorf("failed to parse preheader template: %v", err)
+	}
+
+	// Text 템플릿
+	// 텍스트 본문이 없으면 마크다운 원문 또는 HTML에서 생성 (빈 text/plain 파트는 스팸 점수에 불리하다)
+	if strings.TrimSpace(src.Text) != "" || t.markdown != nil {
+		body := src.Text
+		if strings.TrimSpace(body) == "" {
+			body = "{{" + markdownTextFunc + " .}}"
+		}
+		t.text, err = src.composeText(body, template.FuncMap{
+			markdownTextFunc: func(data interface{}) (string, error) {
+				markdown, err := executeMarkdown(t.markdownText, data)
+				if err != nil {
+					return "", err
+				}
+				return MarkdownToText(markdown), nil
+			},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse text template: %v", err)
+		}
+	}
+
+	return t, nil
+}
+
+// Execute 파싱된 템플릿에 변수를 적용하고 HTML 후처리
+func (t *Template) Execute(variables map[string]interface{}) (*Rendered, error) {
+	var subjectBuffer bytes.Buffer
+	if err := t.subject.Execute(&subjectBuffer, variables); err != nil {
+		return nil, fmt.Errorf("failed to execute subject template: %v", err)
+	}
+
+	var htmlBuffer bytes.Buffer
+	if err := t.html.Execute(&htmlBuffer, variables); err != nil {
+		return nil, fmt.Errorf("failed to execute HTML template: %v", err)
+	}
+
+	var preheaderBuffer bytes.Buffer
+	if err := t.preheader.Execute(&preheaderBuffer, variables); err != nil {
+		return nil, fmt.Errorf("failed to execute preheader template: %v", err)
+	}
+
+	// CSS 인라인, 미리보기 문구 삽입, 공백 정리
+	processedHTML, warnings, err := PostProcess(htmlBuffer.String(), strings.TrimSpace(preheaderBuffer.String()))
+	if err != nil {
+		return nil, err
+	}
+
+	var text string
+	if t.text != nil {
+		var textBuffer bytes.Buffer
+		if err := t.text.Execute(&textBuffer, variables); err != nil {
+			return nil, fmt.Errorf("failed to execute text template: %v", err)
+		}
+		text = textBuffer.String()
+	} else {
+		text = HTMLToText(htmlBuffer.String())
+	}
+
+	return &Rendered{
+		Subject:  subjectBuffer.String(),
+		HTML:     processedHTML,
+		Text:     text,
+		Warnings: warnings,
+	}, nil
+}
+
+// executeMarkdown 마크다운 본문에 변수 적용
+func executeMarkdown(tmpl *template.Template, data interface{}) (string, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to execute markdown template: %v", err)
+	}
+	return buf.String(), nil
+}
+
+// composeHTML 레이아웃, 본문, 부분 템플릿을 하나의 HTML 템플릿 집합으로 구성
+func (s *Source) composeHTML(body string, funcs htmltemplate.FuncMap) (*htmltemplate.Template, error) {
+	layout := s.Layout != nil && s.Layout.HTML != ""
+	root := body
+	if layout {
+		root = s.Layout.HTML
+	}
+
+	tmpl, err := htmltemplate.New("email").Funcs(htmlFuncs).Funcs(funcs).Parse(root)
+	if err != nil {
+		return nil, err
+	}
+	if layout {
+		if _, err := tmpl.New(ContentTemplate).Parse(body); err != nil {
+			return nil, err
+		}
+	}
+	for name, partial := range s.Partials {
+		if partial.HTML == "" {
+			continue
+		}
+		if _, err := tmpl.New(name).Parse(partial.HTML); err != nil {
+			return nil, fmt.Errorf("partial %q: %v", name, err)
+		}
+	}
+	return tmpl, nil
+}
+
+// composeText 레이아웃, 본문, 부분 템플릿을 하나의 텍스트 템플릿 집합으로 구성
+// 텍스트 본문은 사용자가 그대로 읽으므로 HTML 이스케이프하지 않는다 (e.g., "Tom & Jerry's").
+func (s *Source) composeText(body string, funcs template.FuncMap) (*template.Template, error) {
+	layout := s.Layout != nil && s.Layout.Text != ""
+	root := body
+	if layout {
+		root = s.Layout.Text
+	}
+
+	tmpl, err := template.New("email").Funcs(Funcs).Funcs(funcs).Parse(root)
+	if err != nil {
+		return nil, err
+	}
+	if layout {
+		if _, err := tmpl.New(ContentTemplate).Parse(body); err != nil {
+			return nil, err
+		}
+	}
+	for name, partial := range s.Partials {
+		if partial.Text == "" {
+			continue
+		}
+		if _, err := tmpl.New(name).Parse(partial.Text); err != nil {
+			return nil, fmt.Errorf("partial %q: %v", name, err)
+		}
+	}
+	return tmpl, nil
+}
+
+// composeMarkdown 마크다운 본문과 부분 템플릿을 하나의 텍스트 템플릿 집합으로 구성 (부분 템플릿은 텍스트 본문을 포함한다)
+func (s *Source) composeMarkdown() (*template.Template, error) {
+	tmpl, err := template.New("markdown").Funcs(Funcs).Parse(s.Markdown)
+	if err != nil {
+		return nil, err
+	}
+	for name, partial := range s.Partials {
+		if partial.Text == "" {
+			continue
+		}
+		if _, err := tmpl.New(name).Parse(partial.Text); err != nil {
+			return nil, fmt.Errorf("partial %q: %v", name, err)
+		}
+	}
+	return tmpl, nil
+}
+
+// graph 본문("content")과 부분 템플릿 사이의 포함 관계
+func (s *Source) graph() map[string][]string {
+	graph := make(map[string][]string, len(s.Partials)+1)
+	graph[ContentTemplate], _ = ReferencedTemplates(s.HTML, s.Text, s.Markdown)
+	for name, partial := range s.Partials {
+		graph[name], _ = ReferencedTemplates(partial.HTML, partial.Text)
+	}
+	return graph
+}
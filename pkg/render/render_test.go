@@ -0,0 +1,131 @@
+package render
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+// 특수 문자가 들어간 변수 (HTML 파트에서만 이스케이프되어야 한다)
+var specialVariables = map[string]interface{}{
+	"name":    "Tom & Jerry's <b>",
+	"company": `"Acme" & Co.`,
+	"url":     "https://x.example/a?b=1&c=2",
+}
+
+func TestRenderGolden(t *testing.T) {
+	tests := []struct {
+		name string
+		src  *Source
+	}{
+		{
+			name: "special_characters",
+			src: &Source{
+				Subject: "Hello {{.name}}",
+				HTML:    `<p>Hello {{.name}} from {{.company}}</p><p><a href="{{.url}}">Open</a></p>`,
+				Text:    "Hello {{.name}} from {{.company}}\nOpen: {{.url}}",
+			},
+		},
+		{
+			name: "layout_and_partial",
+			src: &Source{
+				Subject:   "{{.company}} news",
+				HTML:      `<p>Hi {{.name}}</p>{{template "footer" .}}`,
+				Text:      "Hi {{.name}}\n{{template \"footer\" .}}",
+				Preheader: "For {{.name}}",
+				Layout: &Fragment{
+					HTML: `<html><body><h1>{{.company}}</h1>{{template "content" .}}</body></html>`,
+					Text: "{{.company}}\n\n{{template \"content\" .}}",
+				},
+				Partials: map[string]Fragment{
+					"footer": {
+						HTML: `<p class="footer">Sent to {{.name}}</p>`,
+						Text: "-- Sent to {{.name}}",
+					},
+				},
+			},
+		},
+		{
+			// 텍스트 본문이 없으면 HTML에서 생성 (엔티티는 원래 문자로 되돌린다)
+			name: "generated_text",
+			src: &Source{
+				Subject: "Hi",
+				HTML:    `<h1>Welcome {{.name}}</h1><ul><li>{{.company}}</li></ul><a href="{{.url}}">Start</a>`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rendered, err := Render(tt.src, specialVariables)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkGolden(t, tt.name+".subject.txt", rendered.Subject)
+			checkGolden(t, tt.name+".html", rendered.HTML)
+			checkGolden(t, tt.name+".txt", rendered.Text)
+		})
+	}
+}
+
+func checkGolden(t *testing.T, name, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", "golden", name)
+	if *update {
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run go test -update to create it)", err)
+	}
+	if got != string(want) {
+		t.Errorf("%s mismatch\n--- got ---\n%s\n--- want ---\n%s", name, got, want)
+	}
+}
+
+func TestMarkdownVariablesAreEscaped(t *testing.T) {
+	tmpl, err := Compile(&Source{
+		Subject:  "s",
+		Markdown: "Hello {{.name}}\n\n{{if .show}}{{.note}}{{end}}\n\n[Open]({{.url}})\n",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rendered, err := tmpl.Execute(map[string]interface{}{
+		"name": "[Verify](https://evil.example/)",
+		"show": true,
+		"note": "<script>alert(1)</script> **bold** # x",
+		"url":  "https://x.example/a_b-c",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, unwanted := range []string{`href="https://evil.example/"`, "<script>", "<strong>"} {
+		if strings.Contains(rendered.HTML, unwanted) {
+			t.Errorf("HTML contains %q:\n%s", unwanted, rendered.HTML)
+		}
+	}
+	for _, want := range []string{
+		"Hello [Verify](https://evil.example/)",
+		"&lt;script&gt;alert(1)&lt;/script&gt; **bold** # x",
+		`href="https://x.example/a_b-c"`,
+	} {
+		if !strings.Contains(rendered.HTML, want) {
+			t.Errorf("HTML missing %q:\n%s", want, rendered.HTML)
+		}
+	}
+
+	// 텍스트 본문에는 이스케이프 백슬래시가 남지 않는다
+	if !strings.Contains(rendered.Text, "Hello Verify [https://evil.example/]") || strings.Contains(rendered.Text, `\`) {
+		t.Errorf("Text = %q", rendered.Text)
+	}
+}
@@ -0,0 +1,318 @@
+package render
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// TextWidth 자동 생성한 텍스트 본문의 줄 길이 (RFC 5322 권장 78자)
+const TextWidth = 78
+
+// 숨김 요소 (preheader 등)
+var hiddenStylePattern = regexp.MustCompile(`(?i)display\s*:\s*none`)
+
+// 텍스트로 옮기지 않는 요소
+var skippedElements = map[atom.Atom]bool{
+	atom.Head: true, atom.Style: true, atom.Script: true, atom.Title: true, atom.Noscript: true,
+}
+
+// 앞뒤로 단락을 나누는 요소
+var blockElements = map[atom.Atom]bool{
+	atom.P: true, atom.Div: true, atom.Section: true, atom.Article: true, atom.Header: true,
+	atom.Footer: true, atom.Main: true, atom.Aside: true, atom.Nav: true, atom.Blockquote: true,
+	atom.Table: true, atom.Tr: true, atom.Td: true, atom.Th: true, atom.Center: true,
+	atom.Address: true, atom.Figure: true, atom.Figcaption: true, atom.Dl: true, atom.Dt: true, atom.Dd: true,
+}
+
+var headingLevels = map[atom.Atom]int{
+	atom.H1: 1, atom.H2: 2, atom.H3: 3, atom.H4: 4, atom.H5: 5, atom.H6: 6,
+}
+
+type textBlock struct {
+	prefix   string // 첫 줄 앞에 붙는 문자열 (e.g., 목록 기호)
+	indent   string // 나머지 줄의 들여쓰기
+	text     string
+	heading  int
+	pre      bool
+	listItem int // 목록 항목이면 최상위 목록 번호 (같은 목록의 항목은 빈 줄 없이 잇는다)
+}
+
+type listState struct {
+	ordered bool
+	count   int
+}
+
+type textConverter struct {
+	blocks   []textBlock
+	inline   strings.Builder
+	prefix   string
+	indent   string
+	lists    []listState
+	pre      int
+	listItem int
+	listSeq  int
+
+	// 처리 중인 링크의 텍스트 (블록 요소를 만나 inline이 비워져도 유지된다)
+	label *strings.Builder
+}
+
+// HTMLToText 렌더링된 HTML로 읽기 쉬운 텍스트 본문 생성
+// 링크는 "텍스트 [URL]", 목록은 "* 항목" / "1. 항목", 제목은 밑줄로 표시하고 78자에서 줄을 바꾼다.
+func HTMLToText(src string) string {
+	if strings.TrimSpace(src) == "" {
+		return ""
+	}
+
+	root, err := parseHTML(src, documentPattern.MatchString(src))
+	if err != nil {
+		return ""
+	}
+
+	c := &textConverter{}
+	c.walk(root)
+	c.flush(0)
+
+	var b strings.Builder
+	for i, block := range c.blocks {
+		if i > 0 {
+			if block.listItem > 0 && block.listItem == c.blocks[i-1].listItem {
+				b.WriteString("\n")
+			} else {
+				b.WriteString("\n\n")
+			}
+		}
+		b.WriteString(block.format())
+	}
+
+	text := strings.TrimSpace(b.String())
+	if text == "" {
+		return ""
+	}
+	return text + "\n"
+}
+
+func (c *textConverter) walk(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		c.text(n.Data)
+		return
+	case html.ElementNode:
+	case html.DocumentNode:
+		c.children(n)
+		return
+	default:
+		return
+	}
+
+	if skippedElements[n.DataAtom] || hiddenStylePattern.MatchString(getAttr(n, "style")) {
+		return
+	}
+
+	switch {
+	case n.DataAtom == atom.Br:
+		c.inline.WriteString("\n")
+
+	case n.DataAtom == atom.Hr:
+		c.flush(0)
+		c.blocks = append(c.blocks, textBlock{text: strings.Repeat("-", 20), pre: true})
+
+	case n.DataAtom == atom.Img:
+		if alt := strings.TrimSpace(getAttr(n, "alt")); alt != "" {
+			c.text(alt)
+		}
+
+	case n.DataAtom == atom.A:
+		outer := c.label
+		c.label = &strings.Builder{}
+		c.children(n)
+		label := c.label.String()
+		c.label = outer
+		if outer != nil {
+			outer.WriteString(label)
+		}
+		if href := linkTarget(getAttr(n, "href"), strings.TrimSpace(label)); href != "" {
+			c.text(" [" + href + "]")
+		}
+
+	case n.DataAtom == atom.Ul || n.DataAtom == atom.Ol:
+		c.flush(0)
+		if len(c.lists) == 0 {
+			c.listSeq++
+		}
+		c.lists = append(c.lists, listState{ordered: n.DataAtom == atom.Ol})
+		c.children(n)
+		c.flush(0)
+		c.lists = c.lists[:len(c.lists)-1]
+
+	case n.DataAtom == atom.Li:
+		c.flush(0)
+		prefix, indent, listItem := c.prefix, c.indent, c.listItem
+		depth := len(c.lists)
+		marker := "* "
+		if depth > 0 {
+			list := &c.lists[depth-1]
+			list.count++
+			if list.ordered {
+				marker = strconv.Itoa(list.count) + ". "
+			}
+		} else {
+			depth = 1
+		}
+		base := strings.Repeat("  ", depth-1)
+		c.prefix, c.indent, c.listItem = base+marker, base+strings.Repeat(" ", len(marker)), c.listSeq
+		c.children(n)
+		c.flush(0)
+		c.prefix, c.indent, c.listItem = prefix, indent, listItem
+
+	case headingLevels[n.DataAtom] > 0:
+		c.flush(0)
+		c.children(n)
+		c.flush(headingLevels[n.DataAtom])
+
+	case n.DataAtom == atom.Pre:
+		c.flush(0)
+		c.pre++
+		c.children(n)
+		c.pre--
+		c.flushPre()
+
+	case blockElements[n.DataAtom]:
+		c.flush(0)
+		c.children(n)
+		c.flush(0)
+
+	default:
+		c.children(n)
+	}
+}
+
+func (c *textConverter) children(n *html.Node) {
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		c.walk(child)
+	}
+}
+
+// text 인라인 텍스트 추가 (pre 밖에서는 연속 공백을 하나로 줄인다)
+func (c *textConverter) text(s string) {
+	if c.pre == 0 {
+		s = whitespacePattern.ReplaceAllString(s, " ")
+		current := c.inline.String()
+		if strings.HasPrefix(s, " ") && (current == "" || strings.HasSuffix(current, " ") || strings.HasSuffix(current, "\n")) {
+			s = s[1:]
+		}
+	}
+	c.inline.WriteString(s)
+	if c.label != nil {
+		c.label.WriteString(s)
+	}
+}
+
+// flush 모은 인라인 텍스트를 하나의 블록으로 확정
+func (c *textConverter) flush(heading int) {
+	lines := strings.Split(c.inline.String(), "\n")
+	c.inline.Reset()
+
+	var kept []string
+	for _, line := range lines {
+		if line = strings.TrimSpace(line); line != "" {
+			kept = append(kept, line)
+		}
+	}
+	if len(kept) == 0 {
+		return
+	}
+
+	c.blocks = append(c.blocks, textBlock{
+		prefix:   c.prefix,
+		indent:   c.indent,
+		text:     strings.Join(kept, "\n"),
+		heading:  heading,
+		listItem: c.listItem,
+	})
+	// 같은 목록 항목 안의 다음 단락은 들여쓰기만 유지
+	c.prefix = c.indent
+}
+
+func (c *textConverter) flushPre() {
+	text := strings.Trim(c.inline.String(), "\n")
+	c.inline.Reset()
+	if strings.TrimSpace(text) == "" {
+		return
+	}
+	c.blocks = append(c.blocks, textBlock{text: text, pre: true})
+}
+
+// format 블록을 줄 길이에 맞춰 문자열로 변환
+func (b textBlock) format() string {
+	if b.pre {
+		return b.text
+	}
+
+	text := b.text
+	if b.heading > 0 && b.heading <= 2 {
+		underline := "="
+		if b.heading == 2 {
+			underline = "-"
+		}
+		width := utf8.RuneCountInString(text)
+		if width > TextWidth {
+			width = TextWidth
+		}
+		return wrap(text, b.prefix, b.indent, TextWidth) + "\n" + b.indent + strings.Repeat(underline, width)
+	}
+	return wrap(text, b.prefix, b.indent, TextWidth)
+}
+
+// wrap 단어 단위로 줄을 바꾼다 (너비보다 긴 단어는 자르지 않는다)
+func wrap(text, prefix, indent string, width int) string {
+	var out []string
+	first := true
+	for _, paragraph := range strings.Split(text, "\n") {
+		line := indent
+		if first {
+			line = prefix
+			first = false
+		}
+		start := utf8.RuneCountInString(line)
+		length := start
+		for _, word := range strings.Fields(paragraph) {
+			wordLen := utf8.RuneCountInString(word)
+			if length > start && length+1+wordLen > width {
+				out = append(out, line)
+				line, length = indent, utf8.RuneCountInString(indent)
+				start = length
+			}
+			if length > start {
+				line += " "
+				length++
+			}
+			line += word
+			length += wordLen
+		}
+		out = append(out, line)
+	}
+	return strings.Join(out, "\n")
+}
+
+// linkTarget 텍스트 뒤에 표시할 링크 주소 (링크 텍스트와 같거나 문서 내부 링크면 생략)
+func linkTarget(href, label string) string {
+	href = strings.TrimSpace(href)
+	if href == "" || strings.HasPrefix(href, "#") {
+		return ""
+	}
+	if address := strings.TrimPrefix(href, "mailto:"); address != href {
+		if address == label {
+			return ""
+		}
+		return address
+	}
+	if href == label || strings.TrimSuffix(href, "/") == strings.TrimSuffix(label, "/") {
+		return ""
+	}
+	return href
+}
@@ -0,0 +1,75 @@
+package render
+
+import "testing"
+
+func TestHTMLToText(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want string
+	}{
+		{
+			name: "link",
+			html: `<p>Visit <a href="https://x.example/y">our site</a> today</p>`,
+			want: "Visit our site [https://x.example/y] today\n",
+		},
+		{
+			name: "link with the address as text",
+			html: `<p><a href="https://x.example/">https://x.example</a></p>`,
+			want: "https://x.example\n",
+		},
+		{
+			// 링크 안의 블록 요소가 inline을 비워도 링크 텍스트는 유지되어야 한다
+			name: "block element inside link",
+			html: `<div>Some long prefix text <a href="https://x.example/y"><div>Go</div></a></div>`,
+			want: "Some long prefix text\n\nGo\n\n[https://x.example/y]\n",
+		},
+		{
+			name: "block element inside link with matching text",
+			html: `<div>Prefix <a href="https://x.example/y"><div>https://x.example/y</div></a></div>`,
+			want: "Prefix\n\nhttps://x.example/y\n",
+		},
+		{
+			name: "list",
+			html: `<ul><li>one</li><li>two</li></ul><ol><li>first</li></ol>`,
+			want: "* one\n* two\n\n1. first\n",
+		},
+		{
+			name: "heading",
+			html: `<h1>Title</h1><p>body</p>`,
+			want: "Title\n=====\n\nbody\n",
+		},
+		{
+			name: "hidden preheader",
+			html: `<div style="display:none">hidden</div><p>shown</p>`,
+			want: "shown\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HTMLToText(tt.html); got != tt.want {
+				t.Errorf("HTMLToText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExecuteUsesTextTemplate(t *testing.T) {
+	// 텍스트 본문이 있으면 HTML에서 텍스트를 만들지 않는다
+	tmpl, err := Compile(&Source{
+		Subject: "s",
+		HTML:    `<div>Some long prefix text <a href="{{.url}}"><div>Go</div></a></div>`,
+		Text:    "Go to {{.url}}",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rendered, err := tmpl.Execute(map[string]interface{}{"url": "https://x.example/y"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "Go to https://x.example/y"; rendered.Text != want {
+		t.Errorf("Text = %q, want %q", rendered.Text, want)
+	}
+}
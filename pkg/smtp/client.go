@@ -1,11 +1,12 @@
 package smtp
 
 import (
-	"bytes"
+	"context"
+	"crypto/tls"
 	"fmt"
-	"html/template"
+	"net"
 	"net/smtp"
-	"strings"
+	"time"
 )
 
 type SMTPClient struct {
@@ -26,60 +27,95 @@ func NewSMTPClient(host string, port int, username, password, from string) *SMTP
 	}
 }
 
-func (c *SMTPClient) SendEmail(to []string, subject, htmlContent string, textContent string, variables map[string]interface{}) error {
-	// HTML 템플릿 처리
-	htmlTemplate, err := template.New("email").Parse(htmlContent)
-	if err != nil {
-		return fmt.Errorf("failed to parse HTML template: %v", err)
+// NewMessage 발신자가 설정된 메시지 생성
+func (c *SMTPClient) NewMessage(to []string, subject, html, text string, headers map[string]string) *Message {
+	return &Message{
+		From:    c.from,
+		To:      to,
+		Subject: subject,
+		HTML:    html,
+		Text:    text,
+		Headers: headers,
 	}
+}
+
+// SendEmail 렌더링된 이메일 전송
+func (c *SMTPClient) SendEmail(ctx context.Context, msg *Message) error {
+	// SMTP 인증
+	auth := smtp.PlainAuth("", c.username, c.password, c.host)
 
-	var htmlBuffer bytes.Buffer
-	if err := htmlTemplate.Execute(&htmlBuffer, variables); err != nil {
-		return fmt.Errorf("failed to execute HTML template: %v", err)
+	// 이메일 전송
+	if err := c.sendMail(ctx, auth, msg.To, msg.Bytes()); err != nil {
+		return fmt.Errorf("failed to send email: %v", ctxErr(ctx, err))
 	}
 
-	// Text 템플릿 처리
-	textTemplate, err := template.New("email").Parse(textContent)
+	return nil
+}
+
+// sendMail smtp.SendMail과 동일한 절차를 컨텍스트 취소/데드라인을 지키며 수행
+func (c *SMTPClient) sendMail(ctx context.Context, auth smtp.Auth, to []string, msg []byte) error {
+	addr := fmt.Sprintf("%s:%d", c.host, c.port)
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
 	if err != nil {
-		return fmt.Errorf("failed to parse text template: %v", err)
+		return err
 	}
+	defer conn.Close()
 
-	var textBuffer bytes.Buffer
-	if err := textTemplate.Execute(&textBuffer, variables); err != nil {
-		return fmt.Errorf("failed to execute text template: %v", err)
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
 	}
+	// 컨텍스트가 취소되면 진행 중인 읽기/쓰기를 즉시 중단
+	stop := context.AfterFunc(ctx, func() {
+		conn.SetDeadline(time.Now())
+	})
+	defer stop()
 
-	// 이메일 헤더 구성
-	mime := "MIME-version: 1.0;\nContent-Type: multipart/alternative; boundary=\"boundary-string\"\n\n"
-
-	body := fmt.Sprintf("--%s\n"+
-		"Content-Type: text/plain; charset=\"UTF-8\"\n"+
-		"\n%s\n\n"+
-		"--%s\n"+
-		"Content-Type: text/html; charset=\"UTF-8\"\n"+
-		"\n%s\n\n"+
-		"--%s--",
-		"boundary-string",
-		textBuffer.String(),
-		"boundary-string",
-		htmlBuffer.String(),
-		"boundary-string")
-
-	msg := fmt.Sprintf("Subject: %s\nTo: %s\nFrom: %s\n%s\n%s",
-		subject,
-		strings.Join(to, ","),
-		c.from,
-		mime,
-		body)
+	client, err := smtp.NewClient(conn, c.host)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
 
-	// SMTP 인증
-	auth := smtp.PlainAuth("", c.username, c.password, c.host)
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: c.host}); err != nil {
+			return err
+		}
+	}
+	if ok, _ := client.Extension("AUTH"); ok {
+		if err := client.Auth(auth); err != nil {
+			return err
+		}
+	}
 
-	// 이메일 전송
-	addr := fmt.Sprintf("%s:%d", c.host, c.port)
-	if err := smtp.SendMail(addr, auth, c.from, to, []byte(msg)); err != nil {
-		return fmt.Errorf("failed to send email: %v", err)
+	if err := client.Mail(c.from); err != nil {
+		return err
+	}
+	for _, rcpt := range to {
+		if err := client.Rcpt(rcpt); err != nil {
+			return err
+		}
 	}
 
-	return nil
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
+
+// ctxErr 컨텍스트가 끝난 경우 원인 에러를 우선 반환
+func ctxErr(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
 }
@@ -0,0 +1,76 @@
+package smtp
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Message 전송할 이메일 (본문은 렌더링이 끝난 상태)
+type Message struct {
+	From    string
+	To      []string
+	Subject string
+	HTML    string
+	Text    string
+	Headers map[string]string // 추가 헤더 (템플릿 버전 등 추적 정보)
+}
+
+// Bytes RFC 5322 형식의 multipart/alternative 메시지 생성
+func (m *Message) Bytes() []byte {
+	var buf bytes.Buffer
+	boundary := newBoundary()
+
+	writeHeader(&buf, "From", m.From)
+	writeHeader(&buf, "To", strings.Join(m.To, ", "))
+	writeHeader(&buf, "Subject", mime.QEncoding.Encode("UTF-8", m.Subject))
+	writeHeader(&buf, "Date", time.Now().Format(time.RFC1123Z))
+
+	keys := make([]string, 0, len(m.Headers))
+	for k := range m.Headers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		writeHeader(&buf, k, m.Headers[k])
+	}
+
+	writeHeader(&buf, "MIME-Version", "1.0")
+	writeHeader(&buf, "Content-Type", fmt.Sprintf("multipart/alternative; boundary=%q", boundary))
+	buf.WriteString("\r\n")
+
+	writePart(&buf, boundary, "text/plain", m.Text)
+	writePart(&buf, boundary, "text/html", m.HTML)
+	fmt.Fprintf(&buf, "--%s--\r\n", boundary)
+
+	return buf.Bytes()
+}
+
+func writeHeader(buf *bytes.Buffer, key, value string) {
+	// 헤더 인젝션 방지
+	value = strings.NewReplacer("\r", "", "\n", "").Replace(value)
+	fmt.Fprintf(buf, "%s: %s\r\n", key, value)
+}
+
+func writePart(buf *bytes.Buffer, boundary, contentType, body string) {
+	fmt.Fprintf(buf, "--%s\r\n", boundary)
+	fmt.Fprintf(buf, "Content-Type: %s; charset=\"UTF-8\"\r\n", contentType)
+	buf.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+
+	w := quotedprintable.NewWriter(buf)
+	w.Write([]byte(strings.ReplaceAll(body, "\r\n", "\n")))
+	w.Close()
+	buf.WriteString("\r\n")
+}
+
+func newBoundary() string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	return "boundary-" + hex.EncodeToString(b)
+}
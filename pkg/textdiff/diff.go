@@ -0,0 +1,164 @@
+package textdiff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// 편집 종류
+const (
+	OpEqual  = ' '
+	OpInsert = '+'
+	OpDelete = '-'
+)
+
+// 변경 주변에 보여줄 문맥 줄 수
+const contextLines = 3
+
+// Line 편집 스크립트의 한 줄
+type Line struct {
+	Op   byte
+	Text string
+}
+
+// Lines 두 텍스트의 줄 단위 편집 스크립트 (LCS 기반)
+func Lines(a, b string) []Line {
+	x := splitLines(a)
+	y := splitLines(b)
+
+	// lcs[i][j] = x[i:]와 y[j:]의 최장 공통 부분 수열 길이
+	lcs := make([][]int, len(x)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(y)+1)
+	}
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			if x[i] == y[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var lines []Line
+	i, j := 0, 0
+	for i < len(x) && j < len(y) {
+		switch {
+		case x[i] == y[j]:
+			lines = append(lines, Line{Op: OpEqual, Text: x[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			lines = append(lines, Line{Op: OpDelete, Text: x[i]})
+			i++
+		default:
+			lines = append(lines, Line{Op: OpInsert, Text: y[j]})
+			j++
+		}
+	}
+	for ; i < len(x); i++ {
+		lines = append(lines, Line{Op: OpDelete, Text: x[i]})
+	}
+	for ; j < len(y); j++ {
+		lines = append(lines, Line{Op: OpInsert, Text: y[j]})
+	}
+
+	return lines
+}
+
+// Unified unified diff 형식 문자열 (변경이 없으면 빈 문자열)
+func Unified(fromName, toName, a, b string) string {
+	lines := Lines(a, b)
+
+	changed := false
+	for _, l := range lines {
+		if l.Op != OpEqual {
+			changed = true
+			break
+		}
+	}
+	if !changed {
+		return ""
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", fromName, toName)
+
+	// 각 줄의 원본/대상 줄 번호 계산
+	aLine := make([]int, len(lines))
+	bLine := make([]int, len(lines))
+	ai, bi := 1, 1
+	for k, l := range lines {
+		aLine[k], bLine[k] = ai, bi
+		if l.Op != OpInsert {
+			ai++
+		}
+		if l.Op != OpDelete {
+			bi++
+		}
+	}
+
+	for k := 0; k < len(lines); {
+		if lines[k].Op == OpEqual {
+			k++
+			continue
+		}
+
+		// 변경 블록과 문맥을 묶어 hunk 구성
+		start := k - contextLines
+		if start < 0 {
+			start = 0
+		}
+		end := k
+		for end < len(lines) {
+			if lines[end].Op != OpEqual {
+				end++
+				continue
+			}
+			// 다음 변경까지 문맥 범위 안이면 같은 hunk로 합친다
+			next := end
+			for next < len(lines) && lines[next].Op == OpEqual {
+				next++
+			}
+			if next < len(lines) && next-end <= 2*contextLines {
+				end = next
+				continue
+			}
+			end += contextLines
+			if end > len(lines) {
+				end = len(lines)
+			}
+			break
+		}
+
+		aCount, bCount := 0, 0
+		for _, l := range lines[start:end] {
+			if l.Op != OpInsert {
+				aCount++
+			}
+			if l.Op != OpDelete {
+				bCount++
+			}
+		}
+		fmt.Fprintf(&sb, "@@ -%d,%d +%d,%d @@\n", aLine[start], aCount, bLine[start], bCount)
+		for _, l := range lines[start:end] {
+			sb.WriteByte(l.Op)
+			sb.WriteString(l.Text)
+			sb.WriteByte('\n')
+		}
+
+		k = end
+	}
+
+	return sb.String()
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}